@@ -0,0 +1,350 @@
+package bus
+
+import (
+	"errors"
+	"mylife-home-common/tools"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+)
+
+// A broker subscriber: either an in-process client, or a network session
+type brokerSubscriber interface {
+	// Called with the broker lock held, must not block.
+	// filters contains all the subscriptions of the subscriber matching the topic.
+	deliver(filters []string, topic string, payload []byte, retained bool)
+}
+
+type brokerMessage struct {
+	topic    string
+	payload  []byte
+	retained bool
+}
+
+// In-process broker, with retained messages, wildcards and last will support.
+//
+// It can also be exposed on the network (MQTT 3.1.1, QoS 0 only) so that other processes can connect to it.
+type memoryBroker struct {
+	subscriptions map[brokerSubscriber]map[string]struct{}
+	wills         map[brokerSubscriber]*brokerMessage
+	retained      map[string][]byte
+	listener      net.Listener
+	mux           sync.Mutex
+}
+
+func newMemoryBroker() *memoryBroker {
+	return &memoryBroker{
+		subscriptions: make(map[brokerSubscriber]map[string]struct{}),
+		wills:         make(map[brokerSubscriber]*brokerMessage),
+		retained:      make(map[string][]byte),
+	}
+}
+
+func (broker *memoryBroker) attach(subscriber brokerSubscriber, will *brokerMessage) {
+	broker.mux.Lock()
+	defer broker.mux.Unlock()
+
+	broker.subscriptions[subscriber] = make(map[string]struct{})
+
+	if will != nil {
+		broker.wills[subscriber] = will
+	}
+}
+
+// If the detach is not clean, the will message of the subscriber is published
+func (broker *memoryBroker) detach(subscriber brokerSubscriber, clean bool) {
+	broker.mux.Lock()
+	defer broker.mux.Unlock()
+
+	if _, exists := broker.subscriptions[subscriber]; !exists {
+		return
+	}
+
+	will := broker.wills[subscriber]
+	delete(broker.subscriptions, subscriber)
+	delete(broker.wills, subscriber)
+
+	if !clean && will != nil {
+		broker.route(will)
+	}
+}
+
+func (broker *memoryBroker) subscribe(subscriber brokerSubscriber, filters ...string) error {
+	broker.mux.Lock()
+	defer broker.mux.Unlock()
+
+	subscriptions, exists := broker.subscriptions[subscriber]
+	if !exists {
+		return errNotConnected
+	}
+
+	for _, filter := range filters {
+		subscriptions[filter] = struct{}{}
+	}
+
+	for topic, payload := range broker.retained {
+		matches := matchFilters(filters, topic)
+		if len(matches) > 0 {
+			subscriber.deliver(matches, topic, payload, true)
+		}
+	}
+
+	return nil
+}
+
+func (broker *memoryBroker) unsubscribe(subscriber brokerSubscriber, filters ...string) error {
+	broker.mux.Lock()
+	defer broker.mux.Unlock()
+
+	subscriptions, exists := broker.subscriptions[subscriber]
+	if !exists {
+		return errNotConnected
+	}
+
+	for _, filter := range filters {
+		delete(subscriptions, filter)
+	}
+
+	return nil
+}
+
+func (broker *memoryBroker) publish(msg *brokerMessage) {
+	broker.mux.Lock()
+	defer broker.mux.Unlock()
+
+	broker.route(msg)
+}
+
+// Call with the lock held
+func (broker *memoryBroker) route(msg *brokerMessage) {
+	if msg.retained {
+		if len(msg.payload) == 0 {
+			delete(broker.retained, msg.topic)
+		} else {
+			broker.retained[msg.topic] = msg.payload
+		}
+	}
+
+	for subscriber, subscriptions := range broker.subscriptions {
+		matches := make([]string, 0)
+		for filter := range subscriptions {
+			if matchTopic(filter, msg.topic) {
+				matches = append(matches, filter)
+			}
+		}
+
+		// Note: the retain flag is only set when a subscription receives stored messages
+		if len(matches) > 0 {
+			subscriber.deliver(matches, msg.topic, msg.payload, false)
+		}
+	}
+}
+
+// Expose the broker on the network
+func (broker *memoryBroker) listen(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	broker.mux.Lock()
+	broker.listener = listener
+	broker.mux.Unlock()
+
+	logger.Infof("Broker listening on '%s'", listener.Addr())
+
+	go broker.acceptLoop(listener)
+
+	return nil
+}
+
+func (broker *memoryBroker) address() string {
+	broker.mux.Lock()
+	defer broker.mux.Unlock()
+
+	if broker.listener == nil {
+		return ""
+	}
+
+	return broker.listener.Addr().String()
+}
+
+func (broker *memoryBroker) close() {
+	broker.mux.Lock()
+	listener := broker.listener
+	broker.listener = nil
+	broker.mux.Unlock()
+
+	if listener != nil {
+		listener.Close()
+	}
+}
+
+func (broker *memoryBroker) acceptLoop(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.WithError(err).Error("Broker could not accept connection")
+			}
+			return
+		}
+
+		newBrokerNetworkSession(broker, conn)
+	}
+}
+
+var _ brokerSubscriber = (*brokerNetworkSession)(nil)
+
+type brokerNetworkSession struct {
+	broker *memoryBroker
+	conn   net.Conn
+	output chan<- packets.ControlPacket
+}
+
+func newBrokerNetworkSession(broker *memoryBroker, conn net.Conn) *brokerNetworkSession {
+	input, output := tools.BufferedChannel[packets.ControlPacket]()
+
+	session := &brokerNetworkSession{
+		broker: broker,
+		conn:   conn,
+		output: input,
+	}
+
+	go session.writeLoop(output)
+	go session.readLoop()
+
+	return session
+}
+
+func (session *brokerNetworkSession) deliver(filters []string, topic string, payload []byte, retained bool) {
+	pub := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+	pub.TopicName = topic
+	pub.Payload = payload
+	pub.Retain = retained
+	session.output <- pub
+}
+
+func (session *brokerNetworkSession) writeLoop(output <-chan packets.ControlPacket) {
+	for packet := range output {
+		// Errors are handled by the read loop
+		packet.Write(session.conn)
+	}
+}
+
+func (session *brokerNetworkSession) readLoop() {
+	clean := false
+
+	defer func() {
+		session.broker.detach(session, clean)
+		session.conn.Close()
+		close(session.output)
+	}()
+
+	packet, err := packets.ReadPacket(session.conn)
+	if err != nil {
+		return
+	}
+
+	connect, ok := packet.(*packets.ConnectPacket)
+	if !ok {
+		return
+	}
+
+	var will *brokerMessage
+	if connect.WillFlag {
+		will = &brokerMessage{connect.WillTopic, connect.WillMessage, connect.WillRetain}
+	}
+
+	session.broker.attach(session, will)
+
+	ack := packets.NewControlPacket(packets.Connack).(*packets.ConnackPacket)
+	ack.ReturnCode = packets.Accepted
+	session.output <- ack
+
+	for {
+		packet, err := packets.ReadPacket(session.conn)
+		if err != nil {
+			return
+		}
+
+		switch p := packet.(type) {
+		case *packets.SubscribePacket:
+			ack := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+			ack.MessageID = p.MessageID
+			ack.ReturnCodes = make([]byte, len(p.Topics)) // QoS 0 granted
+			session.output <- ack
+
+			session.broker.subscribe(session, p.Topics...)
+
+		case *packets.UnsubscribePacket:
+			session.broker.unsubscribe(session, p.Topics...)
+
+			ack := packets.NewControlPacket(packets.Unsuback).(*packets.UnsubackPacket)
+			ack.MessageID = p.MessageID
+			session.output <- ack
+
+		case *packets.PublishPacket:
+			session.broker.publish(&brokerMessage{p.TopicName, p.Payload, p.Retain})
+
+			switch p.Qos {
+			case 1:
+				ack := packets.NewControlPacket(packets.Puback).(*packets.PubackPacket)
+				ack.MessageID = p.MessageID
+				session.output <- ack
+			case 2:
+				ack := packets.NewControlPacket(packets.Pubrec).(*packets.PubrecPacket)
+				ack.MessageID = p.MessageID
+				session.output <- ack
+			}
+
+		case *packets.PubrelPacket:
+			ack := packets.NewControlPacket(packets.Pubcomp).(*packets.PubcompPacket)
+			ack.MessageID = p.MessageID
+			session.output <- ack
+
+		case *packets.PingreqPacket:
+			session.output <- packets.NewControlPacket(packets.Pingresp)
+
+		case *packets.DisconnectPacket:
+			clean = true
+			return
+		}
+	}
+}
+
+func matchFilters(filters []string, topic string) []string {
+	matches := make([]string, 0)
+
+	for _, filter := range filters {
+		if matchTopic(filter, topic) {
+			matches = append(matches, filter)
+		}
+	}
+
+	return matches
+}
+
+// MQTT topic matching, with '+' and '#' wildcards support
+func matchTopic(filter string, topic string) bool {
+	filterParts := strings.Split(filter, "/")
+	topicParts := strings.Split(topic, "/")
+
+	for index, part := range filterParts {
+		if part == "#" {
+			return true
+		}
+
+		if index >= len(topicParts) {
+			return false
+		}
+
+		if part != "+" && part != topicParts[index] {
+			return false
+		}
+	}
+
+	return len(filterParts) == len(topicParts)
+}
@@ -0,0 +1,78 @@
+package bus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Broker exposed on the network, to run mqtt clients against it
+func newTestBroker(t *testing.T) string {
+	broker := newMemoryBroker()
+	if err := broker.listen("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(broker.close)
+
+	return "tcp://" + broker.address()
+}
+
+type testSubscriber struct {
+	messages []string
+}
+
+func (subscriber *testSubscriber) deliver(filters []string, topic string, payload []byte, retained bool) {
+	flag := ""
+	if retained {
+		flag = " (retained)"
+	}
+
+	subscriber.messages = append(subscriber.messages, topic+"="+string(payload)+flag)
+}
+
+func TestMatchTopic(t *testing.T) {
+	assert.True(t, matchTopic("a/b/c", "a/b/c"))
+	assert.True(t, matchTopic("a/+/c", "a/b/c"))
+	assert.True(t, matchTopic("a/#", "a/b/c"))
+	assert.True(t, matchTopic("#", "a"))
+	assert.False(t, matchTopic("a/+", "a/b/c"))
+	assert.False(t, matchTopic("a/b/c/d", "a/b/c"))
+	assert.False(t, matchTopic("a/x/c", "a/b/c"))
+}
+
+func TestBrokerRetained(t *testing.T) {
+	broker := newMemoryBroker()
+	publisher := &testSubscriber{}
+	subscriber := &testSubscriber{}
+
+	broker.attach(publisher, nil)
+	broker.attach(subscriber, nil)
+
+	broker.publish(&brokerMessage{"a/state/value", []byte("1"), true})
+	broker.publish(&brokerMessage{"a/state/other", []byte("2"), true})
+	broker.publish(&brokerMessage{"a/state/other", []byte{}, true})
+
+	assert.NoError(t, broker.subscribe(subscriber, "a/state/#"))
+	broker.publish(&brokerMessage{"a/state/value", []byte("3"), false})
+
+	assert.Equal(t, []string{"a/state/value=1 (retained)", "a/state/value=3"}, subscriber.messages)
+}
+
+func TestBrokerWill(t *testing.T) {
+	broker := newMemoryBroker()
+	clean := &testSubscriber{}
+	crashed := &testSubscriber{}
+	observer := &testSubscriber{}
+
+	broker.attach(clean, &brokerMessage{"clean/online", []byte{}, true})
+	broker.attach(crashed, &brokerMessage{"crashed/online", []byte{}, true})
+	broker.attach(observer, nil)
+	assert.NoError(t, broker.subscribe(observer, "+/online"))
+
+	broker.detach(clean, true)
+	broker.detach(crashed, false)
+
+	assert.Equal(t, []string{"crashed/online="}, observer.messages)
+	assert.ErrorIs(t, broker.subscribe(crashed, "#"), errNotConnected)
+}
@@ -5,16 +5,17 @@ import (
 	"mylife-home-common/config"
 	"mylife-home-common/tools"
 	"strings"
+	"sync"
 	"time"
 
-	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"golang.org/x/sync/errgroup"
 )
 
 var errClosing = errors.New("client closing")
+var errNotConnected = errors.New("not connected")
 
 type busConfig struct {
-	ServerUrl string `mapstructure:"serverUrl"`
+	Type string `mapstructure:"type"` // mqtt (default) or memory
 }
 
 type OnlineChangedHandler func(bool)
@@ -53,57 +54,49 @@ func (m *message) Retained() bool {
 }
 
 type client struct {
-	instanceName string
-	mqtt         mqtt.Client
-	online       tools.SubjectValue[bool]
+	instanceName  string
+	transport     clientTransport
+	online        tools.SubjectValue[bool]
+	outbound      *outboundQueue
+	subscriptions map[string]*inboundQueue
+	subsMux       sync.Mutex
 }
 
-func newClient(instanceName string) *client {
+func newClient(instanceName string) (*client, error) {
 	conf := busConfig{}
 	config.BindStructure("bus", &conf)
 
 	// Need it in advance
 	client := &client{
-		instanceName: instanceName,
-		online:       tools.MakeSubjectValue[bool](false),
+		instanceName:  instanceName,
+		online:        tools.MakeSubjectValue[bool](false),
+		subscriptions: make(map[string]*inboundQueue),
 	}
 
-	options := mqtt.NewClientOptions()
-	options.AddBroker(conf.ServerUrl)
-	options.SetClientID(instanceName)
-	options.SetCleanSession(true)
-	options.SetResumeSubs(false)
-	options.SetConnectRetry(true)
-	options.SetMaxReconnectInterval(time.Second * 5)
-	options.SetConnectRetryInterval(time.Second * 5)
-	options.SetOrderMatters(false)
-	options.SetConnectRetry(false)
-	options.SetOrderMatters(true)
-
-	options.SetBinaryWill(client.BuildTopic(presenceDomain), []byte{}, 0, true)
-
-	options.SetConnectionLostHandler(func(_ mqtt.Client, err error) {
-		logger.WithError(err).Error("Connection lost")
-		client.online.Update(false)
+	transport, err := makeClientTransport(conf.Type, &clientTransportOptions{
+		ClientId:  instanceName,
+		WillTopic: client.BuildTopic(presenceDomain),
+		OnConnect: client.onConnect,
+		OnConnectionLost: func(err error) {
+			logger.WithError(err).Error("Connection lost")
+			client.online.Update(false)
+		},
 	})
 
-	options.SetOnConnectHandler(func(_ mqtt.Client) {
-		client.onConnect()
-	})
+	if err != nil {
+		return nil, err
+	}
 
-	client.mqtt = mqtt.NewClient(options)
+	client.transport = transport
 
-	// Note: with auto-retry, the connection may not fail, or for a severe reason (eg: bad config)
-	token := client.mqtt.Connect()
-	go func() {
-		client.wait(token)
-	}()
+	client.outbound = newOutboundQueue(client.transport)
+	client.transport.Connect()
 
-	return client
+	return client, nil
 }
 
 func (client *client) Terminate() {
-	if client.mqtt.IsConnectionOpen() {
+	if client.transport.IsConnected() {
 		if err := client.ClearRetain(client.BuildTopic(presenceDomain)); err != nil {
 			logger.WithError(err).Errorf("Error clearing presence")
 		}
@@ -113,7 +106,9 @@ func (client *client) Terminate() {
 		}
 	}
 
-	client.mqtt.Disconnect(100)
+	client.outbound.terminate()
+	client.clearSubscriptions()
+	client.transport.Disconnect()
 }
 
 func (client *client) InstanceName() string {
@@ -123,7 +118,7 @@ func (client *client) InstanceName() string {
 func (client *client) onConnect() {
 	go func() {
 		if err := client.clearResidentState(); err != nil {
-			if !client.mqtt.IsConnectionOpen() {
+			if !client.transport.IsConnected() {
 				return
 			}
 
@@ -133,7 +128,7 @@ func (client *client) onConnect() {
 		}
 
 		if err := client.PublishRetain(client.BuildTopic(presenceDomain), Encoding.WriteBool(true)); err != nil {
-			if !client.mqtt.IsConnectionOpen() {
+			if !client.transport.IsConnected() {
 				return
 			}
 
@@ -214,21 +209,37 @@ func (client *client) ClearRetain(topic string) error {
 	return client.PublishRetain(topic, []byte{})
 }
 
+// Note: blocking until the message is sent, the order of publication is preserved
 func (client *client) PublishRetain(topic string, payload []byte) error {
-	return client.wait(client.mqtt.Publish(topic, 0, true, payload))
+	return client.outbound.send(topic, payload, true)
 }
 
+// Note: blocking until the message is sent, the order of publication is preserved
 func (client *client) Publish(topic string, payload []byte) error {
-	return client.wait(client.mqtt.Publish(topic, 0, false, payload))
+	return client.outbound.send(topic, payload, false)
+}
+
+// Note: not blocking, the order of publication is preserved, errors are logged
+func (client *client) PostRetain(topic string, payload []byte) {
+	client.outbound.post(topic, payload, true)
 }
 
+// Note: not blocking, the order of publication is preserved, errors are logged
+func (client *client) Post(topic string, payload []byte) {
+	client.outbound.post(topic, payload, false)
+}
+
+// Note: callback calls are sequential and keep the order of the messages received on the topic.
+// It may block without blocking other subscriptions.
 func (client *client) Subscribe(topic string, callback func(m *message)) error {
+	queue := newInboundQueue(callback)
+	client.setSubscription(topic, queue)
 
-	cb := func(_ mqtt.Client, m mqtt.Message) {
-		// logger.Debugf("Got message %s", m.Topic())
+	handler := func(topic string, payload []byte, retained bool) {
+		// logger.Debugf("Got message %s", topic)
 
 		var instanceName, domain, path string
-		parts := strings.SplitN(m.Topic(), "/", 3)
+		parts := strings.SplitN(topic, "/", 3)
 		count := len(parts)
 
 		if count > 0 {
@@ -241,28 +252,64 @@ func (client *client) Subscribe(topic string, callback func(m *message)) error {
 			path = parts[2]
 		}
 
-		callback(&message{
-			topic:        m.Topic(),
+		queue.push(&message{
+			topic:        topic,
 			instanceName: instanceName,
 			domain:       domain,
 			path:         path,
-			payload:      m.Payload(),
-			retained:     m.Retained(),
+			payload:      payload,
+			retained:     retained,
 		})
 	}
 
-	return client.wait(client.mqtt.Subscribe(topic, 0, cb))
+	if err := client.transport.Subscribe(topic, handler); err != nil {
+		client.removeSubscriptions(topic)
+		return err
+	}
+
+	return nil
 }
 
 func (client *client) Unsubscribe(topics ...string) error {
-	if !client.mqtt.IsConnectionOpen() {
+	client.removeSubscriptions(topics...)
+
+	if !client.transport.IsConnected() {
 		return nil
 	}
 
-	return client.wait(client.mqtt.Unsubscribe(topics...))
+	return client.transport.Unsubscribe(topics...)
 }
 
-func (client *client) wait(token mqtt.Token) error {
-	token.Wait()
-	return token.Error()
+func (client *client) setSubscription(topic string, queue *inboundQueue) {
+	client.subsMux.Lock()
+	defer client.subsMux.Unlock()
+
+	// Subscribing again on the same topic replaces the previous handler
+	if previous, exists := client.subscriptions[topic]; exists {
+		previous.terminate()
+	}
+
+	client.subscriptions[topic] = queue
+}
+
+func (client *client) removeSubscriptions(topics ...string) {
+	client.subsMux.Lock()
+	defer client.subsMux.Unlock()
+
+	for _, topic := range topics {
+		if queue, exists := client.subscriptions[topic]; exists {
+			queue.terminate()
+			delete(client.subscriptions, topic)
+		}
+	}
+}
+
+func (client *client) clearSubscriptions() {
+	client.subsMux.Lock()
+	defer client.subsMux.Unlock()
+
+	for topic, queue := range client.subscriptions {
+		queue.terminate()
+		delete(client.subscriptions, topic)
+	}
 }
@@ -0,0 +1,219 @@
+package bus
+
+import (
+	"fmt"
+	"mylife-home-common/config"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testTimeout = time.Second * 10
+
+// Empty url means memory transport
+func newTestClient(t *testing.T, serverUrl string, instanceName string) *client {
+	content := "bus:\n  type: memory\n"
+	if serverUrl != "" {
+		content = fmt.Sprintf("bus:\n  type: mqtt\n  serverUrl: %s\n", serverUrl)
+	}
+
+	configFile := path.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config.Init(configFile)
+
+	client, err := newClient(instanceName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(client.Terminate)
+
+	onlineChan := make(chan bool, 10)
+	client.Online().Subscribe(onlineChan, true)
+	defer client.Online().Unsubscribe(onlineChan)
+
+	timeout := time.After(testTimeout)
+	for {
+		select {
+		case online := <-onlineChan:
+			if online {
+				return client
+			}
+		case <-timeout:
+			t.Fatalf("client '%s' could not connect", instanceName)
+		}
+	}
+}
+
+type sequenceRecorder struct {
+	values []uint32
+	done   chan struct{}
+	count  int
+	mux    sync.Mutex
+}
+
+func newSequenceRecorder(count int) *sequenceRecorder {
+	return &sequenceRecorder{
+		values: make([]uint32, 0, count),
+		done:   make(chan struct{}),
+		count:  count,
+	}
+}
+
+func (recorder *sequenceRecorder) handler(data []byte) {
+	recorder.mux.Lock()
+	defer recorder.mux.Unlock()
+
+	recorder.values = append(recorder.values, Encoding.ReadUInt32(data))
+	if len(recorder.values) == recorder.count {
+		close(recorder.done)
+	}
+}
+
+func (recorder *sequenceRecorder) check(t *testing.T, name string) {
+	select {
+	case <-recorder.done:
+	case <-time.After(testTimeout):
+		recorder.mux.Lock()
+		defer recorder.mux.Unlock()
+		t.Fatalf("%s: timeout, got %d/%d values", name, len(recorder.values), recorder.count)
+	}
+
+	recorder.mux.Lock()
+	defer recorder.mux.Unlock()
+
+	for index, value := range recorder.values {
+		if !assert.Equal(t, uint32(index), value, "%s: out of order value", name) {
+			return
+		}
+	}
+}
+
+// Run the test on each transport, serverUrl is empty for memory transport
+func runOnTransports(t *testing.T, test func(t *testing.T, serverUrl string)) {
+	t.Run("mqtt", func(t *testing.T) {
+		test(t, newTestBroker(t))
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		test(t, "")
+	})
+}
+
+func TestActionsOrder(t *testing.T) {
+	runOnTransports(t, testActionsOrder)
+}
+
+func testActionsOrder(t *testing.T, serverUrl string) {
+	const count = 1000
+
+	clientA := newTestClient(t, serverUrl, "test-a")
+	clientB := newTestClient(t, serverUrl, "test-b")
+
+	local := newComponents(clientA).AddLocalComponent("comp")
+	recorder := newSequenceRecorder(count)
+	local.RegisterAction("action", recorder.handler)
+
+	remote := newComponents(clientB).TrackRemoteComponent("test-a", "comp")
+
+	for index := 0; index < count; index += 1 {
+		remote.EmitAction("action", Encoding.WriteUInt32(uint32(index)))
+	}
+
+	recorder.check(t, "action")
+}
+
+func TestOrderUnderLoad(t *testing.T) {
+	runOnTransports(t, testOrderUnderLoad)
+}
+
+func testOrderUnderLoad(t *testing.T, serverUrl string) {
+	const componentCount = 10
+	const memberCount = 3
+	const count = 500
+
+	clientA := newTestClient(t, serverUrl, "test-a")
+	clientB := newTestClient(t, serverUrl, "test-b")
+
+	componentsA := newComponents(clientA)
+	componentsB := newComponents(clientB)
+
+	type memberData struct {
+		name     string
+		recorder *sequenceRecorder
+		emit     func(data []byte)
+	}
+
+	members := make([]*memberData, 0)
+
+	for compIndex := 0; compIndex < componentCount; compIndex += 1 {
+		id := fmt.Sprintf("comp-%d", compIndex)
+		local := componentsA.AddLocalComponent(id)
+		remote := componentsB.TrackRemoteComponent("test-a", id)
+
+		for memberIndex := 0; memberIndex < memberCount; memberIndex += 1 {
+			stateName := fmt.Sprintf("state-%d", memberIndex)
+			stateData := &memberData{
+				name:     id + "." + stateName,
+				recorder: newSequenceRecorder(count),
+				emit:     func(data []byte) { local.SetState(stateName, data) },
+			}
+			remote.RegisterStateChange(stateName, stateData.recorder.handler)
+
+			actionName := fmt.Sprintf("action-%d", memberIndex)
+			actionData := &memberData{
+				name:     id + "." + actionName,
+				recorder: newSequenceRecorder(count),
+				emit:     func(data []byte) { remote.EmitAction(actionName, data) },
+			}
+			local.RegisterAction(actionName, actionData.recorder.handler)
+
+			members = append(members, stateData, actionData)
+		}
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(members))
+
+	for _, member := range members {
+		member := member
+		go func() {
+			defer wg.Done()
+
+			for index := 0; index < count; index += 1 {
+				member.emit(Encoding.WriteUInt32(uint32(index)))
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for _, member := range members {
+		member.recorder.check(t, member.name)
+	}
+}
+
+func TestMemoryClientWill(t *testing.T) {
+	observer := newTestClient(t, "", "test-observer")
+	dropped := newTestClient(t, "", "test-dropped")
+
+	presences := make(chan string, 10)
+	err := observer.Subscribe(dropped.BuildTopic(presenceDomain), func(m *message) {
+		presences <- string(m.Payload())
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, string(Encoding.WriteBool(true)), <-presences)
+
+	dropped.transport.(*memoryClientTransport).drop()
+
+	assert.Equal(t, "", <-presences)
+	assert.False(t, dropped.Online().Get())
+}
@@ -0,0 +1,50 @@
+package bus
+
+import (
+	"fmt"
+)
+
+const defaultClientTransportType = "mqtt"
+
+type clientTransportOptions struct {
+	ClientId         string
+	WillTopic        string // will message is an empty retained payload
+	OnConnect        func()
+	OnConnectionLost func(err error)
+}
+
+// Low-level connection to the broker, used by the client
+type clientTransport interface {
+	// Note: not blocking, OnConnect is called when the connection is established
+	Connect()
+	Disconnect()
+	IsConnected() bool
+
+	// Note: blocking until the message is sent
+	Publish(topic string, payload []byte, retained bool) error
+	// Note: handler calls must be sequential and must not block
+	Subscribe(topic string, handler func(topic string, payload []byte, retained bool)) error
+	Unsubscribe(topics ...string) error
+}
+
+// Note: configuration errors are returned, so that they are reported at startup
+type clientTransportFactory = func(options *clientTransportOptions) (clientTransport, error)
+
+var clientTransportRegistry = make(map[string]clientTransportFactory)
+
+func makeClientTransport(typ string, options *clientTransportOptions) (clientTransport, error) {
+	if typ == "" {
+		typ = defaultClientTransportType
+	}
+
+	factory, ok := clientTransportRegistry[typ]
+	if !ok {
+		return nil, fmt.Errorf("invalid bus type: '%s'", typ)
+	}
+
+	return factory(options)
+}
+
+func registerClientTransport(typ string, factory clientTransportFactory) {
+	clientTransportRegistry[typ] = factory
+}
@@ -0,0 +1,141 @@
+package bus
+
+import (
+	"errors"
+	"mylife-home-common/config"
+	"sync"
+)
+
+var errConnectionDropped = errors.New("connection dropped")
+
+type memoryConfig struct {
+	Listen string `mapstructure:"listen"` // optional, eg: ':1883' to let other processes connect with mqtt
+}
+
+// Shared by all the clients of the process
+var sharedBroker *memoryBroker
+var sharedBrokerInit sync.Once
+
+func getSharedBroker() *memoryBroker {
+	sharedBrokerInit.Do(func() {
+		conf := memoryConfig{}
+		config.BindStructure("bus", &conf)
+
+		sharedBroker = newMemoryBroker()
+
+		if conf.Listen != "" {
+			if err := sharedBroker.listen(conf.Listen); err != nil {
+				logger.WithError(err).Errorf("Could not listen on '%s'", conf.Listen)
+			}
+		}
+	})
+
+	return sharedBroker
+}
+
+var _ clientTransport = (*memoryClientTransport)(nil)
+var _ brokerSubscriber = (*memoryClientTransport)(nil)
+
+type memoryClientTransport struct {
+	broker    *memoryBroker
+	options   *clientTransportOptions
+	handlers  map[string]func(topic string, payload []byte, retained bool)
+	connected bool
+	mux       sync.Mutex
+}
+
+func newMemoryClientTransport(options *clientTransportOptions) (clientTransport, error) {
+	return &memoryClientTransport{
+		broker:   getSharedBroker(),
+		options:  options,
+		handlers: make(map[string]func(topic string, payload []byte, retained bool)),
+	}, nil
+}
+
+func (transport *memoryClientTransport) Connect() {
+	go func() {
+		transport.broker.attach(transport, &brokerMessage{transport.options.WillTopic, []byte{}, true})
+
+		transport.mux.Lock()
+		transport.connected = true
+		transport.mux.Unlock()
+
+		transport.options.OnConnect()
+	}()
+}
+
+func (transport *memoryClientTransport) Disconnect() {
+	transport.close(true)
+}
+
+// Lose the connection without a clean disconnect, like a crashed process: the will is published
+func (transport *memoryClientTransport) drop() {
+	transport.close(false)
+	transport.options.OnConnectionLost(errConnectionDropped)
+}
+
+func (transport *memoryClientTransport) close(clean bool) {
+	transport.mux.Lock()
+	transport.connected = false
+	transport.handlers = make(map[string]func(topic string, payload []byte, retained bool))
+	transport.mux.Unlock()
+
+	transport.broker.detach(transport, clean)
+}
+
+func (transport *memoryClientTransport) IsConnected() bool {
+	transport.mux.Lock()
+	defer transport.mux.Unlock()
+
+	return transport.connected
+}
+
+func (transport *memoryClientTransport) Publish(topic string, payload []byte, retained bool) error {
+	if !transport.IsConnected() {
+		return errNotConnected
+	}
+
+	transport.broker.publish(&brokerMessage{topic, payload, retained})
+	return nil
+}
+
+func (transport *memoryClientTransport) Subscribe(topic string, handler func(topic string, payload []byte, retained bool)) error {
+	transport.mux.Lock()
+	transport.handlers[topic] = handler
+	transport.mux.Unlock()
+
+	if err := transport.broker.subscribe(transport, topic); err != nil {
+		transport.mux.Lock()
+		delete(transport.handlers, topic)
+		transport.mux.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+func (transport *memoryClientTransport) Unsubscribe(topics ...string) error {
+	transport.mux.Lock()
+	for _, topic := range topics {
+		delete(transport.handlers, topic)
+	}
+	transport.mux.Unlock()
+
+	return transport.broker.unsubscribe(transport, topics...)
+}
+
+// Called by the broker, with its lock held
+func (transport *memoryClientTransport) deliver(filters []string, topic string, payload []byte, retained bool) {
+	transport.mux.Lock()
+	defer transport.mux.Unlock()
+
+	for _, filter := range filters {
+		if handler, exists := transport.handlers[filter]; exists {
+			handler(topic, payload, retained)
+		}
+	}
+}
+
+func init() {
+	registerClientTransport("memory", newMemoryClientTransport)
+}
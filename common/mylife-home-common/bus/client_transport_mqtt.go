@@ -0,0 +1,182 @@
+package bus
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"mylife-home-common/config"
+	"net/url"
+	"os"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// Note: values can be set from the environment, eg: 'password: ${BUS_PASSWORD}'
+type mqttConfig struct {
+	ServerUrl string        `mapstructure:"serverUrl"` // tcp://, ssl://, ws:// or wss://
+	Username  string        `mapstructure:"username"`
+	Password  string        `mapstructure:"password"`
+	Tls       mqttTlsConfig `mapstructure:"tls"`
+}
+
+type mqttTlsConfig struct {
+	Ca                 string `mapstructure:"ca"`   // PEM file, system pool if not set
+	Cert               string `mapstructure:"cert"` // PEM file, for client certificate authentication
+	Key                string `mapstructure:"key"`  // PEM file
+	InsecureSkipVerify bool   `mapstructure:"insecureSkipVerify"`
+}
+
+func (conf *mqttTlsConfig) isSet() bool {
+	return conf.Ca != "" || conf.Cert != "" || conf.Key != "" || conf.InsecureSkipVerify
+}
+
+var _ clientTransport = (*mqttClientTransport)(nil)
+
+type mqttClientTransport struct {
+	mqtt mqtt.Client
+}
+
+func newMqttClientTransport(opts *clientTransportOptions) (clientTransport, error) {
+	conf := mqttConfig{}
+	config.BindStructure("bus", &conf)
+
+	options := mqtt.NewClientOptions()
+	options.AddBroker(conf.ServerUrl)
+	options.SetClientID(opts.ClientId)
+
+	if conf.Username != "" {
+		options.SetUsername(conf.Username)
+		options.SetPassword(conf.Password)
+	}
+
+	tlsConfig, err := makeTlsConfig(&conf)
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConfig != nil {
+		options.SetTLSConfig(tlsConfig)
+	}
+	options.SetCleanSession(true)
+	options.SetResumeSubs(false)
+	options.SetConnectRetry(true)
+	options.SetMaxReconnectInterval(time.Second * 5)
+	options.SetConnectRetryInterval(time.Second * 5)
+	options.SetOrderMatters(false)
+	options.SetConnectRetry(false)
+	options.SetOrderMatters(true)
+
+	options.SetBinaryWill(opts.WillTopic, []byte{}, 0, true)
+
+	options.SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+		opts.OnConnectionLost(err)
+	})
+
+	options.SetOnConnectHandler(func(_ mqtt.Client) {
+		opts.OnConnect()
+	})
+
+	return &mqttClientTransport{
+		mqtt: mqtt.NewClient(options),
+	}, nil
+}
+
+// Returns nil if TLS is not used
+func makeTlsConfig(conf *mqttConfig) (*tls.Config, error) {
+	serverUrl, err := url.Parse(conf.ServerUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bus server url '%s': %w", conf.ServerUrl, err)
+	}
+
+	secure := false
+	switch serverUrl.Scheme {
+	case "tcp", "mqtt", "ws":
+	case "ssl", "tls", "mqtts", "tcps", "wss":
+		secure = true
+	default:
+		return nil, fmt.Errorf("unsupported bus server url scheme '%s'", serverUrl.Scheme)
+	}
+
+	if !secure {
+		if conf.Tls.isSet() {
+			logger.Warnf("TLS configuration is ignored with bus server url '%s'", conf.ServerUrl)
+		}
+
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: conf.Tls.InsecureSkipVerify,
+	}
+
+	if conf.Tls.InsecureSkipVerify {
+		logger.Warn("TLS server certificate verification is disabled")
+	}
+
+	if conf.Tls.Ca != "" {
+		pem, err := os.ReadFile(conf.Tls.Ca)
+		if err != nil {
+			return nil, fmt.Errorf("could not read bus CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in bus CA file '%s'", conf.Tls.Ca)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if conf.Tls.Cert != "" || conf.Tls.Key != "" {
+		cert, err := tls.LoadX509KeyPair(conf.Tls.Cert, conf.Tls.Key)
+		if err != nil {
+			return nil, fmt.Errorf("could not load bus client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+func (transport *mqttClientTransport) Connect() {
+	// Note: with auto-retry, the connection may not fail, or for a severe reason (eg: bad config)
+	token := transport.mqtt.Connect()
+	go func() {
+		transport.wait(token)
+	}()
+}
+
+func (transport *mqttClientTransport) Disconnect() {
+	transport.mqtt.Disconnect(100)
+}
+
+func (transport *mqttClientTransport) IsConnected() bool {
+	return transport.mqtt.IsConnectionOpen()
+}
+
+func (transport *mqttClientTransport) Publish(topic string, payload []byte, retained bool) error {
+	return transport.wait(transport.mqtt.Publish(topic, 0, retained, payload))
+}
+
+func (transport *mqttClientTransport) Subscribe(topic string, handler func(topic string, payload []byte, retained bool)) error {
+	cb := func(_ mqtt.Client, m mqtt.Message) {
+		handler(m.Topic(), m.Payload(), m.Retained())
+	}
+
+	return transport.wait(transport.mqtt.Subscribe(topic, 0, cb))
+}
+
+func (transport *mqttClientTransport) Unsubscribe(topics ...string) error {
+	return transport.wait(transport.mqtt.Unsubscribe(topics...))
+}
+
+func (transport *mqttClientTransport) wait(token mqtt.Token) error {
+	token.Wait()
+	return token.Error()
+}
+
+func init() {
+	registerClientTransport("mqtt", newMqttClientTransport)
+}
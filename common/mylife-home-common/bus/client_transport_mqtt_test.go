@@ -0,0 +1,52 @@
+package bus
+
+import (
+	"mylife-home-common/config"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeTlsConfig(t *testing.T) {
+	tlsConfig, err := makeTlsConfig(&mqttConfig{ServerUrl: "tcp://localhost:1883"})
+	assert.NoError(t, err)
+	assert.Nil(t, tlsConfig)
+
+	tlsConfig, err = makeTlsConfig(&mqttConfig{ServerUrl: "wss://localhost/mqtt", Tls: mqttTlsConfig{InsecureSkipVerify: true}})
+	assert.NoError(t, err)
+	assert.True(t, tlsConfig.InsecureSkipVerify)
+	assert.Nil(t, tlsConfig.RootCAs)
+
+	_, err = makeTlsConfig(&mqttConfig{ServerUrl: "http://localhost"})
+	assert.Error(t, err)
+
+	invalidCa := path.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(invalidCa, []byte("invalid"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = makeTlsConfig(&mqttConfig{ServerUrl: "ssl://localhost:8883", Tls: mqttTlsConfig{Ca: invalidCa}})
+	assert.Error(t, err)
+
+	_, err = makeTlsConfig(&mqttConfig{ServerUrl: "ssl://localhost:8883", Tls: mqttTlsConfig{Cert: "missing.pem", Key: "missing.key"}})
+	assert.Error(t, err)
+}
+
+func TestNewClientInvalidConfig(t *testing.T) {
+	for _, content := range []string{
+		"bus:\n  type: unknown\n",
+		"bus:\n  serverUrl: ssl://localhost:8883\n  tls:\n    ca: missing.pem\n",
+	} {
+		configFile := path.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		config.Init(configFile)
+
+		_, err := newClient("test-invalid")
+		assert.Error(t, err)
+	}
+}
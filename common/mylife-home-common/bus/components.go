@@ -3,16 +3,16 @@ package bus
 const componentsDomain = "components"
 
 type LocalComponent interface {
-	// Note: blocking
+	// Note: blocking, handler calls are sequential and keep the order of the messages
 	RegisterAction(name string, handler func([]byte))
-	// Note: blocking
+	// Note: not blocking, the order of the calls is preserved on the bus
 	SetState(name string, value []byte)
 }
 
 type RemoteComponent interface {
-	// Note: blocking
+	// Note: not blocking, the order of the calls is preserved on the bus
 	EmitAction(name string, value []byte)
-	// Note: blocking
+	// Note: blocking, handler calls are sequential and keep the order of the messages
 	RegisterStateChange(name string, handler func([]byte))
 }
 
@@ -99,19 +99,14 @@ func (disp *dispatcher) Terminate() {
 	}
 }
 
-// Note: blocking
+// Note: not blocking, messages are enqueued in order
 func (disp *dispatcher) Emit(memberName string, value []byte, persistent bool) {
 	topic := disp.buildTopic(memberName)
 
-	var err error
 	if persistent {
-		err = disp.client.PublishRetain(topic, value)
+		disp.client.PostRetain(topic, value)
 	} else {
-		err = disp.client.Publish(topic, value)
-	}
-
-	if err != nil {
-		logger.WithError(err).Errorf("Could not publish topic: %s", topic)
+		disp.client.Post(topic, value)
 	}
 }
 
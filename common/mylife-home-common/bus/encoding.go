@@ -76,6 +76,16 @@ func (e *encodingImpl) WriteInt32(value int32) []byte {
 	return e.write(value)
 }
 
+func (e *encodingImpl) ReadInt64(buffer []byte) int64 {
+	var data int64
+	e.read(buffer, &data)
+	return data
+}
+
+func (e *encodingImpl) WriteInt64(value int64) []byte {
+	return e.write(value)
+}
+
 func (e *encodingImpl) ReadFloat(buffer []byte) float64 {
 	// Protocol uses float32
 	var data float32
@@ -146,6 +156,12 @@ func (e *encodingImpl) WriteValue(typ metadata.Type, value any) []byte {
 
 	case *metadata.ComplexType:
 		return e.WriteJson(value)
+
+	case *metadata.NullableType:
+		return e.writeNullable(realType, value)
+
+	case *metadata.RecordType, *metadata.ArrayType:
+		return e.WriteJson(value)
 	}
 
 	panic(fmt.Errorf("unsupported type %s", typ.String()))
@@ -170,6 +186,12 @@ func (e *encodingImpl) ReadValue(typ metadata.Type, raw []byte) any {
 
 	case *metadata.ComplexType:
 		return e.ReadJson(raw)
+
+	case *metadata.NullableType:
+		return e.readNullable(realType, raw)
+
+	case *metadata.RecordType, *metadata.ArrayType:
+		return e.readStructured(realType, raw)
 	}
 
 	panic(fmt.Errorf("unsupported type %s", typ.String()))
@@ -199,7 +221,7 @@ func (e *encodingImpl) writeRange(typ *metadata.RangeType, value int64) []byte {
 		return e.WriteInt32(int32(value))
 	}
 
-	panic(fmt.Errorf("Cannot represent range type with min=%d and max=%d because bounds are too big", typ.Min(), typ.Max()))
+	return e.WriteInt64(value)
 }
 
 func (e *encodingImpl) readRange(typ *metadata.RangeType, raw []byte) int64 {
@@ -219,5 +241,150 @@ func (e *encodingImpl) readRange(typ *metadata.RangeType, raw []byte) int64 {
 		return int64(e.ReadInt32(raw))
 	}
 
-	panic(fmt.Errorf("Cannot represent range type with min=%d and max=%d because bounds are too big", typ.Min(), typ.Max()))
+	return e.ReadInt64(raw)
+}
+
+// Nullable values are prefixed by a presence byte: 0 for nil, 1 followed by the inner value.
+// Note: an empty payload cannot be used since it clears retained states.
+func (e *encodingImpl) writeNullable(typ *metadata.NullableType, value any) []byte {
+	if value == nil {
+		return []byte{0}
+	}
+
+	return append([]byte{1}, e.WriteValue(typ.Inner(), value)...)
+}
+
+func (e *encodingImpl) readNullable(typ *metadata.NullableType, raw []byte) any {
+	if len(raw) == 0 {
+		panic(fmt.Errorf("could not read nullable value from empty buffer"))
+	}
+
+	if raw[0] == 0 {
+		return nil
+	}
+
+	return e.ReadValue(typ.Inner(), raw[1:])
+}
+
+// Read a value written as plain JSON (eg: with json.Marshal), converted to the values expected by the type
+func (e *encodingImpl) ReadJsonValue(typ metadata.Type, raw []byte) (value any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			value = nil
+			err = fmt.Errorf("could not read value as %s: %v", typ.String(), r)
+		}
+	}()
+
+	value = e.readStructured(typ, raw)
+	if !typ.Validate(value) {
+		return nil, fmt.Errorf("invalid value %v for type %s", value, typ.String())
+	}
+
+	return value, nil
+}
+
+// Structured values are JSON encoded, then converted back to the values expected by the type (eg: int64 for ranges)
+func (e *encodingImpl) readStructured(typ metadata.Type, raw []byte) any {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		panic(err)
+	}
+
+	return e.fromJson(typ, value)
+}
+
+func (e *encodingImpl) fromJson(typ metadata.Type, value any) any {
+	switch realType := typ.(type) {
+	case *metadata.RangeType:
+		intValue, err := e.jsonNumber(typ, value).Int64()
+		if err != nil {
+			panic(fmt.Errorf("could not read value %v as %s: %w", value, typ.String(), err))
+		}
+		return intValue
+
+	case *metadata.FloatType:
+		floatValue, err := e.jsonNumber(typ, value).Float64()
+		if err != nil {
+			panic(fmt.Errorf("could not read value %v as %s: %w", value, typ.String(), err))
+		}
+		return floatValue
+
+	case *metadata.TextType, *metadata.EnumType, *metadata.BoolType:
+		return value
+
+	case *metadata.ComplexType:
+		return e.fromUntypedJson(value)
+
+	case *metadata.NullableType:
+		if value == nil {
+			return nil
+		}
+
+		return e.fromJson(realType.Inner(), value)
+
+	case *metadata.RecordType:
+		mapValue, ok := value.(map[string]any)
+		if !ok {
+			panic(fmt.Errorf("could not read value %v as %s", value, typ.String()))
+		}
+
+		for index := 0; index < realType.NumFields(); index += 1 {
+			field := realType.FieldAt(index)
+			if fieldValue, exists := mapValue[field.Name()]; exists {
+				mapValue[field.Name()] = e.fromJson(field.ValueType(), fieldValue)
+			}
+		}
+
+		return mapValue
+
+	case *metadata.ArrayType:
+		sliceValue, ok := value.([]any)
+		if !ok {
+			panic(fmt.Errorf("could not read value %v as %s", value, typ.String()))
+		}
+
+		for index, item := range sliceValue {
+			sliceValue[index] = e.fromJson(realType.Item(), item)
+		}
+
+		return sliceValue
+	}
+
+	panic(fmt.Errorf("unsupported type %s", typ.String()))
+}
+
+func (e *encodingImpl) jsonNumber(typ metadata.Type, value any) json.Number {
+	number, ok := value.(json.Number)
+	if !ok {
+		panic(fmt.Errorf("could not read value %v as %s", value, typ.String()))
+	}
+
+	return number
+}
+
+// Untyped values are read as encoding/json does by default (float64 for numbers)
+func (e *encodingImpl) fromUntypedJson(value any) any {
+	switch typedValue := value.(type) {
+	case json.Number:
+		floatValue, _ := typedValue.Float64()
+		return floatValue
+
+	case map[string]any:
+		for key, item := range typedValue {
+			typedValue[key] = e.fromUntypedJson(item)
+		}
+		return typedValue
+
+	case []any:
+		for index, item := range typedValue {
+			typedValue[index] = e.fromUntypedJson(item)
+		}
+		return typedValue
+
+	default:
+		return value
+	}
 }
@@ -0,0 +1,79 @@
+package bus
+
+import (
+	"mylife-home-common/components/metadata"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testValueRoundTrip(t *testing.T, typ metadata.Type, value any, size int) {
+	raw := Encoding.WriteValue(typ, value)
+	assert.Len(t, raw, size, "%s: %v", typ.String(), value)
+	assert.Equal(t, value, Encoding.ReadValue(typ, raw), "%s: %v", typ.String(), value)
+}
+
+func TestRangeEncoding(t *testing.T) {
+	testValueRoundTrip(t, metadata.MakeTypeRange(0, 255), int64(200), 1)
+	testValueRoundTrip(t, metadata.MakeTypeRange(-1, 100), int64(-1), 1)
+	testValueRoundTrip(t, metadata.MakeTypeRange(0, 100000), int64(100000), 4)
+	testValueRoundTrip(t, metadata.MakeTypeRange(-100000, 100000), int64(-100000), 4)
+
+	wide := metadata.MakeTypeRange(-9007199254740991, 9007199254740991)
+	testValueRoundTrip(t, wide, int64(-9007199254740991), 8)
+	testValueRoundTrip(t, wide, int64(9007199254740991), 8)
+	testValueRoundTrip(t, metadata.MakeTypeRange(0, 5000000000), int64(4999999999), 8)
+}
+
+func TestNullableEncoding(t *testing.T) {
+	percent := metadata.MakeTypeNullable(metadata.MakeTypeRange(0, 100))
+	testValueRoundTrip(t, percent, nil, 1)
+	testValueRoundTrip(t, percent, int64(42), 2)
+
+	text := metadata.MakeTypeNullable(metadata.MakeTypeText())
+	testValueRoundTrip(t, text, nil, 1)
+	testValueRoundTrip(t, text, "", 1)
+	testValueRoundTrip(t, text, "hello", 6)
+}
+
+func TestStructuredEncoding(t *testing.T) {
+	typ, err := metadata.ParseType("record{name:text,level:range[0;100],ratio:nullable(float),points:array(range[-10;10]),extra:complex}")
+	assert.NoError(t, err)
+
+	value := map[string]any{
+		"name":   "foo",
+		"level":  int64(42),
+		"ratio":  nil,
+		"points": []any{int64(-3), int64(7)},
+		"extra":  map[string]any{"x": float64(1)},
+	}
+
+	assert.True(t, typ.Validate(value))
+
+	read := Encoding.ReadValue(typ, Encoding.WriteValue(typ, value))
+	assert.Equal(t, value, read)
+	assert.True(t, typ.Validate(read))
+}
+
+func TestJsonValue(t *testing.T) {
+	value, err := Encoding.ReadJsonValue(metadata.MakeTypeRange(0, 100), []byte(`42`))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), value)
+
+	value, err = Encoding.ReadJsonValue(metadata.MakeTypeNullable(metadata.MakeTypeBool()), []byte(`null`))
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	value, err = Encoding.ReadJsonValue(metadata.MakeTypeEnum("on", "off"), []byte(`"on"`))
+	assert.NoError(t, err)
+	assert.Equal(t, "on", value)
+
+	_, err = Encoding.ReadJsonValue(metadata.MakeTypeRange(0, 100), []byte(`142`))
+	assert.Error(t, err)
+
+	_, err = Encoding.ReadJsonValue(metadata.MakeTypeRange(0, 100), []byte(`"text"`))
+	assert.Error(t, err)
+
+	_, err = Encoding.ReadJsonValue(metadata.MakeTypeFloat(), []byte(`{`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,44 @@
+package bus
+
+// Message received on the bus
+type Message interface {
+	Topic() string
+	InstanceName() string
+	Domain() string
+	Path() string
+	Payload() []byte
+	Retained() bool
+}
+
+var _ Message = (*message)(nil)
+
+// Raw access to the bus topics, for tooling (monitoring, recording, replay)
+type Monitor struct {
+	client *client
+}
+
+func newMonitor(client *client) *Monitor {
+	return &Monitor{
+		client: client,
+	}
+}
+
+// Note: handler calls are sequential and keep the order of the messages received on the filter
+func (monitor *Monitor) Subscribe(filter string, handler func(m Message)) error {
+	return monitor.client.Subscribe(filter, func(m *message) {
+		handler(m)
+	})
+}
+
+func (monitor *Monitor) Unsubscribe(filters ...string) error {
+	return monitor.client.Unsubscribe(filters...)
+}
+
+// Note: blocking until the message is sent, the order of publication is preserved
+func (monitor *Monitor) Publish(topic string, payload []byte, retained bool) error {
+	if retained {
+		return monitor.client.PublishRetain(topic, payload)
+	} else {
+		return monitor.client.Publish(topic, payload)
+	}
+}
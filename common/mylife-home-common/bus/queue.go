@@ -0,0 +1,155 @@
+package bus
+
+import (
+	"mylife-home-common/tools"
+	"sync"
+)
+
+type outboundMessage struct {
+	topic    string
+	payload  []byte
+	retained bool
+	done     chan error // nil if nobody waits for the result
+}
+
+// Single ordered queue for all messages sent by the client.
+//
+// Messages are published one after the other, in the order they have been enqueued,
+// so that callers can fire and forget without breaking causality.
+type outboundQueue struct {
+	transport clientTransport
+	input     chan<- *outboundMessage
+	exited    chan struct{}
+	closed    bool
+	mux       sync.RWMutex
+}
+
+func newOutboundQueue(transport clientTransport) *outboundQueue {
+	input, output := tools.BufferedChannel[*outboundMessage]()
+
+	queue := &outboundQueue{
+		transport: transport,
+		input:     input,
+		exited:    make(chan struct{}),
+	}
+
+	go queue.worker(output)
+
+	return queue
+}
+
+// Wait for all pending messages to be sent, then stop the queue
+func (queue *outboundQueue) terminate() {
+	queue.mux.Lock()
+	queue.closed = true
+	close(queue.input)
+	queue.mux.Unlock()
+
+	<-queue.exited
+}
+
+// Enqueue the message and wait for it to be sent
+func (queue *outboundQueue) send(topic string, payload []byte, retained bool) error {
+	done := make(chan error, 1)
+
+	if err := queue.enqueue(&outboundMessage{topic, payload, retained, done}); err != nil {
+		return err
+	}
+
+	return <-done
+}
+
+// Enqueue the message and return immediately. Errors are logged.
+func (queue *outboundQueue) post(topic string, payload []byte, retained bool) {
+	switch err := queue.enqueue(&outboundMessage{topic, payload, retained, nil}); {
+	case err == nil, err == errClosing:
+		// OK
+	default:
+		logger.WithError(err).Errorf("Could not enqueue message on topic '%s'", topic)
+	}
+}
+
+func (queue *outboundQueue) enqueue(msg *outboundMessage) error {
+	queue.mux.RLock()
+	defer queue.mux.RUnlock()
+
+	if queue.closed {
+		return errClosing
+	}
+
+	queue.input <- msg
+	return nil
+}
+
+func (queue *outboundQueue) worker(output <-chan *outboundMessage) {
+	defer close(queue.exited)
+
+	for msg := range output {
+		err := queue.transport.Publish(msg.topic, msg.payload, msg.retained)
+
+		if msg.done != nil {
+			msg.done <- err
+		} else if err != nil {
+			logger.WithError(err).Errorf("Could not publish message on topic '%s'", msg.topic)
+		}
+	}
+}
+
+// Dispatch queue for one subscription.
+//
+// Messages are delivered to the callback one after the other, in the order they have been received,
+// without blocking the network loop nor the other subscriptions.
+type inboundQueue struct {
+	input  chan<- *message
+	closed bool
+	mux    sync.RWMutex
+}
+
+func newInboundQueue(callback func(m *message)) *inboundQueue {
+	input, output := tools.BufferedChannel[*message]()
+
+	queue := &inboundQueue{
+		input: input,
+	}
+
+	tools.DispatchChannel(output, func(m *message) {
+		// drop pending messages after unsubscribe
+		if !queue.isClosed() {
+			callback(m)
+		}
+	})
+
+	return queue
+}
+
+func (queue *inboundQueue) push(m *message) {
+	queue.mux.RLock()
+	defer queue.mux.RUnlock()
+
+	// late message after unsubscribe
+	if queue.closed {
+		return
+	}
+
+	queue.input <- m
+}
+
+func (queue *inboundQueue) isClosed() bool {
+	queue.mux.RLock()
+	defer queue.mux.RUnlock()
+
+	return queue.closed
+}
+
+// Pending messages are dropped
+func (queue *inboundQueue) terminate() {
+	queue.mux.Lock()
+	defer queue.mux.Unlock()
+
+	if queue.closed {
+		return
+	}
+
+	queue.closed = true
+	close(queue.input)
+}
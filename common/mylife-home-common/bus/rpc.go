@@ -1,9 +1,15 @@
 package bus
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"mylife-home-common/tools"
+	"reflect"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
@@ -11,19 +17,27 @@ import (
 const rpcDomain = "rpc"
 const rpcServices = "services"
 const rpcReplies = "replies"
+const rpcCancel = "cancel"
 
 const RpcTimeout = time.Second * 2
 
+// Metadata path on which the served services are described
+const rpcMetadataPath = "rpc"
+const rpcDescribeAddress = "rpc.describe"
+
 type Rpc struct {
 	client     *client
+	metadata   *Metadata
 	services   map[string]RpcService
 	mux        sync.RWMutex
+	publishMux sync.Mutex
 	onlineChan chan bool
 }
 
-func newRpc(client *client) *Rpc {
+func newRpc(client *client, metadata *Metadata) *Rpc {
 	rpc := &Rpc{
 		client:     client,
+		metadata:   metadata,
 		services:   make(map[string]RpcService),
 		onlineChan: make(chan bool),
 	}
@@ -31,6 +45,8 @@ func newRpc(client *client) *Rpc {
 	go rpc.worker()
 	rpc.client.Online().Subscribe(rpc.onlineChan, false)
 
+	rpc.Serve(rpcDescribeAddress, NewRpcService(rpc.describe))
+
 	return rpc
 }
 
@@ -62,6 +78,7 @@ func (rpc *Rpc) worker() {
 	for online := range rpc.onlineChan {
 		if online {
 			go rpc.rebind()
+			go rpc.publishDescriptions()
 		}
 	}
 }
@@ -88,6 +105,73 @@ type RpcService interface {
 	setup(client *client, address string)
 	bind() error
 	unbind() error
+	describe() *RpcServiceDescription
+}
+
+// Description of a served address, with the JSON schemas of its input and output
+type RpcServiceDescription struct {
+	Address string     `json:"address"`
+	Input   JsonSchema `json:"input"`
+	Output  JsonSchema `json:"output"`
+}
+
+// List of the served services, sorted by address
+func (rpc *Rpc) Describe() []*RpcServiceDescription {
+	rpc.mux.RLock()
+	defer rpc.mux.RUnlock()
+
+	list := make([]*RpcServiceDescription, 0, len(rpc.services))
+	for _, svc := range rpc.services {
+		list = append(list, svc.describe())
+	}
+
+	slices.SortFunc(list, func(a, b *RpcServiceDescription) int {
+		return strings.Compare(a.Address, b.Address)
+	})
+
+	return list
+}
+
+type rpcDescribeInput struct {
+	Address string `json:"address,omitempty"` // all services if not set
+}
+
+func (rpc *Rpc) describe(input rpcDescribeInput) ([]*RpcServiceDescription, error) {
+	list := rpc.Describe()
+
+	if input.Address == "" {
+		return list, nil
+	}
+
+	for _, desc := range list {
+		if desc.Address == input.Address {
+			return []*RpcServiceDescription{desc}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("service with address '%s' does not exist", input.Address)
+}
+
+// Describe the services served by a remote instance
+func RpcDescribe(rpc *Rpc, targetInstance string) ([]*RpcServiceDescription, error) {
+	return RpcCall[rpcDescribeInput, []*RpcServiceDescription](rpc, targetInstance, rpcDescribeAddress, rpcDescribeInput{}, RpcTimeout)
+}
+
+// Note: the last publication always contains the current list
+func (rpc *Rpc) publishDescriptions() {
+	rpc.publishMux.Lock()
+	defer rpc.publishMux.Unlock()
+
+	if !rpc.client.Online().Get() {
+		return
+	}
+
+	switch err := rpc.metadata.Set(rpcMetadataPath, rpc.Describe()); {
+	case err == nil, err == errClosing:
+		// OK
+	default:
+		logger.WithError(err).Error("could not publish rpc services description")
+	}
 }
 
 func (rpc *Rpc) Serve(address string, svc RpcService) {
@@ -109,6 +193,8 @@ func (rpc *Rpc) Serve(address string, svc RpcService) {
 			}
 		}
 	}()
+
+	go rpc.publishDescriptions()
 }
 
 func (rpc *Rpc) Unserve(address string) {
@@ -127,24 +213,54 @@ func (rpc *Rpc) Unserve(address string) {
 			logger.WithError(err).Errorf("Could not unbind service '%s'", address)
 		}
 	}()
+
+	go rpc.publishDescriptions()
 }
 
 // Cannot use member function because of generic
 func RpcCall[TInput any, TOutput any](rpc *Rpc, targetInstance string, address string, data TInput, timeout time.Duration) (TOutput, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return rpcCall[TInput, TOutput](ctx, rpc, targetInstance, address, data, nil)
+}
+
+// Call cancellation is propagated to the remote implementation (if it is context aware)
+func RpcCallContext[TInput any, TOutput any](ctx context.Context, rpc *Rpc, targetInstance string, address string, data TInput) (TOutput, error) {
+	return rpcCall[TInput, TOutput](ctx, rpc, targetInstance, address, data, nil)
+}
+
+// Same as RpcCallContext, onProgress is called for each progress message sent by the remote implementation before the response.
+func RpcCallStream[TInput any, TOutput any, TProgress any](ctx context.Context, rpc *Rpc, targetInstance string, address string, data TInput, onProgress func(progress TProgress)) (TOutput, error) {
+	return rpcCall[TInput, TOutput](ctx, rpc, targetInstance, address, data, func(raw json.RawMessage) {
+		var progress TProgress
+		Encoding.ReadTypedJson(raw, &progress)
+		onProgress(progress)
+	})
+}
+
+func rpcCall[TInput any, TOutput any](ctx context.Context, rpc *Rpc, targetInstance string, address string, data TInput, onProgress func(raw json.RawMessage)) (TOutput, error) {
 	replyId := randomTopicPart()
 	replyTopic := rpc.client.BuildTopic(rpcDomain, rpcReplies, replyId)
 	remoteTopic := rpc.client.BuildRemoteTopic(targetInstance, rpcDomain, rpcServices, address)
+	cancelTopic := rpc.client.BuildRemoteTopic(targetInstance, rpcDomain, rpcServices, address, rpcCancel, replyId)
 	var nilOutput TOutput
 
 	request := request[TInput]{
-		Input:      data,
-		ReplyTopic: replyTopic,
+		Input:       data,
+		ReplyTopic:  replyTopic,
+		CancelTopic: cancelTopic,
+		Progress:    onProgress != nil,
 	}
 
-	replyChan := make(chan []byte, 10)
+	replyChan := make(chan []byte)
+	exited := make(chan struct{})
 	onMessage := func(m *message) {
-		replyChan <- m.Payload()
-		close(replyChan)
+		select {
+		case replyChan <- m.Payload():
+		case <-exited:
+			// late message, nobody waits for it anymore
+		}
 	}
 
 	onlineChan := make(chan bool, 10)
@@ -163,34 +279,73 @@ func RpcCall[TInput any, TOutput any](rpc *Rpc, targetInstance string, address s
 		}
 	}()
 
+	defer close(exited)
+
 	if err := rpc.client.Publish(remoteTopic, Encoding.WriteJson(&request)); err != nil {
 		return nilOutput, err
 	}
 
-	var reply []byte
+	for {
+		var reply []byte
+
+		select {
+		case online := <-onlineChan:
+			if !online {
+				return nilOutput, fmt.Errorf("connection lost while waiting for message on topic '%s' (call address: '%s')", replyTopic, address)
+			}
+			continue
 
-	select {
-	case online := <-onlineChan:
-		if !online {
-			return nilOutput, fmt.Errorf("connection lost while waiting for message on topic '%s' (call address: '%s')", replyTopic, address)
+		case <-ctx.Done():
+			// Let the remote implementation know that nobody waits for the result anymore
+			rpc.client.Post(cancelTopic, []byte{})
+
+			if ctx.Err() == context.DeadlineExceeded {
+				return nilOutput, fmt.Errorf("timeout occured while waiting for message on topic '%s' (call address: '%s'): %w", replyTopic, address, ctx.Err())
+			}
+
+			return nilOutput, fmt.Errorf("call cancelled while waiting for message on topic '%s' (call address: '%s'): %w", replyTopic, address, ctx.Err())
+
+		case reply = <-replyChan:
+			// Go ahead
 		}
-	case <-time.After(timeout):
-		return nilOutput, fmt.Errorf("timeout occured while waiting for message on topic '%s' (call address: '%s', timeout: %s)", replyTopic, address, timeout)
-	case reply = <-replyChan:
-		// Go ahead
-	}
 
-	var resp response[TOutput]
-	Encoding.ReadTypedJson(reply, &resp)
+		var resp response[TOutput]
+		Encoding.ReadTypedJson(reply, &resp)
 
-	if respErr := resp.Error; respErr != nil {
-		// Log the stacktrace here but do not forward it
-		logger.Errorf("Remote error: %s, stacktrace: %s", respErr.Message, respErr.Stacktrace)
+		if resp.Progress != nil {
+			if onProgress != nil {
+				onProgress(resp.Progress)
+			}
+			continue
+		}
+
+		if respErr := resp.Error; respErr != nil {
+			// Log the stacktrace here but do not forward it
+			logger.Errorf("Remote error: %s, stacktrace: %s", respErr.Message, respErr.Stacktrace)
+
+			return nilOutput, &RemoteError{Message: respErr.Message, Details: respErr.Details}
+		}
+
+		if resp.Output == nil {
+			return nilOutput, nil
+		}
+
+		return *resp.Output, nil
+	}
+}
+
+type rpcProgressKey struct{}
 
-		return nilOutput, fmt.Errorf("remote error: %s", respErr.Message)
+// Send a progress message to the caller, from the implementation of a context aware service.
+//
+// Note: no-op if the caller did not ask for progress
+func RpcProgress(ctx context.Context, progress any) error {
+	reporter, ok := ctx.Value(rpcProgressKey{}).(func(progress any) error)
+	if !ok {
+		return nil
 	}
 
-	return *resp.Output, nil
+	return reporter(progress)
 }
 
 var _ RpcService = (*rpcServiceImpl[int, int])(nil)
@@ -198,12 +353,23 @@ var _ RpcService = (*rpcServiceImpl[int, int])(nil)
 type rpcServiceImpl[TInput any, TOutput any] struct {
 	client         *client
 	address        string
-	implementation func(TInput) (TOutput, error)
+	implementation func(context.Context, TInput) (TOutput, error)
+	pendings       map[string]context.CancelFunc // by cancel topic
+	mux            sync.Mutex
 }
 
 func NewRpcService[TInput any, TOutput any](implementation func(TInput) (TOutput, error)) RpcService {
+	return NewRpcServiceContext(func(_ context.Context, input TInput) (TOutput, error) {
+		return implementation(input)
+	})
+}
+
+// The context is cancelled when the caller cancels the call (or times out), or when the service is unbound.
+// The implementation can send progress messages to the caller with RpcProgress.
+func NewRpcServiceContext[TInput any, TOutput any](implementation func(context.Context, TInput) (TOutput, error)) RpcService {
 	return &rpcServiceImpl[TInput, TOutput]{
 		implementation: implementation,
+		pendings:       make(map[string]context.CancelFunc),
 	}
 }
 
@@ -212,20 +378,50 @@ func (svc *rpcServiceImpl[TInput, TOutput]) setup(client *client, address string
 	svc.address = address
 }
 
+// Note: requests and cancellations are received on the same subscription, so that their order is kept
 func (svc *rpcServiceImpl[TInput, TOutput]) bind() error {
-	return svc.client.Subscribe(svc.buildTopic(), svc.handleMessage)
+	return svc.client.Subscribe(svc.buildTopic()+"/#", svc.handleMessage)
 }
 
 func (svc *rpcServiceImpl[TInput, TOutput]) unbind() error {
-	return svc.client.Unsubscribe(svc.buildTopic())
+	svc.cancelAll()
+	return svc.client.Unsubscribe(svc.buildTopic() + "/#")
 }
 
 func (svc *rpcServiceImpl[TInput, TOutput]) handleMessage(m *message) {
+	if m.Topic() != svc.buildTopic() {
+		svc.cancel(m.Topic())
+		return
+	}
+
+	var req request[TInput]
+	Encoding.ReadTypedJson(m.Payload(), &req)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Register synchronously, so that a cancellation received right after the request finds it
+	if req.CancelTopic != "" {
+		svc.mux.Lock()
+		svc.pendings[req.CancelTopic] = cancel
+		svc.mux.Unlock()
+	}
+
+	if req.Progress {
+		ctx = context.WithValue(ctx, rpcProgressKey{}, func(progress any) error {
+			return svc.client.Publish(req.ReplyTopic, Encoding.WriteJson(&response[TOutput]{Progress: Encoding.WriteJson(progress)}))
+		})
+	}
+
 	go func() {
-		var req request[TInput]
-		Encoding.ReadTypedJson(m.Payload(), &req)
+		resp := svc.handle(ctx, &req)
+
+		if req.CancelTopic != "" {
+			svc.mux.Lock()
+			delete(svc.pendings, req.CancelTopic)
+			svc.mux.Unlock()
+		}
 
-		resp := svc.handle(&req)
+		cancel()
 
 		output := Encoding.WriteJson(resp)
 		if err := svc.client.Publish(req.ReplyTopic, output); err != nil {
@@ -234,14 +430,37 @@ func (svc *rpcServiceImpl[TInput, TOutput]) handleMessage(m *message) {
 	}()
 }
 
-func (svc *rpcServiceImpl[TInput, TOutput]) handle(req *request[TInput]) *response[TOutput] {
-	output, err := svc.implementation(req.Input)
+func (svc *rpcServiceImpl[TInput, TOutput]) cancel(cancelTopic string) {
+	svc.mux.Lock()
+	defer svc.mux.Unlock()
+
+	// Unknown if the call is already finished
+	if cancel, exists := svc.pendings[cancelTopic]; exists {
+		logger.Debugf("Call cancelled on service '%s'", svc.address)
+		cancel()
+		delete(svc.pendings, cancelTopic)
+	}
+}
+
+func (svc *rpcServiceImpl[TInput, TOutput]) cancelAll() {
+	svc.mux.Lock()
+	defer svc.mux.Unlock()
+
+	for cancelTopic, cancel := range svc.pendings {
+		cancel()
+		delete(svc.pendings, cancelTopic)
+	}
+}
+
+func (svc *rpcServiceImpl[TInput, TOutput]) handle(ctx context.Context, req *request[TInput]) *response[TOutput] {
+	output, err := svc.implementation(ctx, req.Input)
 
 	if err != nil {
 		return &response[TOutput]{
 			Error: &reponseError{
 				Message:    err.Error(),
 				Stacktrace: tools.GetStackTraceStr(err),
+				Details:    getErrorDetails(err),
 			},
 		}
 	} else {
@@ -251,6 +470,14 @@ func (svc *rpcServiceImpl[TInput, TOutput]) handle(req *request[TInput]) *respon
 	}
 }
 
+func (svc *rpcServiceImpl[TInput, TOutput]) describe() *RpcServiceDescription {
+	return &RpcServiceDescription{
+		Address: svc.address,
+		Input:   makeJsonSchema(reflect.TypeOf((*TInput)(nil)).Elem()),
+		Output:  makeJsonSchema(reflect.TypeOf((*TOutput)(nil)).Elem()),
+	}
+}
+
 func (svc *rpcServiceImpl[TInput, TOutput]) buildTopic() string {
 	return svc.client.BuildTopic(rpcDomain, rpcServices, svc.address)
 }
@@ -269,16 +496,51 @@ func randomTopicPart() string {
 }
 
 type request[TInput any] struct {
-	Input      TInput `json:"input"`
-	ReplyTopic string `json:"replyTopic"`
+	Input       TInput `json:"input"`
+	ReplyTopic  string `json:"replyTopic"`
+	CancelTopic string `json:"cancelTopic,omitempty"` // publish on it to cancel the call
+	Progress    bool   `json:"progress,omitempty"`    // the caller accepts progress messages on the reply topic
 }
 
+// Progress messages are sent before the final response, with only the progress field set
 type response[TOutput any] struct {
-	Output *TOutput      `json:"output"`
-	Error  *reponseError `json:"error"`
+	Output   *TOutput        `json:"output"`
+	Error    *reponseError   `json:"error"`
+	Progress json.RawMessage `json:"progress,omitempty"`
 }
 
 type reponseError struct {
-	Message    string `json:"message"`
-	Stacktrace string `json:"stacktrace"`
+	Message    string          `json:"message"`
+	Stacktrace string          `json:"stacktrace"`
+	Details    json.RawMessage `json:"details,omitempty"`
+}
+
+// Errors returned by services can implement it to send structured details to the caller, along with the message
+type RpcErrorDetails interface {
+	RpcErrorDetails() any
+}
+
+// Error returned by the service on the remote side
+type RemoteError struct {
+	Message string
+	Details json.RawMessage // nil if the error had no details
+}
+
+func (err *RemoteError) Error() string {
+	return fmt.Sprintf("remote error: %s", err.Message)
+}
+
+func getErrorDetails(err error) json.RawMessage {
+	var detailed RpcErrorDetails
+	if !errors.As(err, &detailed) {
+		return nil
+	}
+
+	raw, marshalErr := json.Marshal(detailed.RpcErrorDetails())
+	if marshalErr != nil {
+		logger.WithError(marshalErr).Error("Could not serialize RPC error details")
+		return nil
+	}
+
+	return raw
 }
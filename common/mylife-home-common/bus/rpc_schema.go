@@ -0,0 +1,149 @@
+package bus
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSON schema (draft 2020-12 subset) of the values exchanged by a RPC service
+type JsonSchema = map[string]any
+
+var timeType = reflect.TypeOf((*time.Time)(nil)).Elem()
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// Build the JSON schema of the values of type typ, as encoded by encoding/json
+func makeJsonSchema(typ reflect.Type) JsonSchema {
+	builder := &jsonSchemaBuilder{
+		visiting: make(map[reflect.Type]struct{}),
+	}
+
+	return builder.build(typ)
+}
+
+type jsonSchemaBuilder struct {
+	visiting map[reflect.Type]struct{}
+}
+
+func (builder *jsonSchemaBuilder) build(typ reflect.Type) JsonSchema {
+	if typ == timeType {
+		return JsonSchema{"type": "string", "format": "date-time"}
+	}
+
+	// Custom encoding: cannot tell
+	if typ.Implements(jsonMarshalerType) || reflect.PointerTo(typ).Implements(jsonMarshalerType) {
+		return JsonSchema{}
+	}
+
+	if typ.Implements(textMarshalerType) || reflect.PointerTo(typ).Implements(textMarshalerType) {
+		return JsonSchema{"type": "string"}
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return JsonSchema{"type": "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return JsonSchema{"type": "integer"}
+
+	case reflect.Float32, reflect.Float64:
+		return JsonSchema{"type": "number"}
+
+	case reflect.String:
+		return JsonSchema{"type": "string"}
+
+	case reflect.Pointer:
+		return builder.nullable(builder.build(typ.Elem()))
+
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return JsonSchema{"type": "string", "contentEncoding": "base64"}
+		}
+
+		return builder.nullable(JsonSchema{"type": "array", "items": builder.build(typ.Elem())})
+
+	case reflect.Array:
+		return JsonSchema{"type": "array", "items": builder.build(typ.Elem()), "minItems": typ.Len(), "maxItems": typ.Len()}
+
+	case reflect.Map:
+		return builder.nullable(JsonSchema{"type": "object", "additionalProperties": builder.build(typ.Elem())})
+
+	case reflect.Struct:
+		// Recursive type: do not go further
+		if _, visiting := builder.visiting[typ]; visiting {
+			return JsonSchema{"type": "object"}
+		}
+
+		builder.visiting[typ] = struct{}{}
+		defer delete(builder.visiting, typ)
+
+		properties := make(map[string]any)
+		required := make([]string, 0)
+		builder.addFields(typ, properties, &required)
+
+		schema := JsonSchema{"type": "object", "properties": properties, "additionalProperties": false}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+
+		return schema
+
+	default:
+		// interface, any
+		return JsonSchema{}
+	}
+}
+
+func (builder *jsonSchemaBuilder) addFields(typ reflect.Type, properties map[string]any, required *[]string) {
+	for index := 0; index < typ.NumField(); index += 1 {
+		field := typ.Field(index)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+
+		// Embedded structs fields are promoted
+		if field.Anonymous && name == "" {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+
+			if fieldType.Kind() == reflect.Struct {
+				builder.addFields(fieldType, properties, required)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = builder.build(field.Type)
+
+		if !strings.Contains(options, "omitempty") && !strings.Contains(options, "omitzero") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func (builder *jsonSchemaBuilder) nullable(schema JsonSchema) JsonSchema {
+	typ, ok := schema["type"].(string)
+	if !ok {
+		return schema
+	}
+
+	schema["type"] = []string{typ, "null"}
+	return schema
+}
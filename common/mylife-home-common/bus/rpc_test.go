@@ -0,0 +1,187 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRpc(t *testing.T, instanceName string) *Rpc {
+	client := newTestClient(t, "", instanceName)
+	return newRpc(client, newMetadata(client))
+}
+
+// Services are bound asynchronously
+func waitRpcService[TInput any, TOutput any](t *testing.T, caller *Rpc, targetInstance string, address string, probe TInput) {
+	assert.Eventually(t, func() bool {
+		_, err := RpcCall[TInput, TOutput](caller, targetInstance, address, probe, time.Millisecond*100)
+		return err == nil
+	}, testTimeout, time.Millisecond*10)
+}
+
+func TestRpcProgress(t *testing.T) {
+	server := newTestRpc(t, "test-rpc-server")
+	defer server.terminate()
+	caller := newTestRpc(t, "test-rpc-caller")
+	defer caller.terminate()
+
+	server.Serve("count", NewRpcServiceContext(func(ctx context.Context, input int) (string, error) {
+		for index := 0; index < input; index += 1 {
+			if err := RpcProgress(ctx, index); err != nil {
+				return "", err
+			}
+		}
+
+		return "done", nil
+	}))
+
+	waitRpcService[int, string](t, caller, "test-rpc-server", "count", 0)
+
+	progresses := make([]int, 0)
+	output, err := RpcCallStream[int, string](context.Background(), caller, "test-rpc-server", "count", 5, func(progress int) {
+		progresses = append(progresses, progress)
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "done", output)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, progresses)
+
+	// Progress messages are not sent to callers that do not ask for them
+	output, err = RpcCall[int, string](caller, "test-rpc-server", "count", 5, RpcTimeout)
+	assert.NoError(t, err)
+	assert.Equal(t, "done", output)
+}
+
+func TestRpcCancel(t *testing.T) {
+	server := newTestRpc(t, "test-rpc-server")
+	defer server.terminate()
+	caller := newTestRpc(t, "test-rpc-caller")
+	defer caller.terminate()
+
+	started := make(chan struct{})
+	cancelled := make(chan error, 1)
+
+	server.Serve("wait", NewRpcServiceContext(func(ctx context.Context, block bool) (struct{}, error) {
+		if !block {
+			return struct{}{}, nil
+		}
+
+		close(started)
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return struct{}{}, ctx.Err()
+	}))
+
+	waitRpcService[bool, struct{}](t, caller, "test-rpc-server", "wait", false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	_, err := RpcCallContext[bool, struct{}](ctx, caller, "test-rpc-server", "wait", true)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	select {
+	case err := <-cancelled:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(testTimeout):
+		t.Fatal("remote implementation not cancelled")
+	}
+}
+
+type testDescribeInput struct {
+	Id     string             `json:"id"`
+	Config map[string]any     `json:"config,omitempty"`
+	Tags   []string           `json:"tags"`
+	Next   *testDescribeInput `json:"next"`
+	hidden int
+}
+
+func TestRpcDescribe(t *testing.T) {
+	server := newTestRpc(t, "test-rpc-server")
+	defer server.terminate()
+	caller := newTestRpc(t, "test-rpc-caller")
+	defer caller.terminate()
+
+	server.Serve("test.add", NewRpcService(func(input *testDescribeInput) (struct{}, error) {
+		return struct{}{}, nil
+	}))
+
+	waitRpcService[*testDescribeInput, struct{}](t, caller, "test-rpc-server", "test.add", &testDescribeInput{})
+
+	list, err := RpcDescribe(caller, "test-rpc-server")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rpc.describe", "test.add"}, []string{list[0].Address, list[1].Address})
+
+	// Note: schemas went through json
+	input := list[1].Input
+	assert.Equal(t, []any{"object", "null"}, input["type"])
+	assert.Equal(t, []any{"id", "tags", "next"}, input["required"])
+
+	properties := input["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "string"}, properties["id"])
+	assert.Equal(t, map[string]any{"type": []any{"object", "null"}, "additionalProperties": map[string]any{}}, properties["config"])
+	assert.Equal(t, map[string]any{"type": []any{"array", "null"}, "items": map[string]any{"type": "string"}}, properties["tags"])
+	assert.Equal(t, map[string]any{"type": []any{"object", "null"}}, properties["next"])
+	assert.NotContains(t, properties, "hidden")
+
+	output := list[1].Output
+	assert.Equal(t, "object", output["type"])
+
+	// Published in metadata
+	view, err := newMetadata(caller.client).CreateView("test-rpc-server")
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		services, ok := view.Values()[rpcMetadataPath].([]any)
+		return ok && len(services) == 2
+	}, testTimeout, time.Millisecond*10)
+}
+
+type testDetailedError struct {
+	field string
+}
+
+func (err *testDetailedError) Error() string {
+	return "invalid " + err.field
+}
+
+func (err *testDetailedError) RpcErrorDetails() any {
+	return map[string]string{"field": err.field}
+}
+
+func TestRpcErrorDetails(t *testing.T) {
+	server := newTestRpc(t, "test-rpc-server")
+	defer server.terminate()
+	caller := newTestRpc(t, "test-rpc-caller")
+	defer caller.terminate()
+
+	server.Serve("check", NewRpcService(func(input string) (struct{}, error) {
+		if input == "" {
+			return struct{}{}, nil
+		}
+
+		if input == "plain" {
+			return struct{}{}, errors.New("plain error")
+		}
+
+		return struct{}{}, fmt.Errorf("wrapped: %w", &testDetailedError{field: input})
+	}))
+
+	waitRpcService[string, struct{}](t, caller, "test-rpc-server", "check", "")
+
+	_, err := RpcCall[string, struct{}](caller, "test-rpc-server", "check", "name", RpcTimeout)
+	var remoteErr *RemoteError
+	assert.ErrorAs(t, err, &remoteErr)
+	assert.Equal(t, "remote error: wrapped: invalid name", err.Error())
+	assert.JSONEq(t, `{"field": "name"}`, string(remoteErr.Details))
+
+	_, err = RpcCall[string, struct{}](caller, "test-rpc-server", "check", "plain", RpcTimeout)
+	assert.ErrorAs(t, err, &remoteErr)
+	assert.Nil(t, remoteErr.Details)
+}
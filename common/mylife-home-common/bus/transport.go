@@ -17,22 +17,30 @@ type Transport struct {
 	components *Components
 	metadata   *Metadata
 	logger     *Logger
+	monitor    *Monitor
 
 	onlineChan             chan bool
 	instanceInfoUpdateChan chan *instance_info.InstanceInfo
 }
 
-func NewTransport() *Transport {
-	client := newClient(defines.InstanceName())
+// Returns an error if the bus configuration is invalid
+func NewTransport() (*Transport, error) {
+	client, err := newClient(defines.InstanceName())
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := newMetadata(client)
 
 	transport := &Transport{
 		client: client,
 
-		rpc:        newRpc(client),
+		rpc:        newRpc(client, metadata),
 		presence:   newPresence(client),
 		components: newComponents(client),
-		metadata:   newMetadata(client),
+		metadata:   metadata,
 		logger:     newLogger(client),
+		monitor:    newMonitor(client),
 
 		onlineChan:             make(chan bool),
 		instanceInfoUpdateChan: make(chan *instance_info.InstanceInfo),
@@ -43,7 +51,7 @@ func NewTransport() *Transport {
 	transport.client.Online().Subscribe(transport.onlineChan, false)
 	instance_info.OnUpdate().Subscribe(transport.instanceInfoUpdateChan)
 
-	return transport
+	return transport, nil
 }
 
 func (transport *Transport) Terminate() {
@@ -138,6 +146,10 @@ func (transport *Transport) Metadata() *Metadata {
 	return transport.metadata
 }
 
+func (transport *Transport) Monitor() *Monitor {
+	return transport.monitor
+}
+
 func (transport *Transport) Online() tools.ObservableValue[bool] {
 	return transport.client.Online()
 }
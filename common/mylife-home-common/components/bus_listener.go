@@ -350,8 +350,8 @@ func (comp *busListenerComponent) initState(member *metadata.Member) tools.Obser
 	// finish init in background
 	go comp.remoteComponent.RegisterStateChange(name, func(data []byte) {
 		value := bus.Encoding.ReadValue(member.ValueType(), data)
-		// dispatch async
-		go subject.Update(value)
+		// handler calls are sequential per state, so this keeps the order of the messages
+		subject.Update(value)
 	})
 
 	return subject
@@ -362,9 +362,16 @@ func (comp *busListenerComponent) initAction(member *metadata.Member) chan<- any
 	channel := make(chan any)
 
 	tools.DispatchChannel(channel, func(value any) {
-		data := bus.Encoding.WriteValue(member.ValueType(), value)
-		// emit async
-		go comp.remoteComponent.EmitAction(name, data)
+		typ := member.ValueType()
+
+		if !typ.Validate(value) {
+			logger.Errorf("Invalid value %+v for action '%s' of component '%s' (expected type '%s'), not emitting", value, name, comp.Id(), typ.String())
+			return
+		}
+
+		data := bus.Encoding.WriteValue(typ, value)
+		// not blocking, keeps the order of the calls
+		comp.remoteComponent.EmitAction(name, data)
 	})
 
 	return channel
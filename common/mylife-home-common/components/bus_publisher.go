@@ -1,6 +1,7 @@
 package components
 
 import (
+	"fmt"
 	"mylife-home-common/bus"
 	"mylife-home-common/components/metadata"
 	"mylife-home-common/tools"
@@ -206,6 +207,20 @@ func (api *busPublisherApi) PublishMeta(path string, value any) {
 	}()
 }
 
+// Publish synchronously, outside of the publisher worker (so that successive values stay ordered)
+func (api *busPublisherApi) SetMeta(path string, value any) {
+	if err := api.transport.Metadata().Set(path, value); err != nil {
+		logger.WithError(err).Errorf("Could not publish metadata '%s'", path)
+	}
+}
+
+// Unpublish synchronously, so that it cannot be reordered with a previous SetMeta
+func (api *busPublisherApi) ClearMeta(path string) {
+	if err := api.transport.Metadata().Clear(path); err != nil {
+		logger.WithError(err).Errorf("Could not unpublish metadata '%s'", path)
+	}
+}
+
 func (api *busPublisherApi) UnpublishMeta(path string) {
 	go func() {
 		if err := api.transport.Metadata().Clear(path); err != nil {
@@ -357,6 +372,13 @@ type busPublisherComponent struct {
 	component          Component
 	transportComponent bus.LocalComponent
 	stateChans         map[string]chan any
+
+	// only if the component implements HealthReporter
+	healthPath   string
+	healthChan   chan Health
+	healthExited chan struct{}
+	health       Health
+	healthMux    sync.Mutex
 }
 
 func newBusPublisherComponent(component Component, api *busPublisherApi) *busPublisherComponent {
@@ -375,6 +397,10 @@ func newBusPublisherComponent(component Component, api *busPublisherApi) *busPub
 		bc.publishMeta()
 	}
 
+	if reporter, ok := component.(HealthReporter); ok {
+		bc.watchHealth(reporter)
+	}
+
 	return bc
 }
 
@@ -382,23 +408,89 @@ func (bc *busPublisherComponent) onOnlineChange(online bool) {
 	if online {
 		bc.publishComponent()
 		bc.publishMeta()
+		bc.publishHealth()
 	} else {
 		bc.unpublishComponent()
 	}
 }
 
 func (bc *busPublisherComponent) close() {
+	bc.unwatchHealth()
+
 	if bc.api.IsOnline() {
 		bc.unpublishMeta()
 		bc.unpublishComponent()
 	}
 }
 
+func (bc *busPublisherComponent) watchHealth(reporter HealthReporter) {
+	bc.healthPath = "health/" + bc.component.Id()
+	bc.healthChan = make(chan Health)
+	bc.healthExited = make(chan struct{})
+
+	go func() {
+		defer close(bc.healthExited)
+
+		for health := range bc.healthChan {
+			bc.onHealthChange(health)
+		}
+	}()
+
+	reporter.Health().Subscribe(bc.healthChan, true)
+}
+
+func (bc *busPublisherComponent) unwatchHealth() {
+	if bc.healthChan == nil {
+		return
+	}
+
+	bc.component.(HealthReporter).Health().Unsubscribe(bc.healthChan)
+	close(bc.healthChan)
+
+	// wait for a pending publication, so that it cannot be sent after the clear
+	<-bc.healthExited
+
+	if bc.api.IsOnline() {
+		bc.api.ClearMeta(bc.healthPath)
+	}
+}
+
+func (bc *busPublisherComponent) onHealthChange(health Health) {
+	bc.healthMux.Lock()
+	defer bc.healthMux.Unlock()
+
+	bc.health = health
+
+	if bc.api.IsOnline() {
+		bc.api.SetMeta(bc.healthPath, health)
+	}
+}
+
+// Republish the last health (eg: after reconnection)
+func (bc *busPublisherComponent) publishHealth() {
+	if bc.healthChan == nil {
+		return
+	}
+
+	bc.healthMux.Lock()
+	health := bc.health
+	bc.healthMux.Unlock()
+
+	bc.api.PublishMeta(bc.healthPath, health)
+}
+
 func (bc *busPublisherComponent) publishState(name string, value any) {
 	member := bc.component.Plugin().Member(name)
-	data := bus.Encoding.WriteValue(member.ValueType(), value)
+	typ := member.ValueType()
 
-	go bc.transportComponent.SetState(name, data)
+	if !typ.Validate(value) {
+		logger.Errorf("Invalid value %+v for state '%s' of component '%s' (expected type '%s'), not publishing", value, name, bc.component.Id(), typ.String())
+		return
+	}
+
+	data := bus.Encoding.WriteValue(typ, value)
+
+	bc.transportComponent.SetState(name, data)
 }
 
 func (bc *busPublisherComponent) publishMeta() {
@@ -433,13 +525,29 @@ func (bc *busPublisherComponent) registerAction(member *metadata.Member) {
 
 	// register is blocking
 	go bc.transportComponent.RegisterAction(name, func(data []byte) {
-		value := bus.Encoding.ReadValue(typ, data)
-		go func() {
-			channel <- value
-		}()
+		value, err := readActionValue(typ, data)
+		if err != nil {
+			logger.WithError(err).Errorf("Could not read value for action '%s' of component '%s', dropped", name, bc.component.Id())
+			return
+		}
+
+		// handler calls are sequential per action, so this keeps the order of the messages
+		channel <- value
 	})
 }
 
+// Payloads come from the bus: malformed ones must not crash the handler
+func readActionValue(typ metadata.Type, data []byte) (value any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			value = nil
+			err = fmt.Errorf("malformed payload for type %s: %v", typ.String(), r)
+		}
+	}()
+
+	return bus.Encoding.ReadValue(typ, data), nil
+}
+
 func (bc *busPublisherComponent) registerState(member *metadata.Member) {
 	name := member.Name()
 	channel := make(chan any)
@@ -12,3 +12,21 @@ type Component interface {
 	StateItem(name string) tools.ObservableValue[any]
 	Action(name string) chan<- any
 }
+
+// Optional, implemented by components which report their health
+type HealthReporter interface {
+	Health() tools.ObservableValue[Health]
+}
+
+type HealthStatus string
+
+const (
+	HealthOk       HealthStatus = "ok"
+	HealthDegraded HealthStatus = "degraded"
+	HealthError    HealthStatus = "error"
+)
+
+type Health struct {
+	Status  HealthStatus `json:"status"`
+	Message string       `json:"message,omitempty"`
+}
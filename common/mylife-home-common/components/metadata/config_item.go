@@ -1,5 +1,13 @@
 package metadata
 
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"golang.org/x/exp/slices"
+)
+
 type ConfigType string
 
 const (
@@ -13,6 +21,7 @@ type ConfigItem struct {
 	name        string
 	description string
 	valueType   ConfigType
+	constraints *ConfigConstraints
 }
 
 func (config *ConfigItem) Name() string {
@@ -27,6 +36,92 @@ func (config *ConfigItem) ValueType() ConfigType {
 	return config.valueType
 }
 
+// True if the item can be omitted from the component configuration
+func (config *ConfigItem) Optional() bool {
+	return config.constraints.optional || config.constraints.defaultValue != nil
+}
+
+// Value used when the item is omitted: the provided default, the zero value of the type if optional without default, nil if required
+func (config *ConfigItem) Default() any {
+	if config.constraints.defaultValue != nil {
+		return config.constraints.defaultValue
+	}
+
+	if config.constraints.optional {
+		return config.valueType.zero()
+	}
+
+	return nil
+}
+
+// Nil if no minimum (integer and float only)
+func (config *ConfigItem) Min() *float64 {
+	return config.constraints.min
+}
+
+// Nil if no maximum (integer and float only)
+func (config *ConfigItem) Max() *float64 {
+	return config.constraints.max
+}
+
+// Allowed values, nil if not restricted (string only)
+func (config *ConfigItem) Enum() []string {
+	return config.constraints.enum
+}
+
+// Regular expression the value must match, empty if none (string only)
+func (config *ConfigItem) Pattern() string {
+	if config.constraints.pattern == nil {
+		return ""
+	}
+
+	return config.constraints.pattern.String()
+}
+
+// Secret values (eg: passwords) are redacted in logs and RPC responses, and encrypted in the store
+func (config *ConfigItem) Secret() bool {
+	return config.constraints.secret
+}
+
+// Check the value type and constraints, the error describes the first violation
+func (config *ConfigItem) Validate(value any) error {
+	if !config.valueType.Validate(value) {
+		return fmt.Errorf("value '%v' is not of type %s", value, config.valueType)
+	}
+
+	constraints := config.constraints
+
+	if constraints.min != nil || constraints.max != nil {
+		var number float64
+		switch typed := value.(type) {
+		case int64:
+			number = float64(typed)
+		case float64:
+			number = typed
+		}
+
+		if constraints.min != nil && number < *constraints.min {
+			return fmt.Errorf("value %v is lower than minimum %v", value, *constraints.min)
+		}
+
+		if constraints.max != nil && number > *constraints.max {
+			return fmt.Errorf("value %v is greater than maximum %v", value, *constraints.max)
+		}
+	}
+
+	if str, ok := value.(string); ok {
+		if constraints.enum != nil && !slices.Contains(constraints.enum, str) {
+			return fmt.Errorf("value '%s' is not one of %v", str, constraints.enum)
+		}
+
+		if constraints.pattern != nil && !constraints.pattern.MatchString(str) {
+			return fmt.Errorf("value '%s' does not match pattern '%s'", str, constraints.pattern)
+		}
+	}
+
+	return nil
+}
+
 func (ctype ConfigType) Validate(value any) bool {
 	ok := false
 
@@ -43,3 +138,129 @@ func (ctype ConfigType) Validate(value any) bool {
 
 	return ok
 }
+
+// Deserialize properly. go unmarshaller does not differentiate properly int vs float
+func (ctype ConfigType) Read(raw json.RawMessage) (any, error) {
+	switch ctype {
+	case String:
+		return readConfigValue[string](raw)
+	case Bool:
+		return readConfigValue[bool](raw)
+	case Integer:
+		return readConfigValue[int64](raw)
+	case Float:
+		return readConfigValue[float64](raw)
+	default:
+		return nil, fmt.Errorf("unhandled value type '%s'", ctype)
+	}
+}
+
+func readConfigValue[T any](raw json.RawMessage) (any, error) {
+	var value T
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, fmt.Errorf("could not read value '%s': %w", string(raw), err)
+	}
+
+	return value, nil
+}
+
+func (ctype ConfigType) zero() any {
+	switch ctype {
+	case String:
+		return ""
+	case Bool:
+		return false
+	case Integer:
+		return int64(0)
+	case Float:
+		return float64(0)
+	default:
+		return nil
+	}
+}
+
+// Optional constraints of a config item, set through the 'Set*' methods
+type ConfigConstraints struct {
+	defaultValue any
+	optional     bool
+	min          *float64
+	max          *float64
+	enum         []string
+	pattern      *regexp.Regexp
+	secret       bool
+}
+
+func MakeConfigConstraints() *ConfigConstraints {
+	return &ConfigConstraints{}
+}
+
+// The value must match the config type. Implies optional
+func (constraints *ConfigConstraints) SetDefault(value any) *ConfigConstraints {
+	constraints.defaultValue = value
+	return constraints
+}
+
+func (constraints *ConfigConstraints) SetOptional() *ConfigConstraints {
+	constraints.optional = true
+	return constraints
+}
+
+func (constraints *ConfigConstraints) SetMin(value float64) *ConfigConstraints {
+	constraints.min = &value
+	return constraints
+}
+
+func (constraints *ConfigConstraints) SetMax(value float64) *ConfigConstraints {
+	constraints.max = &value
+	return constraints
+}
+
+func (constraints *ConfigConstraints) SetEnum(values ...string) *ConfigConstraints {
+	constraints.enum = values
+	return constraints
+}
+
+// Panics if the regular expression is invalid
+func (constraints *ConfigConstraints) SetPattern(pattern string) *ConfigConstraints {
+	constraints.pattern = regexp.MustCompile(pattern)
+	return constraints
+}
+
+func (constraints *ConfigConstraints) SetSecret() *ConfigConstraints {
+	constraints.secret = true
+	return constraints
+}
+
+// Check that the constraints are consistent with the config type
+func (constraints *ConfigConstraints) Check(valueType ConfigType) error {
+	numeric := valueType == Integer || valueType == Float
+
+	if (constraints.min != nil || constraints.max != nil) && !numeric {
+		return fmt.Errorf("min/max constraints require an integer or float type, got %s", valueType)
+	}
+
+	if constraints.min != nil && constraints.max != nil && *constraints.min > *constraints.max {
+		return fmt.Errorf("min %v is greater than max %v", *constraints.min, *constraints.max)
+	}
+
+	if (constraints.enum != nil || constraints.pattern != nil) && valueType != String {
+		return fmt.Errorf("enum/pattern constraints require a string type, got %s", valueType)
+	}
+
+	if constraints.secret && valueType != String {
+		return fmt.Errorf("secret constraint requires a string type, got %s", valueType)
+	}
+
+	if constraints.enum != nil && len(constraints.enum) == 0 {
+		return fmt.Errorf("enum constraint without values")
+	}
+
+	if constraints.defaultValue != nil {
+		item := &ConfigItem{valueType: valueType, constraints: constraints}
+		if err := item.Validate(constraints.defaultValue); err != nil {
+			return fmt.Errorf("invalid default: %w", err)
+		}
+	}
+
+	return nil
+}
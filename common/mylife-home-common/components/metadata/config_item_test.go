@@ -0,0 +1,87 @@
+package metadata
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigConstraints(t *testing.T) {
+	plugin := MakePluginBuilder("module", "plugin", "", Logic, "1.0.0").
+		AddConfig("required", "", Integer).
+		AddConstrainedConfig("delay", "", Integer, MakeConfigConstraints().SetDefault(int64(10)).SetMin(1).SetMax(60)).
+		AddConstrainedConfig("mode", "", String, MakeConfigConstraints().SetOptional().SetEnum("on", "off")).
+		AddConstrainedConfig("host", "", String, MakeConfigConstraints().SetPattern(`^[a-z]+$`)).
+		Build()
+
+	required := plugin.Config("required")
+	assert.False(t, required.Optional())
+	assert.Nil(t, required.Default())
+
+	delay := plugin.Config("delay")
+	assert.True(t, delay.Optional())
+	assert.Equal(t, int64(10), delay.Default())
+	assert.NoError(t, delay.Validate(int64(60)))
+	assert.ErrorContains(t, delay.Validate(int64(0)), "lower than minimum")
+	assert.ErrorContains(t, delay.Validate(int64(61)), "greater than maximum")
+	assert.ErrorContains(t, delay.Validate(1.5), "not of type integer")
+
+	mode := plugin.Config("mode")
+	assert.Equal(t, "", mode.Default())
+	assert.ErrorContains(t, mode.Validate("auto"), "not one of")
+
+	host := plugin.Config("host")
+	assert.NoError(t, host.Validate("abc"))
+	assert.ErrorContains(t, host.Validate("ABC"), "does not match pattern")
+
+	// Invalid default
+	assert.Panics(t, func() {
+		MakePluginBuilder("module", "plugin", "", Logic, "1.0.0").
+			AddConstrainedConfig("delay", "", Integer, MakeConfigConstraints().SetDefault(int64(0)).SetMin(1))
+	})
+
+	// Enum on integer
+	assert.Panics(t, func() {
+		MakePluginBuilder("module", "plugin", "", Logic, "1.0.0").
+			AddConstrainedConfig("delay", "", Integer, MakeConfigConstraints().SetEnum("a"))
+	})
+}
+
+func TestConfigSerialization(t *testing.T) {
+	plugin := MakePluginBuilder("module", "plugin", "", Logic, "1.0.0").
+		AddConstrainedConfig("delay", "", Integer, MakeConfigConstraints().SetDefault(int64(10)).SetMin(1)).
+		AddConstrainedConfig("host", "", String, MakeConfigConstraints().SetPattern(`^[a-z]+$`).SetEnum("a", "b")).
+		AddConstrainedConfig("password", "", String, MakeConfigConstraints().SetSecret()).
+		Build()
+
+	data := Serializer.SerializePlugin(plugin)
+	raw, err := json.Marshal(data)
+	assert.NoError(t, err)
+	assert.Contains(t, string(raw), `"delay":{"default":10,"min":1,"optional":true,"valueType":"integer"}`)
+
+	restored := Serializer.DeserializePlugin(data)
+	delay := restored.Config("delay")
+	assert.Equal(t, int64(10), delay.Default())
+	assert.Equal(t, 1.0, *delay.Min())
+	assert.Nil(t, delay.Max())
+
+	host := restored.Config("host")
+	assert.Equal(t, `^[a-z]+$`, host.Pattern())
+	assert.Equal(t, []string{"a", "b"}, host.Enum())
+	assert.False(t, host.Secret())
+	assert.True(t, restored.Config("password").Secret())
+}
+
+func TestConfigRead(t *testing.T) {
+	value, err := Integer.Read(json.RawMessage("42"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), value)
+
+	_, err = Integer.Read(json.RawMessage("4.2"))
+	assert.Error(t, err)
+
+	value, err = Float.Read(json.RawMessage("42"))
+	assert.NoError(t, err)
+	assert.Equal(t, float64(42), value)
+}
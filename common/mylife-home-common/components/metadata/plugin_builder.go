@@ -39,10 +39,17 @@ func (builder *PluginBuilder) AddAction(name string, description string, valueTy
 }
 
 func (builder *PluginBuilder) AddConfig(name string, description string, valueType ConfigType) *PluginBuilder {
+	return builder.AddConstrainedConfig(name, description, valueType, MakeConfigConstraints())
+}
+
+func (builder *PluginBuilder) AddConstrainedConfig(name string, description string, valueType ConfigType, constraints *ConfigConstraints) *PluginBuilder {
 	_, exists := builder.target.config[name]
 	panics.IsFalse(exists)
 
-	builder.target.config[name] = &ConfigItem{name, description, valueType}
+	err := constraints.Check(valueType)
+	panics.IsTrue(err == nil, "invalid constraints for config '%s': %s", name, err)
+
+	builder.target.config[name] = &ConfigItem{name, description, valueType, constraints}
 
 	return builder
 }
@@ -22,8 +22,15 @@ type netPlugin struct {
 }
 
 type netConfig struct {
-	Description string     `json:"description,omitempty"`
-	ValueType   ConfigType `json:"valueType"`
+	Description string          `json:"description,omitempty"`
+	ValueType   ConfigType      `json:"valueType"`
+	Optional    bool            `json:"optional,omitempty"`
+	Default     json.RawMessage `json:"default,omitempty"`
+	Min         *float64        `json:"min,omitempty"`
+	Max         *float64        `json:"max,omitempty"`
+	Enum        []string        `json:"enum,omitempty"`
+	Pattern     string          `json:"pattern,omitempty"`
+	Secret      bool            `json:"secret,omitempty"`
 }
 
 type netMember struct {
@@ -70,6 +77,13 @@ func (e *serializerImpl) SerializePlugin(plugin *Plugin) any {
 		net.Config[name] = netConfig{
 			Description: configItem.Description(),
 			ValueType:   configItem.ValueType(),
+			Optional:    configItem.Optional(),
+			Default:     serializeConfigDefault(configItem.constraints.defaultValue),
+			Min:         configItem.Min(),
+			Max:         configItem.Max(),
+			Enum:        configItem.Enum(),
+			Pattern:     configItem.Pattern(),
+			Secret:      configItem.Secret(),
 		}
 	}
 
@@ -100,7 +114,7 @@ func (e *serializerImpl) DeserializePlugin(data any) *Plugin {
 		panics.NotEmpty(name)
 		panics.NotEmpty(configItem.ValueType)
 
-		builder.AddConfig(name, configItem.Description, configItem.ValueType)
+		builder.AddConstrainedConfig(name, configItem.Description, configItem.ValueType, deserializeConfigConstraints(&configItem))
 	}
 
 	for name, member := range net.Members {
@@ -126,6 +140,58 @@ func (e *serializerImpl) DeserializePlugin(data any) *Plugin {
 	return builder.Build()
 }
 
+func serializeConfigDefault(value any) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return raw
+}
+
+func deserializeConfigConstraints(net *netConfig) *ConfigConstraints {
+	constraints := MakeConfigConstraints()
+
+	if net.Optional {
+		constraints.SetOptional()
+	}
+
+	if net.Default != nil {
+		value, err := net.ValueType.Read(net.Default)
+		if err != nil {
+			panic(err)
+		}
+
+		constraints.SetDefault(value)
+	}
+
+	if net.Min != nil {
+		constraints.SetMin(*net.Min)
+	}
+
+	if net.Max != nil {
+		constraints.SetMax(*net.Max)
+	}
+
+	if net.Enum != nil {
+		constraints.SetEnum(net.Enum...)
+	}
+
+	if net.Pattern != "" {
+		constraints.SetPattern(net.Pattern)
+	}
+
+	if net.Secret {
+		constraints.SetSecret()
+	}
+
+	return constraints
+}
+
 func safeSerialize(net any) any {
 	raw, err := json.Marshal(net)
 	if err != nil {
@@ -163,3 +163,187 @@ func (typ *ComplexType) Equals(other Type) bool {
 	_, ok := other.(*ComplexType)
 	return ok
 }
+
+// Wraps another type to allow "no value" (nil)
+type NullableType struct {
+	inner Type
+}
+
+func (typ *NullableType) String() string {
+	return fmt.Sprintf("nullable(%s)", typ.inner.String())
+}
+
+func (typ *NullableType) Validate(value any) bool {
+	if value == nil {
+		return true
+	}
+
+	return typ.inner.Validate(value)
+}
+
+func (typ *NullableType) Equals(other Type) bool {
+	otherNullable, ok := other.(*NullableType)
+	if !ok {
+		return false
+	}
+
+	return typ.inner.Equals(otherNullable.inner)
+}
+
+func (typ *NullableType) Inner() Type {
+	return typ.inner
+}
+
+type RecordField struct {
+	name      string
+	valueType Type
+}
+
+func (field *RecordField) Name() string {
+	return field.name
+}
+
+func (field *RecordField) ValueType() Type {
+	return field.valueType
+}
+
+// Structured value with named typed fields.
+//
+// Values are map[string]any with exactly the fields of the record.
+type RecordType struct {
+	fields []*RecordField
+}
+
+func (typ *RecordType) String() string {
+	fields := make([]string, 0, len(typ.fields))
+	for _, field := range typ.fields {
+		fields = append(fields, fmt.Sprintf("%s:%s", field.name, field.valueType.String()))
+	}
+
+	return fmt.Sprintf("record{%s}", strings.Join(fields, ","))
+}
+
+func (typ *RecordType) Validate(value any) bool {
+	mapValue, ok := value.(map[string]any)
+	if !ok || mapValue == nil {
+		return false
+	}
+
+	if len(mapValue) != len(typ.fields) {
+		return false
+	}
+
+	for _, field := range typ.fields {
+		fieldValue, exists := mapValue[field.name]
+		if !exists || !field.valueType.Validate(fieldValue) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (typ *RecordType) Equals(other Type) bool {
+	otherRecord, ok := other.(*RecordType)
+	if !ok {
+		return false
+	}
+
+	if len(typ.fields) != len(otherRecord.fields) {
+		return false
+	}
+
+	for _, field := range typ.fields {
+		otherField := otherRecord.Field(field.name)
+		if otherField == nil || !field.valueType.Equals(otherField.valueType) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (typ *RecordType) NumFields() int {
+	return len(typ.fields)
+}
+
+func (typ *RecordType) FieldAt(index int) *RecordField {
+	return typ.fields[index]
+}
+
+// Returns nil if the field does not exist
+func (typ *RecordType) Field(name string) *RecordField {
+	for _, field := range typ.fields {
+		if field.name == name {
+			return field
+		}
+	}
+
+	return nil
+}
+
+// List of values of the same type.
+//
+// Values are []any.
+type ArrayType struct {
+	item Type
+}
+
+func (typ *ArrayType) String() string {
+	return fmt.Sprintf("array(%s)", typ.item.String())
+}
+
+func (typ *ArrayType) Validate(value any) bool {
+	sliceValue, ok := value.([]any)
+	if !ok || sliceValue == nil {
+		return false
+	}
+
+	for _, item := range sliceValue {
+		if !typ.item.Validate(item) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (typ *ArrayType) Equals(other Type) bool {
+	otherArray, ok := other.(*ArrayType)
+	if !ok {
+		return false
+	}
+
+	return typ.item.Equals(otherArray.item)
+}
+
+func (typ *ArrayType) Item() Type {
+	return typ.item
+}
+
+// Zero value of a type, as used by a state before it is first set.
+//
+// Records and arrays are never nil, which is not a valid value for them. Scalar zero values may still be out of the type bounds.
+func ZeroValue(typ Type) any {
+	switch typ := typ.(type) {
+	case *RangeType:
+		return int64(0)
+	case *TextType, *EnumType:
+		return ""
+	case *FloatType:
+		return float64(0)
+	case *BoolType:
+		return false
+	case *RecordType:
+		value := make(map[string]any, len(typ.fields))
+		for _, field := range typ.fields {
+			value[field.name] = ZeroValue(field.valueType)
+		}
+		return value
+	case *ArrayType:
+		return []any{}
+	default:
+		// complex, nullable
+		return nil
+	}
+}
@@ -1,6 +1,8 @@
 package metadata
 
 import (
+	"regexp"
+
 	"github.com/gookit/goutil/errorx/panics"
 )
 
@@ -37,3 +39,41 @@ func MakeTypeEnum(values ...string) Type {
 func MakeTypeComplex() Type {
 	return &ComplexType{}
 }
+
+// Note: complex values can already be nil, nullable cannot be nested,
+// and structured values are represented by maps and slices in plugins
+func MakeTypeNullable(inner Type) Type {
+	switch inner.(type) {
+	case *ComplexType, *NullableType, *RecordType, *ArrayType:
+		panics.IsTrue(false, "type '%s' cannot be nullable", inner.String())
+	}
+
+	return &NullableType{inner}
+}
+
+var recordFieldNameParser = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func MakeRecordField(name string, valueType Type) *RecordField {
+	panics.IsTrue(recordFieldNameParser.MatchString(name), "invalid record field name '%s'", name)
+	panics.NotNil(valueType)
+
+	return &RecordField{name, valueType}
+}
+
+func MakeTypeRecord(fields ...*RecordField) Type {
+	panics.IsTrue(len(fields) > 0)
+
+	uniques := make(map[string]struct{})
+	for _, field := range fields {
+		uniques[field.name] = struct{}{}
+	}
+	panics.IsTrue(len(uniques) == len(fields))
+
+	return &RecordType{fields}
+}
+
+func MakeTypeArray(item Type) Type {
+	panics.NotNil(item)
+
+	return &ArrayType{item}
+}
@@ -10,6 +10,8 @@ import (
 var parser = regexp.MustCompile(`([a-z]+)(.*)`)
 var rangeParser = regexp.MustCompile(`\[(-?\d+);(-?\d+)\]`)
 var enumParser = regexp.MustCompile(`{(.[\w_\-,]+)}`)
+var innerParser = regexp.MustCompile(`^\((.+)\)$`)
+var recordParser = regexp.MustCompile(`^{(.+)}$`)
 
 func ParseType(value string) (Type, error) {
 	matchs := parser.FindStringSubmatch(value)
@@ -91,7 +93,91 @@ func ParseType(value string) (Type, error) {
 		}
 		return MakeTypeComplex(), nil
 
+	case "nullable":
+		matchs := innerParser.FindStringSubmatch(args)
+		if matchs == nil || len(matchs) != 2 {
+			return nil, fmt.Errorf("invalid type '%s' (bad args)", value)
+		}
+
+		inner, err := ParseType(matchs[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid type '%s' (%w)", value, err)
+		}
+
+		switch inner.(type) {
+		case *ComplexType, *NullableType, *RecordType, *ArrayType:
+			return nil, fmt.Errorf("invalid type '%s' (type cannot be nullable)", value)
+		}
+
+		return MakeTypeNullable(inner), nil
+
+	case "array":
+		matchs := innerParser.FindStringSubmatch(args)
+		if matchs == nil || len(matchs) != 2 {
+			return nil, fmt.Errorf("invalid type '%s' (bad args)", value)
+		}
+
+		item, err := ParseType(matchs[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid type '%s' (%w)", value, err)
+		}
+
+		return MakeTypeArray(item), nil
+
+	case "record":
+		matchs := recordParser.FindStringSubmatch(args)
+		if matchs == nil || len(matchs) != 2 {
+			return nil, fmt.Errorf("invalid type '%s' (bad args)", value)
+		}
+
+		fields := make([]*RecordField, 0)
+		names := make(map[string]struct{})
+
+		for _, item := range splitTopLevel(matchs[1]) {
+			name, fieldTypeStr, ok := strings.Cut(item, ":")
+			if !ok || !recordFieldNameParser.MatchString(name) {
+				return nil, fmt.Errorf("invalid type '%s' (bad field '%s')", value, item)
+			}
+
+			if _, exists := names[name]; exists {
+				return nil, fmt.Errorf("invalid type '%s' (duplicate field '%s')", value, name)
+			}
+			names[name] = struct{}{}
+
+			fieldType, err := ParseType(fieldTypeStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid type '%s' (%w)", value, err)
+			}
+
+			fields = append(fields, MakeRecordField(name, fieldType))
+		}
+
+		return MakeTypeRecord(fields...), nil
+
 	default:
 		return nil, fmt.Errorf("invalid type '%s' (unknown type)", value)
 	}
 }
+
+// Split on commas which are not nested in brackets, eg: 'a:enum{x,y},b:text' => ['a:enum{x,y}', 'b:text']
+func splitTopLevel(value string) []string {
+	items := make([]string, 0)
+	depth := 0
+	start := 0
+
+	for index, char := range value {
+		switch char {
+		case '{', '[', '(':
+			depth += 1
+		case '}', ']', ')':
+			depth -= 1
+		case ',':
+			if depth == 0 {
+				items = append(items, value[start:index])
+				start = index + 1
+			}
+		}
+	}
+
+	return append(items, value[start:])
+}
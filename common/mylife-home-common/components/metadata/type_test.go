@@ -40,9 +40,101 @@ func TestParseComplex(t *testing.T) {
 	testParseType(t, "complex")
 }
 
+func TestParseWideRange(t *testing.T) {
+	testParseType(t, "range[-9007199254740991;9007199254740991]")
+}
+
+func TestParseNullable(t *testing.T) {
+	testParseType(t, "nullable(range[0;100])")
+	testParseType(t, "nullable(enum{one,two})")
+}
+
+func TestParseNullableInvalid(t *testing.T) {
+	for _, str := range []string{"nullable", "nullable()", "nullable(complex)", "nullable(nullable(bool))", "nullable(range[0;100]"} {
+		_, err := ParseType(str)
+		assert.Error(t, err, str)
+	}
+}
+
+func TestNullableValidate(t *testing.T) {
+	typ := MakeTypeNullable(MakeTypeRange(0, 100))
+
+	assert.True(t, typ.Validate(nil))
+	assert.True(t, typ.Validate(int64(42)))
+	assert.False(t, typ.Validate(int64(-1)))
+	assert.False(t, typ.Validate("42"))
+}
+
+func TestNullableEquals(t *testing.T) {
+	assert.True(t, MakeTypeNullable(MakeTypeBool()).Equals(MakeTypeNullable(MakeTypeBool())))
+	assert.False(t, MakeTypeNullable(MakeTypeBool()).Equals(MakeTypeBool()))
+	assert.False(t, MakeTypeBool().Equals(MakeTypeNullable(MakeTypeBool())))
+}
+
 func TestEnumEquals(t *testing.T) {
 	e1 := MakeTypeEnum("one", "two")
 	e2 := MakeTypeEnum("two", "one")
 
 	assert.True(t, e1.Equals(e2))
 }
+
+func TestParseArray(t *testing.T) {
+	testParseType(t, "array(float)")
+	testParseType(t, "array(array(nullable(bool)))")
+}
+
+func TestParseRecord(t *testing.T) {
+	testParseType(t, "record{name:text,level:range[0;100],mode:enum{on,off},tags:array(text)}")
+	testParseType(t, "record{inner:record{a:bool,b:nullable(float)}}")
+}
+
+func TestParseStructuredInvalid(t *testing.T) {
+	for _, str := range []string{"array", "array()", "array(foo)", "record", "record{}", "record{a}", "record{a:text,a:bool}", "record{1a:text}", "record{a:text", "nullable(array(text))"} {
+		_, err := ParseType(str)
+		assert.Error(t, err, str)
+	}
+}
+
+func TestRecordValidate(t *testing.T) {
+	typ := MakeTypeRecord(MakeRecordField("name", MakeTypeText()), MakeRecordField("level", MakeTypeNullable(MakeTypeRange(0, 100))))
+
+	assert.True(t, typ.Validate(map[string]any{"name": "foo", "level": int64(42)}))
+	assert.True(t, typ.Validate(map[string]any{"name": "foo", "level": nil}))
+	assert.False(t, typ.Validate(map[string]any{"name": "foo"}))
+	assert.False(t, typ.Validate(map[string]any{"name": "foo", "level": int64(42), "other": true}))
+	assert.False(t, typ.Validate(map[string]any{"name": "foo", "level": int64(142)}))
+	assert.False(t, typ.Validate(map[string]any(nil)))
+	assert.False(t, typ.Validate("foo"))
+}
+
+func TestArrayValidate(t *testing.T) {
+	typ := MakeTypeArray(MakeTypeBool())
+
+	assert.True(t, typ.Validate([]any{}))
+	assert.True(t, typ.Validate([]any{true, false}))
+	assert.False(t, typ.Validate([]any{true, "false"}))
+	assert.False(t, typ.Validate([]any(nil)))
+	assert.False(t, typ.Validate([]bool{true}))
+}
+
+func TestStructuredZeroValue(t *testing.T) {
+	typ := MakeTypeRecord(MakeRecordField("name", MakeTypeText()), MakeRecordField("level", MakeTypeNullable(MakeTypeRange(0, 100))), MakeRecordField("tags", MakeTypeArray(MakeTypeText())))
+
+	value := ZeroValue(typ)
+	assert.Equal(t, map[string]any{"name": "", "level": nil, "tags": []any{}}, value)
+	assert.True(t, typ.Validate(value))
+}
+
+func TestStructuredEquals(t *testing.T) {
+	r1, _ := ParseType("record{a:text,b:array(bool)}")
+	r2, _ := ParseType("record{b:array(bool),a:text}")
+	r3, _ := ParseType("record{a:text,b:array(float)}")
+	r4, _ := ParseType("record{a:text}")
+
+	assert.True(t, r1.Equals(r2))
+	assert.False(t, r1.Equals(r3))
+	assert.False(t, r1.Equals(r4))
+	assert.False(t, r4.Equals(r1))
+	assert.False(t, r1.Equals(MakeTypeComplex()))
+	assert.False(t, MakeTypeArray(MakeTypeText()).Equals(MakeTypeArray(MakeTypeFloat())))
+}
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"regexp"
+	"strings"
+
 	config "github.com/gookit/config/v2"
 	yaml "github.com/gookit/config/v2/yaml"
 
@@ -21,7 +24,7 @@ func Init(configFile string) {
 		panic(err)
 	}
 
-	logger.Infof("Config loaded: %+v", conf.Data())
+	logger.Infof("Config loaded: %+v", redact("", conf.Data()))
 }
 
 func BindStructure(key string, value any) {
@@ -30,13 +33,13 @@ func BindStructure(key string, value any) {
 		panic(err)
 	}
 
-	logger.Debugf("Config '%s' fetched: %+v", key, value)
+	logger.Debugf("Config '%s' fetched: %+v", key, redact(key, conf.Get(key)))
 }
 
 func GetString(key string) string {
 	value := conf.MustString(key)
 
-	logger.Debugf("Config '%s' fetched: %s", key, value)
+	logger.Debugf("Config '%s' fetched: %s", key, redact(key, value))
 	return value
 }
 
@@ -54,6 +57,38 @@ func FindString(key string) (string, bool) {
 		return "", false
 	}
 
-	logger.Debugf("Config '%s' fetched: %s", key, str)
+	logger.Debugf("Config '%s' fetched: %s", key, redact(key, str))
 	return str, true
 }
+
+const redacted = "******"
+
+var secretKeyParser = regexp.MustCompile(`(?i)(password|secret|token|^pass$)`)
+
+// Hide the values of secret keys (eg: 'bus.password', 'store.secretKey') in logs
+func redact(key string, value any) any {
+	if secretKeyParser.MatchString(key[strings.LastIndex(key, ".")+1:]) {
+		return redacted
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		result := make(map[string]any)
+		for name, item := range typed {
+			result[name] = redact(name, item)
+		}
+
+		return result
+
+	case []any:
+		result := make([]any, 0, len(typed))
+		for _, item := range typed {
+			result = append(result, redact("", item))
+		}
+
+		return result
+
+	default:
+		return value
+	}
+}
@@ -33,6 +33,13 @@ func initInstanceName() {
 	instanceNameValue = hostname + "-" + mainComponentValue
 }
 
+// Suffix the instance name with the process id, for tools that may run several times at once.
+// (the instance name is also the bus client id, two connections with the same one would kick each other)
+func MakeInstanceNameUnique() {
+	panics.IsTrue(instanceNameValue != "", "InstanceName value has not been set")
+	instanceNameValue = fmt.Sprintf("%s-%d", instanceNameValue, os.Getpid())
+}
+
 func MainComponentVersion() string {
 	panics.IsTrue(mainComponentVersionValue != "", "MainComponentVersion value has not been set")
 	return mainComponentVersionValue
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"reflect"
 	"sync"
 
 	"github.com/gookit/goutil/errorx/panics"
@@ -118,7 +119,7 @@ func (sub *subjectValue[T]) Update(newValue T) bool {
 	sub.valMux.Lock()
 	defer sub.valMux.Unlock()
 
-	if sub.value == newValue {
+	if valuesEqual(sub.value, newValue) {
 		return false
 	}
 
@@ -169,3 +170,15 @@ func (sub *subjectValue[T]) Unsubscribe(observer chan<- T) {
 
 	delete(sub.observers, observer)
 }
+
+// Note: interfaces may hold maps or slices (eg: structured values), which panic with ==
+func valuesEqual[T comparable](a T, b T) bool {
+	typeA := reflect.TypeOf(any(a))
+	typeB := reflect.TypeOf(any(b))
+
+	if (typeA != nil && !typeA.Comparable()) || (typeB != nil && !typeB.Comparable()) {
+		return reflect.DeepEqual(a, b)
+	}
+
+	return a == b
+}
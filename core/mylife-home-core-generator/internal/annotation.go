@@ -15,15 +15,25 @@ type State struct {
 	Name        string `annotation:"name=name"`
 	Description string `annotation:"name=description"`
 	Type        string `annotation:"name=type"`
+	Persistent  bool   `annotation:"name=persistent,default=false"`
 }
 
 type Action struct {
 	Name        string `annotation:"name=name"`
 	Description string `annotation:"name=description"`
 	Type        string `annotation:"name=type"`
+	Debounce    string `annotation:"name=debounce"`
+	RateLimit   string `annotation:"name=rateLimit"`
 }
 
 type Config struct {
 	Name        string `annotation:"name=name"`
 	Description string `annotation:"name=description"`
+	Default     string `annotation:"name=default"`
+	Optional    bool   `annotation:"name=optional,default=false"`
+	Min         string `annotation:"name=min"`
+	Max         string `annotation:"name=max"`
+	Enum        string `annotation:"name=enum"`
+	Pattern     string `annotation:"name=pattern"`
+	Secret      bool   `annotation:"name=secret,default=false"`
 }
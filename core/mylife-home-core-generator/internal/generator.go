@@ -1,10 +1,15 @@
 package internal
 
 import (
+	"encoding/json"
 	"fmt"
 	"go/ast"
+	"go/types"
 	"mylife-home-common/components/metadata"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	annotation "github.com/YReshetko/go-annotation/pkg"
 	"github.com/gookit/goutil/errorx/panics"
@@ -47,6 +52,7 @@ type StateData struct {
 	name        string
 	description string
 	valueType   metadata.Type
+	persistent  bool
 }
 
 type ActionData struct {
@@ -59,6 +65,8 @@ type ActionData struct {
 	name        string
 	description string
 	valueType   metadata.Type
+	debounce    time.Duration
+	rateLimit   time.Duration
 }
 
 type ConfigData struct {
@@ -70,6 +78,18 @@ type ConfigData struct {
 	name        string
 	description string
 	valueType   metadata.ConfigType
+	constraints *ConfigConstraintsData
+}
+
+// Parsed from the '@Config' annotation, nil/empty fields are not set
+type ConfigConstraintsData struct {
+	defaultValue any
+	optional     bool
+	min          *float64
+	max          *float64
+	enum         []string
+	pattern      string
+	secret       bool
 }
 
 func MakeGenerator(node annotation.Node, outputPath string, moduleName string) *Generator {
@@ -205,13 +225,14 @@ func (generator *Generator) enrich() {
 			}
 
 			state.description = state.ann.Description
+			state.persistent = state.ann.Persistent
 
 			// Ensure we have "definitions.State[__the_type__]"
 			indexExpr := state.field.Type.(*ast.IndexExpr)
 			selExpr := indexExpr.X.(*ast.SelectorExpr)
 			panics.IsTrue(selExpr.Sel.Name == "State")
 			panics.IsTrue(selExpr.X.(*ast.Ident).Name == "definitions")
-			nativeTypeName := indexExpr.Index.(*ast.Ident).Name
+			nativeTypeName := getNativeTypeName(indexExpr.Index)
 
 			state.valueType = parseType(state.ann.Type, nativeTypeName)
 		}
@@ -228,9 +249,13 @@ func (generator *Generator) enrich() {
 			fnType := action.fn.Type
 			panics.IsTrue(fnType.Results == nil || len(fnType.Results.List) == 0)
 			panics.IsTrue(action.fn.Type.Params != nil && len(action.fn.Type.Params.List) == 1)
-			nativeTypeName := action.fn.Type.Params.List[0].Type.(*ast.Ident).Name
+			nativeTypeName := getNativeTypeName(action.fn.Type.Params.List[0].Type)
 
 			action.valueType = parseType(action.ann.Type, nativeTypeName)
+
+			action.debounce = parseActionDelay(action.ann.Debounce)
+			action.rateLimit = parseActionDelay(action.ann.RateLimit)
+			panics.IsTrue(action.debounce == 0 || action.rateLimit == 0, "Invalid action '%s' on plugin '%s': debounce and rateLimit cannot be combined", action.name, plugin.name)
 		}
 
 		for _, config := range plugin.configs {
@@ -243,6 +268,10 @@ func (generator *Generator) enrich() {
 
 			nativeTypeName := config.field.Type.(*ast.Ident).Name
 			config.valueType = parseConfigType(nativeTypeName)
+			config.constraints = parseConfigConstraints(config.ann, config.valueType)
+
+			err := config.constraints.toMetadata().Check(config.valueType)
+			panics.IsTrue(err == nil, "Invalid config '%s' on plugin '%s': %s", config.name, plugin.name, err)
 		}
 	}
 }
@@ -273,6 +302,12 @@ func parseType(provided string, native string) metadata.Type {
 			return metadata.MakeTypeBool()
 		case "any":
 			return metadata.MakeTypeComplex()
+		case "*string":
+			return metadata.MakeTypeNullable(metadata.MakeTypeText())
+		case "*float64":
+			return metadata.MakeTypeNullable(metadata.MakeTypeFloat())
+		case "*bool":
+			return metadata.MakeTypeNullable(metadata.MakeTypeBool())
 		default:
 			panic(fmt.Sprintf("Cannot infer metadata type from native type '%s'", native))
 		}
@@ -281,24 +316,40 @@ func parseType(provided string, native string) metadata.Type {
 	providedType, err := metadata.ParseType(provided)
 	panics.IsTrue(err == nil, "Error parsing type '%s': %s", provided, err)
 
-	switch providedType.(type) {
+	expectNative(getExpectedNative(providedType), native)
+
+	return providedType
+}
+
+func getExpectedNative(typ metadata.Type) string {
+	switch typed := typ.(type) {
 	case *metadata.RangeType:
-		expectNative("int64", native)
+		return "int64"
 	case *metadata.TextType:
-		expectNative("string", native)
+		return "string"
 	case *metadata.FloatType:
-		expectNative("float64", native)
+		return "float64"
 	case *metadata.BoolType:
-		expectNative("bool", native)
+		return "bool"
 	case *metadata.EnumType:
-		expectNative("string", native)
+		return "string"
 	case *metadata.ComplexType:
-		expectNative("any", native)
+		return "any"
+	case *metadata.NullableType:
+		// eg: nullable(range[0;100]) => *int64
+		return "*" + getExpectedNative(typed.Inner())
+	case *metadata.RecordType:
+		return "map[string]any"
+	case *metadata.ArrayType:
+		return "[]any"
 	default:
-		panic(fmt.Sprintf("Unexpected type '%s'", providedType.String()))
+		panic(fmt.Sprintf("Unexpected type '%s'", typ.String()))
 	}
+}
 
-	return providedType
+// 'int64', '*int64' for pointers, 'map[string]any' for records
+func getNativeTypeName(expr ast.Expr) string {
+	return types.ExprString(expr)
 }
 
 func expectNative(expected string, native string) {
@@ -307,6 +358,18 @@ func expectNative(expected string, native string) {
 	}
 }
 
+// eg: '500ms', 0 if not provided
+func parseActionDelay(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	delay, err := time.ParseDuration(value)
+	panics.IsTrue(err == nil && delay > 0, "Invalid action delay '%s'", value)
+
+	return delay
+}
+
 func parseConfigType(native string) metadata.ConfigType {
 	switch native {
 	case "string":
@@ -322,6 +385,84 @@ func parseConfigType(native string) metadata.ConfigType {
 	}
 }
 
+func parseConfigConstraints(ann *Config, valueType metadata.ConfigType) *ConfigConstraintsData {
+	constraints := &ConfigConstraintsData{
+		optional: ann.Optional,
+		pattern:  ann.Pattern,
+		secret:   ann.Secret,
+	}
+
+	if ann.Default != "" {
+		if valueType == metadata.String {
+			constraints.defaultValue = ann.Default
+		} else {
+			value, err := valueType.Read(json.RawMessage(ann.Default))
+			panics.IsTrue(err == nil, "Invalid config default '%s': %s", ann.Default, err)
+			constraints.defaultValue = value
+		}
+	}
+
+	constraints.min = parseConfigLimit(ann.Min)
+	constraints.max = parseConfigLimit(ann.Max)
+
+	if ann.Enum != "" {
+		constraints.enum = strings.Split(ann.Enum, ",")
+	}
+
+	return constraints
+}
+
+func parseConfigLimit(value string) *float64 {
+	if value == "" {
+		return nil
+	}
+
+	limit, err := strconv.ParseFloat(value, 64)
+	panics.IsTrue(err == nil, "Invalid config limit '%s': %s", value, err)
+
+	return &limit
+}
+
+func (constraints *ConfigConstraintsData) isEmpty() bool {
+	return constraints.defaultValue == nil && !constraints.optional && constraints.min == nil && constraints.max == nil && constraints.enum == nil && constraints.pattern == "" && !constraints.secret
+}
+
+func (constraints *ConfigConstraintsData) toMetadata() *metadata.ConfigConstraints {
+	result := metadata.MakeConfigConstraints()
+
+	if constraints.defaultValue != nil {
+		result.SetDefault(constraints.defaultValue)
+	}
+
+	if constraints.optional {
+		result.SetOptional()
+	}
+
+	if constraints.min != nil {
+		result.SetMin(*constraints.min)
+	}
+
+	if constraints.max != nil {
+		result.SetMax(*constraints.max)
+	}
+
+	if constraints.enum != nil {
+		result.SetEnum(constraints.enum...)
+	}
+
+	if constraints.pattern != "" {
+		_, err := regexp.Compile(constraints.pattern)
+		panics.IsTrue(err == nil, "Invalid config pattern '%s': %s", constraints.pattern, err)
+		result.SetPattern(constraints.pattern)
+	}
+
+	if constraints.secret {
+		result.SetSecret()
+	}
+
+	return result
+}
+
 func makeModuleName(name string) string {
 	return strcase.ToKebab(name)
 }
@@ -341,15 +482,15 @@ func (generator *Generator) write() []byte {
 		writer.BeginPlugin(plugin.typeName, generator.moduleName, plugin.name, plugin.description, plugin.usage, generator.moduleVersion)
 
 		for _, state := range plugin.states {
-			writer.AddState(state.fieldName, state.name, state.description, state.valueType)
+			writer.AddState(state.fieldName, state.name, state.description, state.valueType, state.persistent)
 		}
 
 		for _, action := range plugin.actions {
-			writer.AddAction(action.methName, action.name, action.description, action.valueType)
+			writer.AddAction(action.methName, action.name, action.description, action.valueType, action.debounce, action.rateLimit)
 		}
 
 		for _, config := range plugin.configs {
-			writer.AddConfig(config.fieldName, config.name, config.description, config.valueType)
+			writer.AddConfig(config.fieldName, config.name, config.description, config.valueType, config.constraints)
 		}
 
 		writer.EndPlugin()
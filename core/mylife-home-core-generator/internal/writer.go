@@ -4,27 +4,30 @@ import (
 	"encoding/json"
 	"fmt"
 	"mylife-home-common/components/metadata"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Writer struct {
-	builder *strings.Builder
+	packageName string
+	imports     []string
+	builder     *strings.Builder
 }
 
 func MakeWrite(packageName string) *Writer {
-	writer := &Writer{
-		builder: &strings.Builder{},
+	return &Writer{
+		packageName: packageName,
+		imports:     []string{"mylife-home-common/components/metadata", "mylife-home-core-library/registry"},
+		builder:     &strings.Builder{},
 	}
+}
 
-	writer.appendLinef(`package %s`, packageName)
-	writer.appendLine(``)
-	writer.appendLine(`import (`)
-	writer.appendLine(`	"mylife-home-common/components/metadata"`)
-	writer.appendLine(`	"mylife-home-core-library/registry"`)
-	writer.appendLine(`)`)
-	writer.appendLine(``)
-
-	return writer
+func (writer *Writer) addImport(path string) {
+	if !slices.Contains(writer.imports, path) {
+		writer.imports = append(writer.imports, path)
+	}
 }
 
 func (writer *Writer) appendBlock(str string) {
@@ -50,29 +53,64 @@ func (writer *Writer) BeginPlugin(pluginType string, module string, name string,
 		renderStringLiteral(version))
 }
 
-func (writer *Writer) AddState(fieldName string, name string, description string, valueType metadata.Type) {
-	writer.appendLinef(`	builder.AddState(%s, %s, %s, %s)`,
+func (writer *Writer) AddState(fieldName string, name string, description string, valueType metadata.Type, persistent bool) {
+	method := "AddState"
+	if persistent {
+		method = "AddPersistentState"
+	}
+
+	writer.appendLinef(`	builder.%s(%s, %s, %s, %s)`,
+		method,
 		renderStringLiteral(fieldName),
 		renderStringLiteral(name),
 		renderStringLiteral(description),
 		renderType(valueType))
 }
 
-func (writer *Writer) AddAction(methodName string, name string, description string, valueType metadata.Type) {
-	writer.appendLinef(`	builder.AddAction(%s, %s, %s, %s)`,
+func (writer *Writer) AddAction(methodName string, name string, description string, valueType metadata.Type, debounce time.Duration, rateLimit time.Duration) {
+	if debounce == 0 && rateLimit == 0 {
+		writer.appendLinef(`	builder.AddAction(%s, %s, %s, %s)`,
+			renderStringLiteral(methodName),
+			renderStringLiteral(name),
+			renderStringLiteral(description),
+			renderType(valueType))
+		return
+	}
+
+	writer.addImport("time")
+
+	limit := `registry.MakeActionLimit()`
+	if debounce > 0 {
+		limit += fmt.Sprintf(`.SetDebounce(%s)`, renderDuration(debounce))
+	}
+	if rateLimit > 0 {
+		limit += fmt.Sprintf(`.SetRateLimit(%s)`, renderDuration(rateLimit))
+	}
+
+	writer.appendLinef(`	builder.AddLimitedAction(%s, %s, %s, %s, %s)`,
 		renderStringLiteral(methodName),
 		renderStringLiteral(name),
 		renderStringLiteral(description),
-		renderType(valueType))
-
+		renderType(valueType),
+		limit)
 }
 
-func (writer *Writer) AddConfig(fieldName string, name string, description string, valueType metadata.ConfigType) {
-	writer.appendLinef(`	builder.AddConfig(%s, %s, %s, %s)`,
+func (writer *Writer) AddConfig(fieldName string, name string, description string, valueType metadata.ConfigType, constraints *ConfigConstraintsData) {
+	if constraints.isEmpty() {
+		writer.appendLinef(`	builder.AddConfig(%s, %s, %s, %s)`,
+			renderStringLiteral(fieldName),
+			renderStringLiteral(name),
+			renderStringLiteral(description),
+			renderConfigType(valueType))
+		return
+	}
+
+	writer.appendLinef(`	builder.AddConstrainedConfig(%s, %s, %s, %s, %s)`,
 		renderStringLiteral(fieldName),
 		renderStringLiteral(name),
 		renderStringLiteral(description),
-		renderConfigType(valueType))
+		renderConfigType(valueType),
+		renderConfigConstraints(constraints))
 }
 
 func (writer *Writer) EndPlugin() {
@@ -82,7 +120,20 @@ func (writer *Writer) EndPlugin() {
 }
 
 func (writer *Writer) Content() []byte {
-	return []byte(writer.builder.String())
+	header := &strings.Builder{}
+
+	header.WriteString(fmt.Sprintf("package %s\n\n", writer.packageName))
+	header.WriteString("import (\n")
+
+	imports := slices.Clone(writer.imports)
+	slices.Sort(imports)
+	for _, path := range imports {
+		header.WriteString(fmt.Sprintf("\t%s\n", strconv.Quote(path)))
+	}
+
+	header.WriteString(")\n\n")
+
+	return []byte(header.String() + writer.builder.String())
 }
 
 func renderPluginUsage(usage metadata.PluginUsage) string {
@@ -131,6 +182,26 @@ func renderType(typ metadata.Type) string {
 	case *metadata.ComplexType:
 		return `metadata.MakeTypeComplex()`
 
+	case *metadata.NullableType:
+		return fmt.Sprintf(`metadata.MakeTypeNullable(%s)`, renderType(typed.Inner()))
+
+	case *metadata.RecordType:
+		builder := strings.Builder{}
+		builder.WriteString(`metadata.MakeTypeRecord(`)
+		for index := 0; index < typed.NumFields(); index += 1 {
+			if index > 0 {
+				builder.WriteString(`, `)
+			}
+
+			field := typed.FieldAt(index)
+			builder.WriteString(fmt.Sprintf(`metadata.MakeRecordField("%s", %s)`, field.Name(), renderType(field.ValueType())))
+		}
+		builder.WriteString(`)`)
+		return builder.String()
+
+	case *metadata.ArrayType:
+		return fmt.Sprintf(`metadata.MakeTypeArray(%s)`, renderType(typed.Item()))
+
 	default:
 		return "???"
 	}
@@ -151,6 +222,87 @@ func renderConfigType(configType metadata.ConfigType) string {
 	}
 }
 
+func renderConfigConstraints(constraints *ConfigConstraintsData) string {
+	builder := strings.Builder{}
+	builder.WriteString(`metadata.MakeConfigConstraints()`)
+
+	if constraints.defaultValue != nil {
+		builder.WriteString(fmt.Sprintf(`.SetDefault(%s)`, renderConfigValue(constraints.defaultValue)))
+	}
+
+	if constraints.optional {
+		builder.WriteString(`.SetOptional()`)
+	}
+
+	if constraints.min != nil {
+		builder.WriteString(fmt.Sprintf(`.SetMin(%s)`, renderFloat(*constraints.min)))
+	}
+
+	if constraints.max != nil {
+		builder.WriteString(fmt.Sprintf(`.SetMax(%s)`, renderFloat(*constraints.max)))
+	}
+
+	if constraints.enum != nil {
+		values := make([]string, 0, len(constraints.enum))
+		for _, value := range constraints.enum {
+			values = append(values, renderStringLiteral(value))
+		}
+
+		builder.WriteString(fmt.Sprintf(`.SetEnum(%s)`, strings.Join(values, ", ")))
+	}
+
+	if constraints.pattern != "" {
+		builder.WriteString(fmt.Sprintf(`.SetPattern(%s)`, renderStringLiteral(constraints.pattern)))
+	}
+
+	if constraints.secret {
+		builder.WriteString(`.SetSecret()`)
+	}
+
+	return builder.String()
+}
+
+func renderConfigValue(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return renderStringLiteral(typed)
+	case bool:
+		return strconv.FormatBool(typed)
+	case int64:
+		return fmt.Sprintf(`int64(%d)`, typed)
+	case float64:
+		return fmt.Sprintf(`float64(%s)`, renderFloat(typed))
+	default:
+		return "???"
+	}
+}
+
+// eg: '500 * time.Millisecond'
+func renderDuration(value time.Duration) string {
+	units := []struct {
+		unit time.Duration
+		name string
+	}{
+		{time.Hour, "time.Hour"},
+		{time.Minute, "time.Minute"},
+		{time.Second, "time.Second"},
+		{time.Millisecond, "time.Millisecond"},
+		{time.Microsecond, "time.Microsecond"},
+	}
+
+	for _, item := range units {
+		if value%item.unit == 0 {
+			return fmt.Sprintf("%d * %s", value/item.unit, item.name)
+		}
+	}
+
+	return fmt.Sprintf("time.Duration(%d)", int64(value))
+}
+
+func renderFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
 func renderStringLiteral(value string) string {
 	// annotation lexer split the strings into []byte, then encode them back using 'str += string(b)
 	// this crashed UTF8 encoding.
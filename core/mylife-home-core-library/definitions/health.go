@@ -0,0 +1,10 @@
+package definitions
+
+// Health reported by a plugin instance through its runtime
+type HealthStatus string
+
+const (
+	HealthOk       HealthStatus = "ok"
+	HealthDegraded HealthStatus = "degraded" // working with limitations (eg: reconnecting, partial data)
+	HealthError    HealthStatus = "error"    // not working (eg: device unreachable)
+)
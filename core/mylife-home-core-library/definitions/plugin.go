@@ -4,3 +4,11 @@ type Plugin interface {
 	Init(runtime Runtime) error
 	Terminate() // Note: will be executed even if Init() returns an error
 }
+
+// Optional, implemented by plugins which can apply a new configuration without being recreated.
+//
+// Configuration fields are updated before the call, which is serialized with actions.
+// If an error is returned, the component is recreated with the new configuration.
+type Reconfigurable interface {
+	Reconfigure() error
+}
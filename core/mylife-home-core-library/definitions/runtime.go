@@ -1,5 +1,58 @@
 package definitions
 
+import (
+	"context"
+	"mylife-home-common/log"
+	"time"
+)
+
+// Services provided to a plugin instance, from Init until Terminate.
+//
+// Timers, tickers and the context are bound to the plugin instance: they are stopped/cancelled when it is terminated
+// (including when the component is recreated on reconfiguration).
 type Runtime interface {
 	ComponentId() string
+
+	// Logger of the component (named after its id)
+	Logger() log.Logger
+
+	// Cancelled when the plugin instance is terminated
+	Context() context.Context
+
+	// Time source of the component: use it instead of the 'time' package so that the plugin can be tested with virtual time
+	Clock() Clock
+
+	// Call callback once after delay.
+	// Callbacks are serialized with actions, and never called after Terminate.
+	AfterFunc(delay time.Duration, callback func()) Timer
+
+	// Call callback every period, like AfterFunc
+	TickFunc(period time.Duration, callback func()) Timer
+
+	// Key/value data of the component, kept across restarts (if 'manager.statePath' is configured) and reconfigurations
+	Data() Data
+
+	// Report the health of the component, published on the bus and listed with the components.
+	// It is ok when the plugin instance is created, message explains other statuses
+	SetHealth(status HealthStatus, message string)
+}
+
+type Clock interface {
+	Now() time.Time
+
+	// Call callback once after delay, on its own goroutine
+	AfterFunc(delay time.Duration, callback func()) Timer
+}
+
+type Timer interface {
+	// Returns false if the timer had already expired or been stopped
+	Stop() bool
+}
+
+// Values are JSON encoded
+type Data interface {
+	// Read the value of key into value (pointer), returns false if the key has no value
+	Get(key string, value any) (bool, error)
+	Set(key string, value any) error
+	Delete(key string)
 }
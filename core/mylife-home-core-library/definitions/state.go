@@ -3,4 +3,8 @@ package definitions
 type State[T any] interface {
 	Get() T
 	Set(value T)
+
+	// True if the value was restored at component creation (states annotated with '@State(persistent="true")').
+	// Init should then keep it instead of setting the initial value.
+	Restored() bool
 }
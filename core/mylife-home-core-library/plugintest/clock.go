@@ -0,0 +1,126 @@
+package plugintest
+
+import (
+	"mylife-home-core-library/definitions"
+	"sync"
+	"time"
+)
+
+var _ definitions.Clock = (*Clock)(nil)
+var _ definitions.Timer = (*clockTimer)(nil)
+
+// Virtual time source: time only moves with Advance, which runs the expired timers callbacks
+type Clock struct {
+	now    time.Time
+	timers []*clockTimer // pending timers
+	seq    int           // creation order, to fire timers with the same due time in order
+	mux    sync.Mutex
+}
+
+func NewClock(now time.Time) *Clock {
+	return &Clock{
+		now:    now,
+		timers: make([]*clockTimer, 0),
+	}
+}
+
+func (clock *Clock) Now() time.Time {
+	clock.mux.Lock()
+	defer clock.mux.Unlock()
+
+	return clock.now
+}
+
+func (clock *Clock) AfterFunc(delay time.Duration, callback func()) definitions.Timer {
+	clock.mux.Lock()
+	defer clock.mux.Unlock()
+
+	clock.seq += 1
+	timer := &clockTimer{
+		clock:    clock,
+		due:      clock.now.Add(delay),
+		seq:      clock.seq,
+		callback: callback,
+	}
+
+	clock.timers = append(clock.timers, timer)
+	return timer
+}
+
+// Move time forward by delay.
+//
+// Timers expiring meanwhile are fired in order, on the calling goroutine, with Now() set to their due time.
+// Timers created by the callbacks are fired too if they expire before the end of the delay.
+func (clock *Clock) Advance(delay time.Duration) {
+	clock.mux.Lock()
+	end := clock.now.Add(delay)
+	clock.mux.Unlock()
+
+	for {
+		timer := clock.popNext(end)
+		if timer == nil {
+			return
+		}
+
+		timer.callback()
+	}
+}
+
+// Number of timers which have not expired yet
+func (clock *Clock) Pending() int {
+	clock.mux.Lock()
+	defer clock.mux.Unlock()
+
+	return len(clock.timers)
+}
+
+// Remove the next timer expiring before end and move time to it, or move time to end if there is none
+func (clock *Clock) popNext(end time.Time) *clockTimer {
+	clock.mux.Lock()
+	defer clock.mux.Unlock()
+
+	var next *clockTimer
+	nextIndex := -1
+	for index, timer := range clock.timers {
+		if timer.due.After(end) {
+			continue
+		}
+
+		if next == nil || timer.due.Before(next.due) || (timer.due.Equal(next.due) && timer.seq < next.seq) {
+			next = timer
+			nextIndex = index
+		}
+	}
+
+	if next == nil {
+		clock.now = end
+		return nil
+	}
+
+	clock.timers = append(clock.timers[:nextIndex], clock.timers[nextIndex+1:]...)
+	clock.now = next.due
+	return next
+}
+
+type clockTimer struct {
+	clock    *Clock
+	due      time.Time
+	seq      int
+	callback func()
+}
+
+func (timer *clockTimer) Stop() bool {
+	clock := timer.clock
+
+	clock.mux.Lock()
+	defer clock.mux.Unlock()
+
+	for index, pending := range clock.timers {
+		if pending == timer {
+			clock.timers = append(clock.timers[:index], clock.timers[index+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
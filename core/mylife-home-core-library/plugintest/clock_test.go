@@ -0,0 +1,35 @@
+package plugintest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClock(t *testing.T) {
+	clock := NewClock(Epoch)
+	fired := make([]string, 0)
+
+	clock.AfterFunc(2*time.Second, func() { fired = append(fired, "b") })
+	clock.AfterFunc(time.Second, func() {
+		fired = append(fired, "a")
+		assert.Equal(t, Epoch.Add(time.Second), clock.Now())
+
+		// created by a callback, expires within the same advance
+		clock.AfterFunc(500*time.Millisecond, func() { fired = append(fired, "a2") })
+	})
+	stopped := clock.AfterFunc(time.Second, func() { fired = append(fired, "stopped") })
+
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop())
+
+	clock.Advance(1500 * time.Millisecond)
+	assert.Equal(t, []string{"a", "a2"}, fired)
+	assert.Equal(t, Epoch.Add(1500*time.Millisecond), clock.Now())
+	assert.Equal(t, 1, clock.Pending())
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, []string{"a", "a2", "b"}, fired)
+	assert.Equal(t, 0, clock.Pending())
+}
@@ -0,0 +1,352 @@
+// Run plugins in unit tests, without the core: set the config, call actions, record state changes, and control time.
+//
+//	h := plugintest.New(t, pluginType, map[string]any{"triggers0": "l s"})
+//	h.Action("action", true)
+//	h.Advance(time.Second)
+//	h.Action("action", false)
+//	assert.Equal(t, []any{true, false}, h.Values("output0"))
+//
+// Plugins must use the runtime (Clock, AfterFunc, TickFunc) instead of the 'time' package to be driven by the virtual clock.
+package plugintest
+
+import (
+	"fmt"
+	"mylife-home-core-library/definitions"
+	"mylife-home-core-library/registry"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Initial time of the virtual clock
+var Epoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Plugin instance under test
+type Harness struct {
+	t           testing.TB
+	id          string
+	pluginType  *registry.PluginType
+	target      reflect.Value // pointer to the plugin struct
+	clock       *Clock
+	runtime     *registry.PluginRuntime
+	health      definitions.HealthStatus
+	message     string
+	healthMux   sync.Mutex
+	states      map[string]untypedState
+	actions     map[string]*registry.ActionType
+	limiters    map[string]*registry.ActionLimiter // only for limited actions
+	changes     []StateChange
+	changesMux  sync.Mutex
+	dispatchMux sync.Mutex // actions and timers callbacks are serialized, like in the core
+	initialized bool
+	terminated  bool
+}
+
+// Registered plugin type, by id (eg: 'logic-selectors.smart-input'), nil if not found.
+//
+// Note: plugin types are registered by the generated code of the plugin package
+func Find(id string) *registry.PluginType {
+	for index := 0; index < registry.NumPlugins(); index += 1 {
+		pluginType := registry.GetPlugin(index)
+		if pluginType.Metadata().Id() == id {
+			return pluginType
+		}
+	}
+
+	return nil
+}
+
+// Registered plugin type, by id. The test is skipped if it is not registered:
+// the generated code of the plugin package is missing ('make generate' before testing).
+func FindOrSkip(t testing.TB, id string) *registry.PluginType {
+	t.Helper()
+
+	pluginType := Find(id)
+	if pluginType == nil {
+		t.Skipf("plugin '%s' is not registered: run 'make generate' first", id)
+	}
+
+	return pluginType
+}
+
+// Create and initialize the plugin instance, the test fails if Init returns an error.
+// It is terminated at the end of the test.
+func New(t testing.TB, pluginType *registry.PluginType, config map[string]any) *Harness {
+	t.Helper()
+
+	harness := Create(t, pluginType, config)
+	if err := harness.Init(); err != nil {
+		t.Fatalf("plugin init failed: %s", err)
+	}
+
+	return harness
+}
+
+// Create the plugin instance, without initializing it (eg: to restore states first).
+//
+// Config values are Go values (numbers are converted), omitted items get their default value.
+// The test fails if the config is invalid.
+func Create(t testing.TB, pluginType *registry.PluginType, config map[string]any) *Harness {
+	t.Helper()
+
+	harness := &Harness{
+		t:          t,
+		id:         pluginType.Metadata().Name(),
+		pluginType: pluginType,
+		target:     reflect.New(pluginType.Target()),
+		clock:      NewClock(Epoch),
+		states:     make(map[string]untypedState),
+		actions:    make(map[string]*registry.ActionType),
+		limiters:   make(map[string]*registry.ActionLimiter),
+		changes:    make([]StateChange, 0),
+		health:     definitions.HealthOk,
+	}
+
+	harness.runtime = makeRuntime(harness)
+
+	if err := harness.configure(config); err != nil {
+		t.Fatalf("invalid config: %s", err)
+	}
+
+	for index := 0; index < pluginType.NumState(); index += 1 {
+		stateType := pluginType.StateItem(index)
+		name := stateType.Metadata().Name()
+		state := makeState(name, harness, stateType.Metadata().ValueType())
+		harness.states[name] = state
+		harness.target.Elem().FieldByIndex(stateType.Target().Index).Set(reflect.ValueOf(state))
+	}
+
+	for index := 0; index < pluginType.NumActions(); index += 1 {
+		actionType := pluginType.Action(index)
+		harness.actions[actionType.Metadata().Name()] = actionType
+
+		if limit := actionType.Limit(); limit != nil {
+			fn := actionType.Target().Func
+			harness.limiters[actionType.Metadata().Name()] = registry.MakeActionLimiter(limit, harness.clock, harness.dispatch, func(value any) {
+				fn.Call([]reflect.Value{harness.target, reflect.ValueOf(value)})
+			})
+		}
+	}
+
+	t.Cleanup(harness.Terminate)
+
+	return harness
+}
+
+func (harness *Harness) configure(config map[string]any) error {
+	known := make(map[string]struct{})
+
+	for index := 0; index < harness.pluginType.NumConfig(); index += 1 {
+		configType := harness.pluginType.ConfigItem(index)
+		meta := configType.Metadata()
+		known[meta.Name()] = struct{}{}
+
+		value, ok := config[meta.Name()]
+		if !ok {
+			value = meta.Default()
+			if value == nil {
+				return fmt.Errorf("item '%s': missing value", meta.Name())
+			}
+		}
+
+		converted, err := convertValue(configType.Target().Type, value)
+		if err != nil {
+			return fmt.Errorf("item '%s': %w", meta.Name(), err)
+		}
+
+		if err := meta.Validate(converted.Interface()); err != nil {
+			return fmt.Errorf("item '%s': %w", meta.Name(), err)
+		}
+
+		harness.target.Elem().FieldByIndex(configType.Target().Index).Set(converted)
+	}
+
+	for name := range config {
+		if _, ok := known[name]; !ok {
+			return fmt.Errorf("unknown item '%s'", name)
+		}
+	}
+
+	return nil
+}
+
+// Set the value of a state as if it was persisted, before Init
+func (harness *Harness) Restore(name string, value any) {
+	harness.t.Helper()
+
+	if harness.initialized {
+		harness.t.Fatalf("state '%s' restored after init", name)
+	}
+
+	if err := harness.state(name).restore(value); err != nil {
+		harness.t.Fatalf("%s", err)
+	}
+}
+
+func (harness *Harness) Init() error {
+	harness.initialized = true
+
+	var err error
+	harness.dispatch(func() {
+		err = harness.Plugin().Init(harness.runtime)
+	})
+
+	return err
+}
+
+// Terminate the plugin instance, stop its timers and cancel its context.
+//
+// Note: called at the end of the test
+func (harness *Harness) Terminate() {
+	if !harness.initialized || harness.terminated {
+		return
+	}
+
+	harness.terminated = true
+
+	harness.dispatch(func() {
+		// Pending values are dropped, like in the core
+		for _, limiter := range harness.limiters {
+			limiter.Stop()
+		}
+
+		harness.Plugin().Terminate()
+	})
+
+	harness.runtime.Terminate()
+}
+
+// Plugin instance, eg: to check its fields
+func (harness *Harness) Plugin() definitions.Plugin {
+	return harness.target.Interface().(definitions.Plugin)
+}
+
+func (harness *Harness) Runtime() definitions.Runtime {
+	return harness.runtime
+}
+
+func (harness *Harness) Clock() *Clock {
+	return harness.clock
+}
+
+// Health last reported by the plugin instance (ok if it did not report any)
+func (harness *Harness) Health() (definitions.HealthStatus, string) {
+	harness.healthMux.Lock()
+	defer harness.healthMux.Unlock()
+
+	return harness.health, harness.message
+}
+
+func (harness *Harness) setHealth(status definitions.HealthStatus, message string) {
+	harness.healthMux.Lock()
+	defer harness.healthMux.Unlock()
+
+	harness.health = status
+	harness.message = message
+}
+
+// Move the virtual time forward, running the timers expiring meanwhile
+func (harness *Harness) Advance(delay time.Duration) {
+	harness.clock.Advance(delay)
+}
+
+// Call an action (by its name in metadata), nullable values can be given as nil or the inner value.
+//
+// The test fails if the value does not match the action type.
+// Action limits (debounce, rate limit) are applied with the virtual clock: use Advance to get the delayed calls.
+func (harness *Harness) Action(name string, value any) {
+	harness.t.Helper()
+
+	actionType, ok := harness.actions[name]
+	if !ok {
+		harness.t.Fatalf("unknown action '%s'", name)
+	}
+
+	fn := actionType.Target().Func
+	arg, err := convertValue(fn.Type().In(1), value)
+	if err != nil {
+		harness.t.Fatalf("invalid value for action '%s': %s", name, err)
+	}
+
+	// The core drops values which do not match the action type
+	if typ := actionType.Metadata().ValueType(); !typ.Validate(published(arg.Interface())) {
+		harness.t.Fatalf("invalid value for action '%s': %+v (expected type '%s')", name, value, typ.String())
+	}
+
+	harness.dispatch(func() {
+		if limiter, ok := harness.limiters[name]; ok {
+			limiter.Submit(arg.Interface())
+			return
+		}
+
+		fn.Call([]reflect.Value{harness.target, arg})
+	})
+}
+
+// Current value of a state (by its name in metadata), nullable values are nil or the inner value
+func (harness *Harness) State(name string) any {
+	harness.t.Helper()
+
+	return harness.state(name).get()
+}
+
+// All state changes so far, in order
+func (harness *Harness) Changes() []StateChange {
+	harness.changesMux.Lock()
+	defer harness.changesMux.Unlock()
+
+	changes := make([]StateChange, len(harness.changes))
+	copy(changes, harness.changes)
+	return changes
+}
+
+// Successive values of a state so far
+func (harness *Harness) Values(name string) []any {
+	values := make([]any, 0)
+
+	for _, change := range harness.Changes() {
+		if change.State == name {
+			values = append(values, change.Value)
+		}
+	}
+
+	return values
+}
+
+// Forget the changes so far (eg: the ones made by Init)
+func (harness *Harness) ClearChanges() {
+	harness.changesMux.Lock()
+	defer harness.changesMux.Unlock()
+
+	harness.changes = make([]StateChange, 0)
+}
+
+func (harness *Harness) state(name string) untypedState {
+	harness.t.Helper()
+
+	state, ok := harness.states[name]
+	if !ok {
+		harness.t.Fatalf("unknown state '%s'", name)
+	}
+
+	return state
+}
+
+func (harness *Harness) record(name string, value any) {
+	harness.changesMux.Lock()
+	defer harness.changesMux.Unlock()
+
+	harness.changes = append(harness.changes, StateChange{
+		Time:  harness.clock.Now(),
+		State: name,
+		Value: value,
+	})
+}
+
+func (harness *Harness) dispatch(fn func()) {
+	harness.dispatchMux.Lock()
+	defer harness.dispatchMux.Unlock()
+
+	fn()
+}
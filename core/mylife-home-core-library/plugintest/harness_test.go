@@ -0,0 +1,181 @@
+package plugintest
+
+import (
+	"mylife-home-common/components/metadata"
+	"mylife-home-core-library/definitions"
+	"mylife-home-core-library/registry"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPlugin struct {
+	Step int64
+
+	Count  definitions.State[int64]
+	Level  definitions.State[*int64]
+	Closed bool
+
+	runtime definitions.Runtime
+	ticker  definitions.Timer
+}
+
+func (component *testPlugin) Init(runtime definitions.Runtime) error {
+	component.runtime = runtime
+
+	if !component.Count.Restored() {
+		component.Count.Set(0)
+	}
+
+	return nil
+}
+
+func (component *testPlugin) Terminate() {
+	component.Closed = true
+}
+
+func (component *testPlugin) Start(arg bool) {
+	if arg {
+		component.ticker = component.runtime.TickFunc(time.Second, component.increment)
+	} else if component.ticker != nil {
+		component.ticker.Stop()
+	}
+}
+
+func (component *testPlugin) SetLevel(arg *int64) {
+	component.Level.Set(arg)
+
+	if arg == nil {
+		component.runtime.SetHealth(definitions.HealthDegraded, "no level")
+	} else {
+		component.runtime.SetHealth(definitions.HealthOk, "")
+	}
+}
+
+// Debounced
+func (component *testPlugin) SetCount(arg int64) {
+	component.Count.Set(arg)
+}
+
+// Rate limited
+func (component *testPlugin) SetCountLimited(arg int64) {
+	component.Count.Set(arg)
+}
+
+func (component *testPlugin) increment() {
+	component.Count.Set(component.Count.Get() + component.Step)
+}
+
+func makeTestPluginType() *registry.PluginType {
+	builder := registry.MakePluginTypeBuilder[testPlugin]("test", "test-plugin", "", metadata.Logic, "1.0.0")
+	builder.AddState("Count", "count", "", metadata.MakeTypeRange(0, 100))
+	builder.AddState("Level", "level", "", metadata.MakeTypeNullable(metadata.MakeTypeRange(0, 100)))
+	builder.AddAction("Start", "start", "", metadata.MakeTypeBool())
+	builder.AddAction("SetLevel", "setLevel", "", metadata.MakeTypeNullable(metadata.MakeTypeRange(0, 100)))
+	builder.AddLimitedAction("SetCount", "setCount", "", metadata.MakeTypeRange(0, 100), registry.MakeActionLimit().SetDebounce(100*time.Millisecond))
+	builder.AddLimitedAction("SetCountLimited", "setCountLimited", "", metadata.MakeTypeRange(0, 100), registry.MakeActionLimit().SetRateLimit(time.Second))
+	builder.AddConstrainedConfig("Step", "step", "", metadata.Integer, metadata.MakeConfigConstraints().SetDefault(int64(1)))
+	return builder.Build()
+}
+
+func TestHarness(t *testing.T) {
+	h := New(t, makeTestPluginType(), map[string]any{"step": 2})
+
+	assert.Equal(t, int64(0), h.State("count"))
+	assert.Nil(t, h.State("level"))
+
+	h.Action("start", true)
+	h.Advance(3500 * time.Millisecond)
+	assert.Equal(t, int64(6), h.State("count"))
+	assert.Equal(t, []any{int64(2), int64(4), int64(6)}, h.Values("count"))
+	assert.Equal(t, Epoch.Add(3*time.Second), h.Changes()[2].Time)
+
+	h.Action("start", false)
+	h.Advance(time.Minute)
+	assert.Equal(t, int64(6), h.State("count"))
+
+	status, _ := h.Health()
+	assert.Equal(t, definitions.HealthOk, status)
+
+	h.ClearChanges()
+	h.Action("setLevel", 42)
+	h.Action("setLevel", 42)
+	h.Action("setLevel", nil)
+	assert.Equal(t, []any{int64(42), nil}, h.Values("level"))
+
+	status, message := h.Health()
+	assert.Equal(t, definitions.HealthDegraded, status)
+	assert.Equal(t, "no level", message)
+
+	h.Terminate()
+	assert.True(t, h.Plugin().(*testPlugin).Closed)
+	assert.Error(t, h.Runtime().Context().Err())
+}
+
+func TestHarnessTerminateStopsTimers(t *testing.T) {
+	h := New(t, makeTestPluginType(), nil)
+
+	h.Action("start", true)
+	h.Terminate()
+	h.Advance(time.Minute)
+
+	assert.Equal(t, int64(0), h.State("count"))
+	assert.Equal(t, 0, h.Clock().Pending())
+}
+
+func TestHarnessRestore(t *testing.T) {
+	h := Create(t, makeTestPluginType(), nil)
+	h.Restore("count", 10)
+	assert.NoError(t, h.Init())
+
+	assert.Equal(t, int64(10), h.State("count"))
+	assert.Empty(t, h.Changes())
+
+	h.Action("start", true)
+	h.Advance(time.Second)
+	assert.Equal(t, int64(11), h.State("count"))
+}
+
+func TestHarnessDebounce(t *testing.T) {
+	h := New(t, makeTestPluginType(), nil)
+	h.ClearChanges()
+
+	h.Action("setCount", 1)
+	h.Advance(50 * time.Millisecond)
+	h.Action("setCount", 2)
+	h.Advance(50 * time.Millisecond)
+	assert.Empty(t, h.Values("count"))
+
+	h.Advance(50 * time.Millisecond)
+	assert.Equal(t, []any{int64(2)}, h.Values("count"))
+	assert.Equal(t, Epoch.Add(150*time.Millisecond), h.Changes()[0].Time)
+
+	// pending value dropped
+	h.Action("setCount", 3)
+	h.Terminate()
+	h.Advance(time.Second)
+	assert.Equal(t, []any{int64(2)}, h.Values("count"))
+	assert.Equal(t, 0, h.Clock().Pending())
+}
+
+func TestHarnessRateLimit(t *testing.T) {
+	h := New(t, makeTestPluginType(), nil)
+	h.ClearChanges()
+
+	h.Action("setCountLimited", 1)
+	assert.Equal(t, []any{int64(1)}, h.Values("count"))
+
+	h.Advance(200 * time.Millisecond)
+	h.Action("setCountLimited", 2)
+	h.Action("setCountLimited", 3)
+	assert.Equal(t, []any{int64(1)}, h.Values("count"))
+
+	h.Advance(800 * time.Millisecond)
+	assert.Equal(t, []any{int64(1), int64(3)}, h.Values("count"))
+
+	h.Advance(2 * time.Second)
+	h.Action("setCountLimited", 4)
+	assert.Equal(t, []any{int64(1), int64(3), int64(4)}, h.Values("count"))
+	assert.Equal(t, 0, h.Clock().Pending())
+}
@@ -0,0 +1,114 @@
+package plugintest
+
+import (
+	"encoding/json"
+	"fmt"
+	"mylife-home-common/log"
+	"mylife-home-core-library/definitions"
+	"mylife-home-core-library/registry"
+	"sync"
+	"testing"
+)
+
+var _ definitions.Data = (*dataImpl)(nil)
+var _ log.Logger = (*testLogger)(nil)
+
+// Same runtime as the core, on the virtual clock
+func makeRuntime(harness *Harness) *registry.PluginRuntime {
+	logger := &testLogger{t: harness.t, name: harness.id}
+	data := &dataImpl{values: make(map[string]json.RawMessage)}
+
+	return registry.MakePluginRuntime(harness.id, logger, harness.clock, harness.dispatch, harness.setHealth, data)
+}
+
+type dataImpl struct {
+	values map[string]json.RawMessage
+	mux    sync.Mutex
+}
+
+func (data *dataImpl) Get(key string, value any) (bool, error) {
+	data.mux.Lock()
+	raw, ok := data.values[key]
+	data.mux.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(raw, value); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (data *dataImpl) Set(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	data.mux.Lock()
+	defer data.mux.Unlock()
+
+	data.values[key] = raw
+	return nil
+}
+
+func (data *dataImpl) Delete(key string) {
+	data.mux.Lock()
+	defer data.mux.Unlock()
+
+	delete(data.values, key)
+}
+
+// Component logs go to the test output
+type testLogger struct {
+	t    testing.TB
+	name string
+	err  error
+}
+
+func (logger *testLogger) log(level string, msg string) {
+	if logger.err != nil {
+		msg = fmt.Sprintf("%s: %s", msg, logger.err)
+	}
+
+	logger.t.Logf("[%s] %s %s", logger.name, level, msg)
+}
+
+func (logger *testLogger) Debugf(format string, args ...interface{}) {
+	logger.log("DEBUG", fmt.Sprintf(format, args...))
+}
+
+func (logger *testLogger) Infof(format string, args ...interface{}) {
+	logger.log("INFO", fmt.Sprintf(format, args...))
+}
+
+func (logger *testLogger) Warnf(format string, args ...interface{}) {
+	logger.log("WARN", fmt.Sprintf(format, args...))
+}
+
+func (logger *testLogger) Errorf(format string, args ...interface{}) {
+	logger.log("ERROR", fmt.Sprintf(format, args...))
+}
+
+func (logger *testLogger) Debug(msg string) {
+	logger.log("DEBUG", msg)
+}
+
+func (logger *testLogger) Info(msg string) {
+	logger.log("INFO", msg)
+}
+
+func (logger *testLogger) Warn(msg string) {
+	logger.log("WARN", msg)
+}
+
+func (logger *testLogger) Error(msg string) {
+	logger.log("ERROR", msg)
+}
+
+func (logger *testLogger) WithError(err error) log.Logger {
+	return &testLogger{t: logger.t, name: logger.name, err: err}
+}
@@ -0,0 +1,169 @@
+package plugintest
+
+import (
+	"fmt"
+	"mylife-home-common/components/metadata"
+	"mylife-home-core-library/definitions"
+	"reflect"
+	"sync"
+	"time"
+)
+
+var _ definitions.State[int64] = (*stateImpl[int64])(nil)
+
+// State value changed, as it would be published: nullable values are nil or the inner value
+type StateChange struct {
+	Time  time.Time
+	State string
+	Value any
+}
+
+type untypedState interface {
+	get() any // published form
+	restore(value any) error
+}
+
+// Note: like the core, only changes are recorded (setting the current value again is not)
+type stateImpl[T any] struct {
+	name     string
+	harness  *Harness
+	value    T
+	restored bool
+	mux      sync.Mutex
+}
+
+func (state *stateImpl[T]) Get() T {
+	state.mux.Lock()
+	defer state.mux.Unlock()
+
+	return state.value
+}
+
+func (state *stateImpl[T]) Set(value T) {
+	state.mux.Lock()
+	changed := !reflect.DeepEqual(state.value, value)
+	state.value = value
+	state.mux.Unlock()
+
+	if changed {
+		state.harness.record(state.name, published(value))
+	}
+}
+
+func (state *stateImpl[T]) Restored() bool {
+	state.mux.Lock()
+	defer state.mux.Unlock()
+
+	return state.restored
+}
+
+func (state *stateImpl[T]) get() any {
+	return published(state.Get())
+}
+
+func (state *stateImpl[T]) restore(value any) error {
+	converted, err := convertValue(reflect.TypeOf(&state.value).Elem(), value)
+	if err != nil {
+		return fmt.Errorf("could not restore state '%s': %w", state.name, err)
+	}
+
+	state.mux.Lock()
+	defer state.mux.Unlock()
+
+	state.value = converted.Interface().(T)
+	state.restored = true
+	return nil
+}
+
+// Nullable values are pointers plugin side
+func published(value any) any {
+	ref := reflect.ValueOf(value)
+	if ref.Kind() != reflect.Pointer {
+		return value
+	}
+
+	if ref.IsNil() {
+		return nil
+	}
+
+	return ref.Elem().Interface()
+}
+
+func makeState(name string, harness *Harness, typ metadata.Type) untypedState {
+	switch typ := typ.(type) {
+	case *metadata.RangeType:
+		return &stateImpl[int64]{name: name, harness: harness}
+	case *metadata.TextType, *metadata.EnumType:
+		return &stateImpl[string]{name: name, harness: harness}
+	case *metadata.FloatType:
+		return &stateImpl[float64]{name: name, harness: harness}
+	case *metadata.BoolType:
+		return &stateImpl[bool]{name: name, harness: harness}
+	case *metadata.ComplexType:
+		return &stateImpl[any]{name: name, harness: harness}
+	case *metadata.RecordType:
+		return &stateImpl[map[string]any]{name: name, harness: harness, value: metadata.ZeroValue(typ).(map[string]any)}
+	case *metadata.ArrayType:
+		return &stateImpl[[]any]{name: name, harness: harness, value: metadata.ZeroValue(typ).([]any)}
+	case *metadata.NullableType:
+		switch typ.Inner().(type) {
+		case *metadata.RangeType:
+			return &stateImpl[*int64]{name: name, harness: harness}
+		case *metadata.TextType, *metadata.EnumType:
+			return &stateImpl[*string]{name: name, harness: harness}
+		case *metadata.FloatType:
+			return &stateImpl[*float64]{name: name, harness: harness}
+		case *metadata.BoolType:
+			return &stateImpl[*bool]{name: name, harness: harness}
+		}
+	}
+
+	panic(fmt.Sprintf("Unexpected type '%s'", typ.String()))
+}
+
+// Convert a value given by a test to the type expected by the plugin.
+// Numbers are converted (eg: int to int64), and nullable values can be given as nil or the inner value
+func convertValue(target reflect.Type, value any) (reflect.Value, error) {
+	if value == nil {
+		switch target.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+			return reflect.Zero(target), nil
+		}
+
+		return reflect.Value{}, fmt.Errorf("nil value for type '%s'", target)
+	}
+
+	ref := reflect.ValueOf(value)
+
+	if ref.Type().AssignableTo(target) {
+		return ref, nil
+	}
+
+	if target.Kind() == reflect.Pointer {
+		inner, err := convertValue(target.Elem(), value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		ptr := reflect.New(target.Elem())
+		ptr.Elem().Set(inner)
+		return ptr, nil
+	}
+
+	if isNumber(ref.Kind()) && isNumber(target.Kind()) {
+		return ref.Convert(target), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("value '%v' of type '%s' is not compatible with type '%s'", value, ref.Type(), target)
+}
+
+func isNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
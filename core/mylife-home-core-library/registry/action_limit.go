@@ -0,0 +1,137 @@
+package registry
+
+import (
+	"mylife-home-core-library/definitions"
+	"time"
+
+	"github.com/gookit/goutil/errorx/panics"
+)
+
+// Limit applied by the core on the calls of an action, declared with '@Action(debounce="...")' or '@Action(rateLimit="...")'
+type ActionLimit struct {
+	debounce  time.Duration
+	rateLimit time.Duration
+}
+
+func MakeActionLimit() *ActionLimit {
+	return &ActionLimit{}
+}
+
+// Call the action once values stop arriving for the given delay, with the last value
+func (limit *ActionLimit) SetDebounce(delay time.Duration) *ActionLimit {
+	panics.IsTrue(delay > 0, "invalid debounce delay: %s", delay)
+	panics.IsTrue(limit.rateLimit == 0, "debounce and rate limit cannot be combined")
+	limit.debounce = delay
+	return limit
+}
+
+// Call the action at most once per period: values arriving meanwhile are coalesced, and the last one is applied at the end of the period
+func (limit *ActionLimit) SetRateLimit(period time.Duration) *ActionLimit {
+	panics.IsTrue(period > 0, "invalid rate limit period: %s", period)
+	panics.IsTrue(limit.debounce == 0, "debounce and rate limit cannot be combined")
+	limit.rateLimit = period
+	return limit
+}
+
+// 0 if not set
+func (limit *ActionLimit) Debounce() time.Duration {
+	return limit.debounce
+}
+
+// 0 if not set
+func (limit *ActionLimit) RateLimit() time.Duration {
+	return limit.rateLimit
+}
+
+// Apply the limit of an action (debounce or rate limit), only the last value of a burst is kept.
+//
+// Used by the core and plugintest. Not synchronized: Submit and Stop must be called from the component dispatcher, timers post back to it
+type ActionLimiter struct {
+	limit *ActionLimit
+	clock definitions.Clock
+	post  func(fn func())
+	call  func(value any)
+
+	last       time.Time // last call, for rate limit
+	pending    bool
+	value      any
+	timer      definitions.Timer // nil if not scheduled
+	generation int               // to ignore timers which fire after being stopped
+}
+
+// Post runs a function on the component dispatcher, call invokes the action
+func MakeActionLimiter(limit *ActionLimit, clock definitions.Clock, post func(fn func()), call func(value any)) *ActionLimiter {
+	return &ActionLimiter{
+		limit: limit,
+		clock: clock,
+		post:  post,
+		call:  call,
+	}
+}
+
+func (limiter *ActionLimiter) Submit(value any) {
+	limiter.value = value
+	limiter.pending = true
+
+	if delay := limiter.limit.Debounce(); delay > 0 {
+		limiter.schedule(delay)
+		return
+	}
+
+	if limiter.timer != nil {
+		return // applied at the end of the period
+	}
+
+	wait := limiter.limit.RateLimit() - limiter.clock.Now().Sub(limiter.last)
+	if wait <= 0 {
+		limiter.flush()
+		return
+	}
+
+	limiter.schedule(wait)
+}
+
+// Drop the pending value, if any
+func (limiter *ActionLimiter) Stop() {
+	limiter.cancel()
+	limiter.pending = false
+	limiter.value = nil
+}
+
+func (limiter *ActionLimiter) schedule(delay time.Duration) {
+	limiter.cancel()
+
+	generation := limiter.generation
+	limiter.timer = limiter.clock.AfterFunc(delay, func() {
+		limiter.post(func() {
+			if generation != limiter.generation {
+				return
+			}
+
+			limiter.timer = nil
+			limiter.flush()
+		})
+	})
+}
+
+func (limiter *ActionLimiter) flush() {
+	if !limiter.pending {
+		return
+	}
+
+	value := limiter.value
+	limiter.pending = false
+	limiter.value = nil
+	limiter.last = limiter.clock.Now()
+
+	limiter.call(value)
+}
+
+func (limiter *ActionLimiter) cancel() {
+	limiter.generation += 1
+
+	if limiter.timer != nil {
+		limiter.timer.Stop()
+		limiter.timer = nil
+	}
+}
@@ -0,0 +1,184 @@
+package registry
+
+import (
+	"context"
+	"mylife-home-common/log"
+	"mylife-home-core-library/definitions"
+	"sync"
+	"time"
+
+	"github.com/gookit/goutil/errorx/panics"
+)
+
+var _ definitions.Runtime = (*PluginRuntime)(nil)
+var _ definitions.Timer = (*runtimeTimer)(nil)
+
+// Runtime of one plugin instance, used by the core and by plugintest (with a virtual clock)
+type PluginRuntime struct {
+	id      string
+	logger  log.Logger
+	clock   definitions.Clock
+	execute func(fn func()) // run timer callbacks serialized with actions
+	health  func(status definitions.HealthStatus, message string)
+	data    definitions.Data
+	ctx     context.Context
+	cancel  context.CancelFunc
+	timers  map[*runtimeTimer]struct{}
+	mux     sync.Mutex
+}
+
+func MakePluginRuntime(componentId string, logger log.Logger, clock definitions.Clock, execute func(fn func()), health func(status definitions.HealthStatus, message string), data definitions.Data) *PluginRuntime {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &PluginRuntime{
+		id:      componentId,
+		logger:  logger,
+		clock:   clock,
+		execute: execute,
+		health:  health,
+		data:    data,
+		ctx:     ctx,
+		cancel:  cancel,
+		timers:  make(map[*runtimeTimer]struct{}),
+	}
+}
+
+func (rt *PluginRuntime) ComponentId() string {
+	return rt.id
+}
+
+func (rt *PluginRuntime) Logger() log.Logger {
+	return rt.logger
+}
+
+func (rt *PluginRuntime) Context() context.Context {
+	return rt.ctx
+}
+
+func (rt *PluginRuntime) Clock() definitions.Clock {
+	return rt.clock
+}
+
+func (rt *PluginRuntime) AfterFunc(delay time.Duration, callback func()) definitions.Timer {
+	return rt.schedule(delay, 0, callback)
+}
+
+func (rt *PluginRuntime) TickFunc(period time.Duration, callback func()) definitions.Timer {
+	panics.IsTrue(period > 0, "invalid ticker period: %s", period)
+	return rt.schedule(period, period, callback)
+}
+
+func (rt *PluginRuntime) Data() definitions.Data {
+	return rt.data
+}
+
+// Note: ignored once terminated, the health of a terminated plugin instance does not matter anymore
+func (rt *PluginRuntime) SetHealth(status definitions.HealthStatus, message string) {
+	panics.IsTrue(status == definitions.HealthOk || status == definitions.HealthDegraded || status == definitions.HealthError, "invalid health status: '%s'", status)
+
+	rt.mux.Lock()
+	defer rt.mux.Unlock()
+
+	if rt.ctx.Err() != nil {
+		return
+	}
+
+	rt.health(status, message)
+}
+
+// Stop the timers and cancel the context, once the plugin instance is terminated
+func (rt *PluginRuntime) Terminate() {
+	rt.mux.Lock()
+	defer rt.mux.Unlock()
+
+	rt.cancel()
+
+	for timer := range rt.timers {
+		timer.pending.Stop()
+	}
+
+	clear(rt.timers)
+}
+
+func (rt *PluginRuntime) schedule(delay time.Duration, period time.Duration, callback func()) *runtimeTimer {
+	timer := &runtimeTimer{
+		runtime:  rt,
+		period:   period,
+		callback: callback,
+	}
+
+	rt.mux.Lock()
+	defer rt.mux.Unlock()
+
+	// Terminated: never fires
+	if rt.ctx.Err() != nil {
+		return timer
+	}
+
+	rt.timers[timer] = struct{}{}
+	timer.arm(rt.clock.Now().Add(delay))
+
+	return timer
+}
+
+type runtimeTimer struct {
+	runtime  *PluginRuntime
+	period   time.Duration // 0 if not a ticker
+	callback func()
+
+	// protected by the runtime lock
+	due     time.Time
+	pending definitions.Timer
+}
+
+// Call inside the runtime lock
+func (timer *runtimeTimer) arm(due time.Time) {
+	delay := due.Sub(timer.runtime.clock.Now())
+	if delay < 0 {
+		delay = 0
+	}
+
+	timer.due = due
+	timer.pending = timer.runtime.clock.AfterFunc(delay, timer.fire)
+}
+
+func (timer *runtimeTimer) fire() {
+	rt := timer.runtime
+
+	rt.execute(func() {
+		rt.mux.Lock()
+
+		// Stopped while waiting for the dispatcher
+		if _, active := rt.timers[timer]; !active {
+			rt.mux.Unlock()
+			return
+		}
+
+		if timer.period > 0 {
+			// Based on the due time so that ticks do not drift
+			timer.arm(timer.due.Add(timer.period))
+		} else {
+			delete(rt.timers, timer)
+		}
+
+		rt.mux.Unlock()
+
+		timer.callback()
+	})
+}
+
+func (timer *runtimeTimer) Stop() bool {
+	rt := timer.runtime
+
+	rt.mux.Lock()
+	defer rt.mux.Unlock()
+
+	if _, active := rt.timers[timer]; !active {
+		return false
+	}
+
+	delete(rt.timers, timer)
+	timer.pending.Stop()
+
+	return true
+}
@@ -46,8 +46,9 @@ func (plugin *PluginType) ConfigItem(index int) *ConfigType {
 }
 
 type StateType struct {
-	target *reflect.StructField
-	meta   *metadata.Member
+	target     *reflect.StructField
+	meta       *metadata.Member
+	persistent bool
 }
 
 func (state *StateType) Target() *reflect.StructField {
@@ -58,9 +59,15 @@ func (state *StateType) Metadata() *metadata.Member {
 	return state.meta
 }
 
+// Value saved by the core, and restored before Init on restart
+func (state *StateType) Persistent() bool {
+	return state.persistent
+}
+
 type ActionType struct {
 	target *reflect.Method
 	meta   *metadata.Member
+	limit  *ActionLimit
 }
 
 func (action *ActionType) Target() *reflect.Method {
@@ -71,6 +78,11 @@ func (action *ActionType) Metadata() *metadata.Member {
 	return action.meta
 }
 
+// Limit of the calls, nil if the action is called for each value
+func (action *ActionType) Limit() *ActionLimit {
+	return action.limit
+}
+
 type ConfigType struct {
 	target *reflect.StructField
 	meta   *metadata.ConfigItem
@@ -43,13 +43,22 @@ func MakePluginTypeBuilder[TPlugin any, PTPlugin interface {
 }
 
 func (builder *PluginTypeBuilder) AddState(fieldName string, name string, description string, valueType metadata.Type) *PluginTypeBuilder {
+	return builder.addState(fieldName, name, description, valueType, false)
+}
+
+func (builder *PluginTypeBuilder) AddPersistentState(fieldName string, name string, description string, valueType metadata.Type) *PluginTypeBuilder {
+	return builder.addState(fieldName, name, description, valueType, true)
+}
+
+func (builder *PluginTypeBuilder) addState(fieldName string, name string, description string, valueType metadata.Type, persistent bool) *PluginTypeBuilder {
 	builder.metaBuilder.AddState(name, description, valueType)
 
 	field, ok := builder.target.target.FieldByName(fieldName)
 	panics.IsTrue(ok, "Field '%s' not found on type '%s'", fieldName, builder.target.target)
 
 	stateItem := &StateType{
-		target: &field,
+		target:     &field,
+		persistent: persistent,
 	}
 
 	builder.state = append(builder.state, NamedItem[*StateType]{
@@ -61,6 +70,11 @@ func (builder *PluginTypeBuilder) AddState(fieldName string, name string, descri
 }
 
 func (builder *PluginTypeBuilder) AddAction(methName string, name string, description string, valueType metadata.Type) *PluginTypeBuilder {
+	return builder.AddLimitedAction(methName, name, description, valueType, nil)
+}
+
+// Note: limit is optional
+func (builder *PluginTypeBuilder) AddLimitedAction(methName string, name string, description string, valueType metadata.Type, limit *ActionLimit) *PluginTypeBuilder {
 	builder.metaBuilder.AddAction(name, description, valueType)
 
 	method, ok := reflect.PointerTo(builder.target.target).MethodByName(methName)
@@ -68,6 +82,7 @@ func (builder *PluginTypeBuilder) AddAction(methName string, name string, descri
 
 	action := &ActionType{
 		target: &method,
+		limit:  limit,
 	}
 
 	builder.actions = append(builder.actions, NamedItem[*ActionType]{
@@ -79,7 +94,11 @@ func (builder *PluginTypeBuilder) AddAction(methName string, name string, descri
 }
 
 func (builder *PluginTypeBuilder) AddConfig(fieldName string, name string, description string, valueType metadata.ConfigType) *PluginTypeBuilder {
-	builder.metaBuilder.AddConfig(name, description, valueType)
+	return builder.AddConstrainedConfig(fieldName, name, description, valueType, metadata.MakeConfigConstraints())
+}
+
+func (builder *PluginTypeBuilder) AddConstrainedConfig(fieldName string, name string, description string, valueType metadata.ConfigType, constraints *metadata.ConfigConstraints) *PluginTypeBuilder {
+	builder.metaBuilder.AddConstrainedConfig(name, description, valueType, constraints)
 
 	field, ok := builder.target.target.FieldByName(fieldName)
 	panics.IsTrue(ok, "Field '%s' not found on type '%s'", fieldName, builder.target.target)
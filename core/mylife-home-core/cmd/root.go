@@ -22,25 +22,30 @@ var configFile string
 var logConsole bool
 
 var rootCmd = &cobra.Command{
-	Use:   "mylife-home-core",
-	Short: "mylife-home-core - Mylife Home Core",
-	Run:   run,
+	Use:          "mylife-home-core",
+	Short:        "mylife-home-core - Mylife Home Core",
+	SilenceUsage: true,
+	RunE:         run,
 }
 
-func run(_ *cobra.Command, _ []string) {
+func run(_ *cobra.Command, _ []string) error {
 	log.Init(logConsole)
 	config.Init(configFile)
 	defines.Init("core", version.Value)
 	instance_info.Init()
 	plugins.Build()
 
-	m := manager.MakeManager()
+	m, err := manager.MakeManager()
+	if err != nil {
+		return err
+	}
 
 	channel := make(chan os.Signal, 1)
 	signal.Notify(channel, syscall.SIGINT, syscall.SIGTERM)
 	<-channel
 
 	m.Terminate()
+	return nil
 }
 
 func init() {
@@ -4,6 +4,7 @@ go 1.21.1
 
 require (
 	github.com/spf13/cobra v1.7.0
+	go.etcd.io/bbolt v1.3.10
 	golang.org/x/exp v0.0.0-20230905200255-921286631fa9
 )
 
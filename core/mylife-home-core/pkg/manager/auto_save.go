@@ -0,0 +1,85 @@
+package manager
+
+import (
+	"mylife-home-core-library/definitions"
+	"sync"
+	"time"
+)
+
+// Save once changes stop for delay
+type autoSaver struct {
+	name    string // for logs
+	delay   time.Duration
+	clock   definitions.Clock
+	save    func() error
+	timer   definitions.Timer // nil if no save pending
+	running sync.WaitGroup    // save started by the timer
+	mux     sync.Mutex
+}
+
+func makeAutoSaver(name string, delay time.Duration, clock definitions.Clock, save func() error) *autoSaver {
+	return &autoSaver{
+		name:  name,
+		delay: delay,
+		clock: clock,
+		save:  save,
+	}
+}
+
+// Restart the delay
+func (saver *autoSaver) Changed() {
+	saver.mux.Lock()
+	defer saver.mux.Unlock()
+
+	if saver.timer != nil {
+		saver.timer.Stop()
+	}
+
+	// Note: the callback waits for the lock, so timer is set when it runs
+	var timer definitions.Timer
+	timer = saver.clock.AfterFunc(saver.delay, func() { saver.fire(timer) })
+	saver.timer = timer
+}
+
+// Save now if a save is pending, or wait for the running one
+func (saver *autoSaver) Terminate() {
+	saver.mux.Lock()
+	pending := saver.timer != nil
+	if pending {
+		saver.timer.Stop()
+		saver.timer = nil
+	}
+	saver.mux.Unlock()
+
+	saver.running.Wait()
+
+	if pending {
+		saver.run()
+	}
+}
+
+func (saver *autoSaver) fire(timer definitions.Timer) {
+	saver.mux.Lock()
+
+	// Restarted or terminated meanwhile
+	if saver.timer != timer {
+		saver.mux.Unlock()
+		return
+	}
+
+	saver.timer = nil
+	saver.running.Add(1)
+	saver.mux.Unlock()
+
+	defer saver.running.Done()
+	saver.run()
+}
+
+func (saver *autoSaver) run() {
+	if err := saver.save(); err != nil {
+		logger.WithError(err).Errorf("Could not auto-save %s", saver.name)
+		return
+	}
+
+	logger.Debugf("Auto-saved %s", saver.name)
+}
@@ -0,0 +1,75 @@
+package manager
+
+import (
+	"mylife-home-core-library/plugintest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoSaverDebounce(t *testing.T) {
+	clock := plugintest.NewClock(plugintest.Epoch)
+	var count atomic.Int32
+	saver := makeAutoSaver("test", 50*time.Millisecond, clock, func() error {
+		count.Add(1)
+		return nil
+	})
+
+	for index := 0; index < 5; index++ {
+		saver.Changed()
+		clock.Advance(10 * time.Millisecond)
+	}
+
+	assert.Equal(t, int32(0), count.Load())
+
+	clock.Advance(50 * time.Millisecond)
+	assert.Equal(t, int32(1), count.Load())
+
+	saver.Terminate()
+	assert.Equal(t, int32(1), count.Load())
+}
+
+func TestAutoSaverTerminateFlush(t *testing.T) {
+	clock := plugintest.NewClock(plugintest.Epoch)
+	var count atomic.Int32
+	saver := makeAutoSaver("test", time.Hour, clock, func() error {
+		count.Add(1)
+		return nil
+	})
+
+	saver.Changed()
+	saver.Terminate()
+	assert.Equal(t, int32(1), count.Load())
+	assert.Equal(t, 0, clock.Pending())
+}
+
+func TestAutoSaverTerminateWaitsRunningSave(t *testing.T) {
+	clock := plugintest.NewClock(plugintest.Epoch)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var count atomic.Int32
+	saver := makeAutoSaver("test", time.Second, clock, func() error {
+		close(started)
+		<-release
+		count.Add(1)
+		return nil
+	})
+
+	saver.Changed()
+	go clock.Advance(time.Second)
+	<-started
+
+	var terminated atomic.Bool
+	go func() {
+		saver.Terminate()
+		terminated.Store(true)
+	}()
+
+	assert.Never(t, terminated.Load, 50*time.Millisecond, time.Millisecond)
+
+	close(release)
+	assert.Eventually(t, terminated.Load, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), count.Load())
+}
@@ -7,14 +7,25 @@ import (
 	"mylife-home-common/tools"
 	"mylife-home-core/pkg/store"
 	"strings"
+	"sync"
+	"time"
 )
 
 type binding struct {
 	registry            components.Registry
 	config              *store.BindingConfig
+	key                 string
+	statusChanges       tools.Subject[*bindingStatusChange]
 	componentChangeChan chan *components.ComponentChange
 	workerExited        chan struct{}
 
+	// status, updated by worker and pipe, read by rpc
+	statusMux     sync.Mutex
+	state         bindingState
+	lastError     string
+	lastValue     any
+	lastValueTime time.Time
+
 	// updated by worker
 	sourceInstance  string
 	source          components.Component
@@ -25,12 +36,15 @@ type binding struct {
 	targetAction    chan<- any
 }
 
-func makeBinding(registry components.Registry, config *store.BindingConfig) *binding {
+func makeBinding(registry components.Registry, config *store.BindingConfig, key string, statusChanges tools.Subject[*bindingStatusChange]) *binding {
 	b := &binding{
 		registry:            registry,
 		config:              config,
+		key:                 key,
+		statusChanges:       statusChanges,
 		componentChangeChan: make(chan *components.ComponentChange),
 		workerExited:        make(chan struct{}),
+		state:               bindingWaitingForSource,
 	}
 
 	go b.worker()
@@ -47,6 +61,8 @@ func (b *binding) Terminate() {
 	close(b.componentChangeChan)
 	<-b.workerExited
 
+	b.statusChanges.Notify(&bindingStatusChange{key: b.key, status: nil})
+
 	logger.Infof("Binding '%s' closed", b.config)
 }
 
@@ -117,6 +133,11 @@ func (b *binding) onComponentChange(change *components.ComponentChange) {
 }
 
 func (b *binding) refreshBinding() {
+	b.refreshActivation()
+	b.refreshState()
+}
+
+func (b *binding) refreshActivation() {
 	// check if the state is already consistent
 	shouldActivate := b.source != nil && b.target != nil
 	active := b.targetAction != nil && b.sourceState != nil
@@ -126,7 +147,10 @@ func (b *binding) refreshBinding() {
 	}
 
 	if shouldActivate {
-		if !b.validate() {
+		transform, err := b.validate()
+		if err != nil {
+			logger.Errorf("Binding '%s' errors: %s", b.config, err)
+			b.setLastError(err.Error())
 			return
 		}
 
@@ -141,13 +165,17 @@ func (b *binding) refreshBinding() {
 			return in != nil
 		}
 
+		// Note: transforms return nil to drop a value
+		transformed := tools.FilterChannel(tools.MapChannel(tools.FilterChannel(b.sourceStateChan, filter), b.protectTransform(transform)), filter)
+
 		tools.PipeChannel(
-			tools.FilterChannel(b.sourceStateChan, filter),
+			tools.MapChannel(transformed, b.recordValue),
 			b.targetAction,
 			false,
 		)
 
 		b.sourceState.Subscribe(b.sourceStateChan, true)
+		b.setLastError("")
 
 		logger.Debugf("Binding '%s' activated", b.config)
 
@@ -163,7 +191,22 @@ func (b *binding) refreshBinding() {
 	}
 }
 
-func (b *binding) validate() bool {
+// A panic while transforming a value drops it, instead of stopping the core
+func (b *binding) protectTransform(transform transformFunc) transformFunc {
+	return func(value any) (result any) {
+		defer func() {
+			if err := recover(); err != nil {
+				logger.Errorf("Panic in binding '%s' while transforming value %+v, dropped: %v", b.config, value, err)
+				result = nil
+			}
+		}()
+
+		return transform(value)
+	}
+}
+
+// Returns the transform pipeline to apply on values
+func (b *binding) validate() (transformFunc, error) {
 
 	errors := make([]string, 0)
 
@@ -180,23 +223,97 @@ func (b *binding) validate() bool {
 		errors = append(errors, err)
 	}
 
+	var transform transformFunc
+
 	if sourceState != nil && targetAction != nil {
 		sourceType := sourceState.ValueType()
 		targetType := targetAction.ValueType()
-		if !sourceType.Equals(targetType) {
-			sourceDesc := fmt.Sprintf("State '%s' on component %s", sourceState.Name(), b.buildComponentFullId(b.sourceInstance, b.source))
-			targetDesc := fmt.Sprintf("action '%s' on component %s", targetAction.Name(), b.buildComponentFullId(b.targetInstance, b.target))
-			err := fmt.Sprintf("%s has type '%s', which is different from type '%s' for %s", sourceDesc, sourceType, targetType, targetDesc)
-			errors = append(errors, err)
+		sourceDesc := fmt.Sprintf("State '%s' on component %s", sourceState.Name(), b.buildComponentFullId(b.sourceInstance, b.source))
+		targetDesc := fmt.Sprintf("action '%s' on component %s", targetAction.Name(), b.buildComponentFullId(b.targetInstance, b.target))
+
+		resultType, fn, err := makeTransforms(b.config.Transforms, sourceType)
+
+		switch {
+		case err != nil:
+			errors = append(errors, fmt.Sprintf("%s has type '%s', which does not match transforms: %s", sourceDesc, sourceType, err))
+
+		case len(b.config.Transforms) > 0 && !isAssignableType(resultType, targetType):
+			errors = append(errors, fmt.Sprintf("%s has type '%s' after transforms, which is not compatible with type '%s' for %s", sourceDesc, resultType, targetType, targetDesc))
+
+		case len(b.config.Transforms) == 0 && !isAssignableType(sourceType, targetType):
+			errors = append(errors, fmt.Sprintf("%s has type '%s', which is not compatible with type '%s' for %s", sourceDesc, sourceType, targetType, targetDesc))
+
+		default:
+			transform = fn
 		}
 	}
 
 	if len(errors) > 0 {
-		logger.Errorf("Binding '%s' errors: %s", b.config, strings.Join(errors, ", "))
-		return false
+		return nil, fmt.Errorf("%s", strings.Join(errors, ", "))
+	}
+
+	return transform, nil
+}
+
+func (b *binding) refreshState() {
+	var state bindingState
+
+	switch {
+	case b.targetAction != nil && b.sourceState != nil:
+		state = bindingActive
+	case b.source == nil:
+		state = bindingWaitingForSource
+	case b.target == nil:
+		state = bindingWaitingForTarget
+	default:
+		state = bindingTypeMismatch
+	}
+
+	b.statusMux.Lock()
+	changed := b.state != state
+	b.state = state
+	b.statusMux.Unlock()
+
+	if changed {
+		b.statusChanges.Notify(&bindingStatusChange{key: b.key, status: b.Status()})
+	}
+}
+
+// Empty once the binding is activated
+func (b *binding) setLastError(err string) {
+	b.statusMux.Lock()
+	defer b.statusMux.Unlock()
+
+	b.lastError = err
+}
+
+func (b *binding) recordValue(value any) any {
+	b.statusMux.Lock()
+	defer b.statusMux.Unlock()
+
+	b.lastValue = value
+	b.lastValueTime = time.Now()
+
+	return value
+}
+
+func (b *binding) Status() *bindingStatus {
+	b.statusMux.Lock()
+	defer b.statusMux.Unlock()
+
+	status := &bindingStatus{
+		Config:    b.config,
+		State:     b.state,
+		LastError: b.lastError,
+		LastValue: b.lastValue,
+	}
+
+	if !b.lastValueTime.IsZero() {
+		lastValueTime := b.lastValueTime
+		status.LastValueTime = &lastValueTime
 	}
 
-	return true
+	return status
 }
 
 func (b *binding) buildComponentFullId(instanceName string, comp components.Component) string {
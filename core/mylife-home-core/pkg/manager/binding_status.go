@@ -0,0 +1,53 @@
+package manager
+
+import (
+	"mylife-home-common/bus"
+	"mylife-home-core/pkg/store"
+	"time"
+)
+
+type bindingState string
+
+const (
+	bindingWaitingForSource bindingState = "waiting-for-source"
+	bindingWaitingForTarget bindingState = "waiting-for-target"
+	bindingTypeMismatch     bindingState = "type-mismatch" // source and target are present, but the binding cannot be activated (see last error)
+	bindingActive           bindingState = "active"
+)
+
+type bindingStatus struct {
+	Config        *store.BindingConfig `json:"config"`
+	State         bindingState         `json:"state"`
+	LastError     string               `json:"lastError,omitempty"`
+	LastValue     any                  `json:"lastValue,omitempty"`     // last value transmitted to the target action
+	LastValueTime *time.Time           `json:"lastValueTime,omitempty"` // time of the last value transmitted to the target action
+}
+
+type bindingStatusChange struct {
+	key    string
+	status *bindingStatus // nil when the binding is removed
+}
+
+const bindingsMetadataPath = "bindings/"
+
+// Publish bindings status as instance metadata, at 'bindings/<key>'.
+//
+// Note: metadata is refreshed on state changes only, use the 'bindings.status' rpc to get the last transmitted values.
+func makeBindingsStatusPublisher(transport *bus.Transport, cm *componentManager) *metadataPublisher {
+	values := func() map[string]any {
+		values := make(map[string]any)
+		for key, status := range cm.GetBindingsStatus() {
+			values[key] = status
+		}
+		return values
+	}
+
+	mapper := func(change *bindingStatusChange) *metadataChange {
+		if change.status == nil {
+			return &metadataChange{key: change.key}
+		}
+		return &metadataChange{key: change.key, value: change.status}
+	}
+
+	return makeMetadataPublisher(transport, bindingsMetadataPath, values, cm.OnBindingStatusChange(), mapper)
+}
@@ -0,0 +1,115 @@
+package manager
+
+import (
+	"mylife-home-common/components"
+	"mylife-home-common/tools"
+	"mylife-home-core/pkg/plugins"
+	"mylife-home-core/pkg/store"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeTestComponent(t *testing.T, id string, plugin string, config map[string]any) *plugins.Component {
+	comp, err := plugins.GetPlugin(plugin).Instantiate(id, config, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(comp.Terminate)
+	return comp
+}
+
+func waitBindingState(t *testing.T, b *binding, state bindingState) {
+	t.Helper()
+	assert.Eventually(t, func() bool { return b.Status().State == state }, time.Second, time.Millisecond)
+}
+
+func TestBindingStates(t *testing.T) {
+	registry := components.NewRegistry()
+	config := &store.BindingConfig{SourceComponent: "source", SourceState: "value", TargetComponent: "target", TargetAction: "setValue"}
+
+	// Note: the registry notifies under its lock, so components are added once the binding has initialized
+	source := makeTestComponent(t, "source", "test.counter", map[string]any{"count": int64(1)})
+	registry.AddComponent("", source)
+
+	b := makeBinding(registry, config, "key", tools.MakeSubject[*bindingStatusChange]())
+	defer b.Terminate()
+
+	waitBindingState(t, b, bindingWaitingForTarget)
+
+	// no 'setValue' action
+	mismatch := makeTestComponent(t, "target", "test.constrained", map[string]any{"usedCount": int64(1), "fanMode": "auto"})
+	registry.AddComponent("", mismatch)
+	waitBindingState(t, b, bindingTypeMismatch)
+	assert.Contains(t, b.Status().LastError, "Action 'setValue' does not exist")
+
+	registry.RemoveComponent("", mismatch)
+	waitBindingState(t, b, bindingWaitingForTarget)
+
+	target := makeTestComponent(t, "target", "test.counter", map[string]any{"count": int64(1)})
+	registry.AddComponent("", target)
+	waitBindingState(t, b, bindingActive)
+	assert.Equal(t, "", b.Status().LastError)
+
+	source.Action("setValue") <- int64(42)
+	assert.Eventually(t, func() bool { return target.StateItem("value").Get() == int64(42) }, time.Second, time.Millisecond)
+	assert.Equal(t, int64(42), b.Status().LastValue)
+	assert.NotNil(t, b.Status().LastValueTime)
+
+	registry.RemoveComponent("", source)
+	waitBindingState(t, b, bindingWaitingForSource)
+}
+
+func TestBindingTransformTypeMismatch(t *testing.T) {
+	registry := components.NewRegistry()
+	threshold := 50.0
+	config := &store.BindingConfig{
+		SourceComponent: "source",
+		SourceState:     "value",
+		TargetComponent: "target",
+		TargetAction:    "setValue",
+		Transforms:      []*store.BindingTransformConfig{{Type: store.TransformThreshold, Threshold: &threshold}},
+	}
+
+	registry.AddComponent("", makeTestComponent(t, "source", "test.counter", map[string]any{"count": int64(1)}))
+	registry.AddComponent("", makeTestComponent(t, "target", "test.counter", map[string]any{"count": int64(1)}))
+
+	b := makeBinding(registry, config, "key", tools.MakeSubject[*bindingStatusChange]())
+	defer b.Terminate()
+
+	waitBindingState(t, b, bindingTypeMismatch)
+	assert.Contains(t, b.Status().LastError, "after transforms, which is not compatible")
+}
+
+func TestBindingsStatusRpc(t *testing.T) {
+	manager := makeTestComponentManager(t, "["+makeTestComponentItem("source", "test.counter", 1)+"]")
+	api := &rpcApi{cm: manager, supportsBindings: true}
+
+	// concurrent reads, like the status publisher when the bus comes online
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for index := 0; index < 100; index++ {
+			manager.GetBindingsStatus()
+		}
+	}()
+
+	for _, target := range []string{"a", "b", "c"} {
+		assert.NoError(t, manager.AddBinding(&store.BindingConfig{SourceComponent: "source", SourceState: "value", TargetComponent: target, TargetAction: "setValue"}))
+	}
+
+	assert.NoError(t, manager.RemoveBinding(&store.BindingConfig{SourceComponent: "source", SourceState: "value", TargetComponent: "b", TargetAction: "setValue"}))
+	<-done
+
+	list, err := api.bindingStatus(struct{}{})
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, "a", list[0].Config.TargetComponent)
+	assert.Equal(t, "c", list[1].Config.TargetComponent)
+	assert.Eventually(t, func() bool {
+		list, _ := api.bindingStatus(struct{}{})
+		return list[0].State == bindingWaitingForTarget
+	}, time.Second, time.Millisecond)
+}
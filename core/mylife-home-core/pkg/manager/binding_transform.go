@@ -0,0 +1,378 @@
+package manager
+
+import (
+	"fmt"
+	"math"
+	"mylife-home-common/components/metadata"
+	"mylife-home-core/pkg/store"
+	"sort"
+	"strconv"
+
+	"golang.org/x/exp/maps"
+)
+
+// Transform a value, returns nil to drop it
+type transformFunc func(value any) any
+
+func identityTransform(value any) any {
+	return value
+}
+
+// Build the transform pipeline of the binding, and the type of the values it produces
+func makeTransforms(configs []*store.BindingTransformConfig, sourceType metadata.Type) (metadata.Type, transformFunc, error) {
+	typ := sourceType
+	pipeline := make([]transformFunc, 0, len(configs))
+
+	for index, config := range configs {
+		outputType, fn, err := makeTransform(config, typ)
+		if err != nil {
+			return nil, nil, fmt.Errorf("transform #%d '%s': %w", index, config.Type, err)
+		}
+
+		typ = outputType
+		pipeline = append(pipeline, fn)
+	}
+
+	if len(pipeline) == 0 {
+		return typ, identityTransform, nil
+	}
+
+	transform := func(value any) any {
+		for _, fn := range pipeline {
+			if value = fn(value); value == nil {
+				return nil
+			}
+		}
+
+		return value
+	}
+
+	return typ, transform, nil
+}
+
+func makeTransform(config *store.BindingTransformConfig, inputType metadata.Type) (metadata.Type, transformFunc, error) {
+	switch config.Type {
+	case store.TransformInvert:
+		return makeInvertTransform(inputType)
+	case store.TransformScale:
+		return makeScaleTransform(config, inputType)
+	case store.TransformThreshold:
+		return makeThresholdTransform(config, inputType)
+	case store.TransformMap:
+		return makeMapTransform(config, inputType)
+	case store.TransformClamp:
+		return makeClampTransform(config, inputType)
+	default:
+		return nil, nil, fmt.Errorf("unknown transform type")
+	}
+}
+
+func makeInvertTransform(inputType metadata.Type) (metadata.Type, transformFunc, error) {
+	if _, ok := inputType.(*metadata.BoolType); !ok {
+		return nil, nil, fmt.Errorf("expected bool input, got '%s'", inputType)
+	}
+
+	return inputType, func(value any) any {
+		return !value.(bool)
+	}, nil
+}
+
+func makeScaleTransform(config *store.BindingTransformConfig, inputType metadata.Type) (metadata.Type, transformFunc, error) {
+	if !isNumericType(inputType) {
+		return nil, nil, fmt.Errorf("expected range or float input, got '%s'", inputType)
+	}
+
+	var fromMin, fromMax float64
+
+	switch {
+	case len(config.From) == 2:
+		fromMin, fromMax = config.From[0], config.From[1]
+	case len(config.From) == 0:
+		rangeType, ok := inputType.(*metadata.RangeType)
+		if !ok {
+			return nil, nil, fmt.Errorf("'from' is required with input type '%s'", inputType)
+		}
+
+		fromMin, fromMax = float64(rangeType.Min()), float64(rangeType.Max())
+	default:
+		return nil, nil, fmt.Errorf("'from' must be [min, max]")
+	}
+
+	if len(config.To) != 2 || !isInteger(config.To[0]) || !isInteger(config.To[1]) {
+		return nil, nil, fmt.Errorf("'to' must be [min, max] with integer values")
+	}
+
+	toMin, toMax := config.To[0], config.To[1]
+
+	if fromMin == fromMax || toMin == toMax {
+		return nil, nil, fmt.Errorf("empty scale")
+	}
+
+	// Note: reversed bounds are allowed, eg: to [100, 0] inverts the scale
+	outputMin := int64(min(toMin, toMax))
+	outputMax := int64(max(toMin, toMax))
+
+	return metadata.MakeTypeRange(outputMin, outputMax), func(value any) any {
+		scaled := toMin + (toFloat(value)-fromMin)*(toMax-toMin)/(fromMax-fromMin)
+		return clampInt(int64(math.Round(scaled)), outputMin, outputMax)
+	}, nil
+}
+
+func makeThresholdTransform(config *store.BindingTransformConfig, inputType metadata.Type) (metadata.Type, transformFunc, error) {
+	if !isNumericType(inputType) {
+		return nil, nil, fmt.Errorf("expected range or float input, got '%s'", inputType)
+	}
+
+	if config.Threshold == nil {
+		return nil, nil, fmt.Errorf("'threshold' is required")
+	}
+
+	threshold := *config.Threshold
+
+	return metadata.MakeTypeBool(), func(value any) any {
+		return toFloat(value) >= threshold
+	}, nil
+}
+
+func makeMapTransform(config *store.BindingTransformConfig, inputType metadata.Type) (metadata.Type, transformFunc, error) {
+	if len(config.Values) == 0 {
+		return nil, nil, fmt.Errorf("'values' is required")
+	}
+
+	// Sort for stable output types
+	keys := maps.Keys(config.Values)
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := checkMapKey(key, inputType); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	values := make(map[string]any)
+	var outputType metadata.Type
+
+	switch config.Values[keys[0]].(type) {
+	case string:
+		enumValues := make([]string, 0)
+		uniques := make(map[string]struct{})
+
+		for _, key := range keys {
+			value, ok := config.Values[key].(string)
+			if !ok {
+				return nil, nil, fmt.Errorf("all values must have the same type")
+			}
+
+			values[key] = value
+
+			if _, exists := uniques[value]; !exists {
+				uniques[value] = struct{}{}
+				enumValues = append(enumValues, value)
+			}
+		}
+
+		outputType = metadata.MakeTypeEnum(enumValues...)
+
+	case bool:
+		for _, key := range keys {
+			value, ok := config.Values[key].(bool)
+			if !ok {
+				return nil, nil, fmt.Errorf("all values must have the same type")
+			}
+
+			values[key] = value
+		}
+
+		outputType = metadata.MakeTypeBool()
+
+	case float64:
+		var lower int64 = math.MaxInt64
+		var upper int64 = math.MinInt64
+
+		for _, key := range keys {
+			value, ok := config.Values[key].(float64)
+			if !ok || !isInteger(value) {
+				return nil, nil, fmt.Errorf("all values must have the same type, and numbers must be integers")
+			}
+
+			intValue := int64(value)
+			values[key] = intValue
+			lower = min(lower, intValue)
+			upper = max(upper, intValue)
+		}
+
+		if lower == upper {
+			return nil, nil, fmt.Errorf("all values map to the same number")
+		}
+
+		outputType = metadata.MakeTypeRange(lower, upper)
+
+	default:
+		return nil, nil, fmt.Errorf("values must be strings, booleans or integers")
+	}
+
+	return outputType, func(value any) any {
+		// unmapped values are dropped
+		return values[formatMapKey(value)]
+	}, nil
+}
+
+func checkMapKey(key string, inputType metadata.Type) error {
+	switch typ := inputType.(type) {
+	case *metadata.TextType:
+		return nil
+
+	case *metadata.EnumType:
+		for index := 0; index < typ.NumValues(); index += 1 {
+			if typ.Value(index) == key {
+				return nil
+			}
+		}
+
+	case *metadata.BoolType:
+		if key == "true" || key == "false" {
+			return nil
+		}
+
+	case *metadata.RangeType:
+		if value, err := strconv.ParseInt(key, 10, 64); err == nil && typ.Validate(value) {
+			return nil
+		}
+
+	default:
+		return fmt.Errorf("expected text, enum, bool or range input, got '%s'", inputType)
+	}
+
+	return fmt.Errorf("key '%s' is not a valid value of input type '%s'", key, inputType)
+}
+
+func formatMapKey(value any) string {
+	switch typedValue := value.(type) {
+	case string:
+		return typedValue
+	case bool:
+		return strconv.FormatBool(typedValue)
+	case int64:
+		return strconv.FormatInt(typedValue, 10)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func makeClampTransform(config *store.BindingTransformConfig, inputType metadata.Type) (metadata.Type, transformFunc, error) {
+	if config.Min == nil && config.Max == nil {
+		return nil, nil, fmt.Errorf("'min' or 'max' is required")
+	}
+
+	lower := math.Inf(-1)
+	if config.Min != nil {
+		lower = *config.Min
+	}
+
+	upper := math.Inf(1)
+	if config.Max != nil {
+		upper = *config.Max
+	}
+
+	switch typ := inputType.(type) {
+	case *metadata.FloatType:
+		if lower >= upper {
+			return nil, nil, fmt.Errorf("'min' must be lower than 'max'")
+		}
+
+		return inputType, func(value any) any {
+			return max(lower, min(upper, value.(float64)))
+		}, nil
+
+	case *metadata.RangeType:
+		if (config.Min != nil && !isInteger(lower)) || (config.Max != nil && !isInteger(upper)) {
+			return nil, nil, fmt.Errorf("'min' and 'max' must be integers with input type '%s'", inputType)
+		}
+
+		outputMin := typ.Min()
+		if config.Min != nil {
+			outputMin = max(outputMin, int64(lower))
+		}
+
+		outputMax := typ.Max()
+		if config.Max != nil {
+			outputMax = min(outputMax, int64(upper))
+		}
+
+		if outputMin >= outputMax {
+			return nil, nil, fmt.Errorf("empty output range")
+		}
+
+		return metadata.MakeTypeRange(outputMin, outputMax), func(value any) any {
+			return clampInt(value.(int64), outputMin, outputMax)
+		}, nil
+
+	default:
+		return nil, nil, fmt.Errorf("expected range or float input, got '%s'", inputType)
+	}
+}
+
+// Check that all values of type 'from' are valid for type 'to'
+func isAssignableType(from metadata.Type, to metadata.Type) bool {
+	if from.Equals(to) {
+		return true
+	}
+
+	switch toType := to.(type) {
+	case *metadata.RangeType:
+		fromRange, ok := from.(*metadata.RangeType)
+		return ok && fromRange.Min() >= toType.Min() && fromRange.Max() <= toType.Max()
+
+	case *metadata.EnumType:
+		fromEnum, ok := from.(*metadata.EnumType)
+		if !ok {
+			return false
+		}
+
+		for index := 0; index < fromEnum.NumValues(); index += 1 {
+			if !toType.Validate(fromEnum.Value(index)) {
+				return false
+			}
+		}
+
+		return true
+
+	case *metadata.TextType:
+		_, ok := from.(*metadata.EnumType)
+		return ok
+
+	case *metadata.NullableType:
+		return isAssignableType(from, toType.Inner())
+
+	default:
+		return false
+	}
+}
+
+func isNumericType(typ metadata.Type) bool {
+	switch typ.(type) {
+	case *metadata.RangeType, *metadata.FloatType:
+		return true
+	default:
+		return false
+	}
+}
+
+func toFloat(value any) float64 {
+	switch typedValue := value.(type) {
+	case int64:
+		return float64(typedValue)
+	case float64:
+		return typedValue
+	default:
+		panic(fmt.Errorf("unexpected numeric value %v", value))
+	}
+}
+
+func isInteger(value float64) bool {
+	return value == math.Trunc(value) && !math.IsInf(value, 0)
+}
+
+func clampInt(value int64, lower int64, upper int64) int64 {
+	return max(lower, min(upper, value))
+}
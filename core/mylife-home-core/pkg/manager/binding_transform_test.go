@@ -0,0 +1,85 @@
+package manager
+
+import (
+	"mylife-home-common/components/metadata"
+	"mylife-home-core/pkg/store"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func float(value float64) *float64 {
+	return &value
+}
+
+func testTransforms(t *testing.T, sourceType metadata.Type, configs []*store.BindingTransformConfig, expectedType string, values map[any]any) {
+	typ, transform, err := makeTransforms(configs, sourceType)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedType, typ.String())
+
+	for input, output := range values {
+		assert.Equal(t, output, transform(input), "%v", input)
+	}
+}
+
+func TestTransformInvert(t *testing.T) {
+	testTransforms(t, metadata.MakeTypeBool(), []*store.BindingTransformConfig{{Type: store.TransformInvert}}, "bool", map[any]any{true: false, false: true})
+}
+
+func TestTransformScale(t *testing.T) {
+	testTransforms(t, metadata.MakeTypeRange(0, 255), []*store.BindingTransformConfig{{Type: store.TransformScale, To: []float64{0, 100}}}, "range[0;100]", map[any]any{int64(0): int64(0), int64(255): int64(100), int64(128): int64(50)})
+	testTransforms(t, metadata.MakeTypeFloat(), []*store.BindingTransformConfig{{Type: store.TransformScale, From: []float64{0, 1}, To: []float64{100, 0}}}, "range[0;100]", map[any]any{0.25: int64(75), 2.0: int64(0)})
+}
+
+func TestTransformThreshold(t *testing.T) {
+	testTransforms(t, metadata.MakeTypeRange(0, 100), []*store.BindingTransformConfig{{Type: store.TransformThreshold, Threshold: float(50)}}, "bool", map[any]any{int64(49): false, int64(50): true})
+}
+
+func TestTransformMap(t *testing.T) {
+	testTransforms(t, metadata.MakeTypeBool(), []*store.BindingTransformConfig{{Type: store.TransformMap, Values: map[string]any{"false": 0.0, "true": 100.0}}}, "range[0;100]", map[any]any{false: int64(0), true: int64(100)})
+	testTransforms(t, metadata.MakeTypeEnum("a", "b", "c"), []*store.BindingTransformConfig{{Type: store.TransformMap, Values: map[string]any{"a": "on", "b": "off"}}}, "enum{on,off}", map[any]any{"a": "on", "b": "off", "c": nil})
+}
+
+func TestTransformClamp(t *testing.T) {
+	testTransforms(t, metadata.MakeTypeRange(0, 255), []*store.BindingTransformConfig{{Type: store.TransformClamp, Max: float(100)}}, "range[0;100]", map[any]any{int64(200): int64(100), int64(10): int64(10)})
+	testTransforms(t, metadata.MakeTypeFloat(), []*store.BindingTransformConfig{{Type: store.TransformClamp, Min: float(0), Max: float(1)}}, "float", map[any]any{-1.0: 0.0, 0.5: 0.5})
+}
+
+func TestTransformPipeline(t *testing.T) {
+	configs := []*store.BindingTransformConfig{
+		{Type: store.TransformThreshold, Threshold: float(1)},
+		{Type: store.TransformInvert},
+	}
+
+	testTransforms(t, metadata.MakeTypeRange(0, 255), configs, "bool", map[any]any{int64(0): true, int64(10): false})
+}
+
+func TestTransformInvalid(t *testing.T) {
+	invalids := []struct {
+		typ    metadata.Type
+		config *store.BindingTransformConfig
+	}{
+		{metadata.MakeTypeRange(0, 100), &store.BindingTransformConfig{Type: store.TransformInvert}},
+		{metadata.MakeTypeFloat(), &store.BindingTransformConfig{Type: store.TransformScale, To: []float64{0, 100}}},
+		{metadata.MakeTypeRange(0, 100), &store.BindingTransformConfig{Type: store.TransformScale, To: []float64{0, 0.5}}},
+		{metadata.MakeTypeRange(0, 100), &store.BindingTransformConfig{Type: store.TransformThreshold}},
+		{metadata.MakeTypeEnum("a", "b"), &store.BindingTransformConfig{Type: store.TransformMap, Values: map[string]any{"c": "x"}}},
+		{metadata.MakeTypeBool(), &store.BindingTransformConfig{Type: store.TransformMap, Values: map[string]any{"true": "x", "false": 1.0}}},
+		{metadata.MakeTypeRange(0, 100), &store.BindingTransformConfig{Type: store.TransformClamp, Min: float(200)}},
+		{metadata.MakeTypeBool(), &store.BindingTransformConfig{Type: "unknown"}},
+	}
+
+	for _, invalid := range invalids {
+		_, _, err := makeTransforms([]*store.BindingTransformConfig{invalid.config}, invalid.typ)
+		assert.Error(t, err, invalid.config.String())
+	}
+}
+
+func TestAssignableType(t *testing.T) {
+	assert.True(t, isAssignableType(metadata.MakeTypeRange(0, 100), metadata.MakeTypeRange(0, 255)))
+	assert.False(t, isAssignableType(metadata.MakeTypeRange(0, 255), metadata.MakeTypeRange(0, 100)))
+	assert.True(t, isAssignableType(metadata.MakeTypeEnum("on", "off"), metadata.MakeTypeEnum("on", "off", "auto")))
+	assert.False(t, isAssignableType(metadata.MakeTypeEnum("on", "off", "auto"), metadata.MakeTypeEnum("on", "off")))
+	assert.True(t, isAssignableType(metadata.MakeTypeBool(), metadata.MakeTypeNullable(metadata.MakeTypeBool())))
+	assert.False(t, isAssignableType(metadata.MakeTypeBool(), metadata.MakeTypeText()))
+}
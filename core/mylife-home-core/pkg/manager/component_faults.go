@@ -0,0 +1,99 @@
+package manager
+
+import (
+	"mylife-home-common/bus"
+	"mylife-home-common/tools"
+	"mylife-home-core/pkg/plugins"
+
+	"golang.org/x/exp/maps"
+)
+
+type componentFault struct {
+	Id    string `json:"id"`
+	Fault string `json:"fault"` // reason: panic, Init error, or error creating the component
+}
+
+type componentFaultChange struct {
+	id    string
+	fault string // empty when the component is not faulted anymore, or removed
+}
+
+// Forward the fault changes of the component to the manager subject
+func (manager *componentManager) watchFault(comp *plugins.Component) {
+	id := comp.Id()
+	observable := comp.Fault()
+	ch := make(chan string)
+	exited := make(chan struct{})
+
+	// Note: faults are tracked here, the fault subject is locked while it notifies
+	go func() {
+		defer close(exited)
+
+		for fault := range ch {
+			manager.faultsMux.Lock()
+			if fault == "" {
+				delete(manager.faults, id)
+			} else {
+				manager.faults[id] = fault
+			}
+			manager.faultsMux.Unlock()
+
+			manager.faultChanges.Notify(&componentFaultChange{id: id, fault: fault})
+		}
+	}()
+
+	observable.Subscribe(ch, true)
+
+	manager.faultWatches[id] = func() {
+		observable.Unsubscribe(ch)
+		close(ch)
+		<-exited
+	}
+}
+
+func (manager *componentManager) unwatchFault(id string) {
+	if unwatch, exists := manager.faultWatches[id]; exists {
+		unwatch()
+		delete(manager.faultWatches, id)
+	}
+
+	manager.faultsMux.Lock()
+	delete(manager.faults, id)
+	manager.faultsMux.Unlock()
+
+	manager.faultChanges.Notify(&componentFaultChange{id: id})
+}
+
+const faultsMetadataPath = "faults/"
+
+// Publish faulted components as instance metadata, at 'faults/<id>'
+func makeFaultsPublisher(transport *bus.Transport, cm *componentManager) *metadataPublisher {
+	values := func() map[string]any {
+		values := make(map[string]any)
+		for id, fault := range cm.GetFaults() {
+			values[id] = &componentFault{Id: id, Fault: fault}
+		}
+		return values
+	}
+
+	mapper := func(change *componentFaultChange) *metadataChange {
+		if change.fault == "" {
+			return &metadataChange{key: change.id}
+		}
+		return &metadataChange{key: change.id, value: &componentFault{Id: change.id, Fault: change.fault}}
+	}
+
+	return makeMetadataPublisher(transport, faultsMetadataPath, values, cm.OnFaultChange(), mapper)
+}
+
+// Reason of the fault of faulted components, by component id
+func (manager *componentManager) GetFaults() map[string]string {
+	manager.faultsMux.Lock()
+	defer manager.faultsMux.Unlock()
+
+	return maps.Clone(manager.faults)
+}
+
+func (manager *componentManager) OnFaultChange() tools.Observable[*componentFaultChange] {
+	return manager.faultChanges
+}
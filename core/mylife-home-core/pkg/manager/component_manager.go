@@ -3,14 +3,17 @@ package manager
 import (
 	"encoding/json"
 	"fmt"
+	"mylife-home-common/bus"
 	"mylife-home-common/components"
-	"mylife-home-common/components/metadata"
 	"mylife-home-common/instance_info"
+	"mylife-home-common/tools"
 	"mylife-home-core/pkg/plugins"
 	"mylife-home-core/pkg/store"
 	"strings"
+	"sync"
 
 	"github.com/gookit/goutil/errorx/panics"
+	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 )
 
@@ -19,22 +22,50 @@ type componentManager struct {
 	store            *store.Store
 	supportsBindings bool
 	components       map[string]*plugins.Component
+	placeholders     map[string]struct{} // ids of stored components which could not be created, kept faulted
 	bindings         map[string]*binding
+	bindingsMux      sync.Mutex // guards bindings, read by the status publisher; changes are also serialized by mux
+	bindingsStatus   tools.Subject[*bindingStatusChange]
+	faultChanges     tools.Subject[*componentFaultChange]
+	faultWatches     map[string]func() // unwatch functions, by component id
+	faults           map[string]string // reason, by id of faulted component
+	faultsMux        sync.Mutex
+	mux              sync.Mutex        // serializes the changes (RPC calls, project apply, store saves)
+	autoSaver        *autoSaver        // nil if disabled
+	states           *statePersistence // nil if disabled
 }
 
-func makeComponentManager(registry components.Registry, supportsBindings bool) *componentManager {
+func makeComponentManager(registry components.Registry, transport *bus.Transport, supportsBindings bool, statePath string) *componentManager {
+	instance_info.AddCapability("components-manager")
+	if supportsBindings {
+		instance_info.AddCapability("bindings-manager")
+	}
+
+	return newComponentManager(registry, store.MakeStore(transport), supportsBindings, statePath)
+}
+
+func newComponentManager(registry components.Registry, componentsStore *store.Store, supportsBindings bool, statePath string) *componentManager {
 
 	manager := &componentManager{
 		registry:         registry,
-		store:            store.MakeStore(),
+		store:            componentsStore,
 		supportsBindings: supportsBindings,
 		components:       make(map[string]*plugins.Component),
+		placeholders:     make(map[string]struct{}),
 		bindings:         make(map[string]*binding),
+		bindingsStatus:   tools.MakeSubject[*bindingStatusChange](),
+		faultChanges:     tools.MakeSubject[*componentFaultChange](),
+		faultWatches:     make(map[string]func()),
+		faults:           make(map[string]string),
 	}
 
-	instance_info.AddCapability("components-manager")
-	if manager.supportsBindings {
-		instance_info.AddCapability("bindings-manager")
+	if statePath != "" {
+		manager.states = makeStatePersistence(statePath)
+	}
+
+	if delay := manager.store.AutoSaveDelay(); delay > 0 {
+		// Note: waits for a running project apply, so that a half-applied store is not saved
+		manager.autoSaver = makeAutoSaver("store", delay, plugins.SystemClock(), manager.Save)
 	}
 
 	for _, id := range plugins.Ids() {
@@ -50,42 +81,121 @@ func makeComponentManager(registry components.Registry, supportsBindings bool) *
 	}
 
 	for _, config := range manager.store.GetComponents() {
-		pluginInstance := plugins.GetPlugin(config.Plugin)
-		panics.IsTrue(pluginInstance != nil, "plugin does not exists: '%s'", config.Plugin)
-
-		pluginConfig, err := manager.buildConfig(pluginInstance.Metadata(), config.Config)
-		panics.IsTrue(err == nil, "could not create plugin config for plugin '%s': %s", pluginInstance.Metadata().Id(), err)
-
-		comp, err := pluginInstance.Instantiate(config.Id, pluginConfig)
-		panics.IsTrue(err == nil, "could not create component '%s' (plugin='%s'): %s", config.Id, pluginInstance.Metadata().Id(), err)
-
-		manager.components[config.Id] = comp
-		manager.registry.AddComponent("", comp)
+		// Note: a component which cannot be created is kept faulted, so that the other components can run
+		if err := manager.createStoredComponent(config); err != nil {
+			logger.WithError(err).Errorf("Component '%s' (plugin='%s') is faulted, it can be retried with 'components.retry'", config.Id, config.Plugin)
+		}
 	}
 
 	for _, config := range manager.store.GetBindings() {
 		key := manager.buildBindingKey(config)
-		manager.bindings[key] = makeBinding(manager.registry, config)
+		manager.bindings[key] = makeBinding(manager.registry, config, key, manager.bindingsStatus)
 	}
 
 	return manager
 }
 
+// Create the component from its stored configuration.
+//
+// If the plugin instance cannot be created (unknown plugin, invalid config), a placeholder is kept faulted with the error as reason.
+// If it fails to initialize, the component is kept faulted.
+func (manager *componentManager) createStoredComponent(config *store.ComponentConfig) error {
+	pluginInstance := plugins.GetPlugin(config.Plugin)
+	if pluginInstance == nil {
+		err := fmt.Errorf("plugin does not exists: '%s'", config.Plugin)
+		manager.setPlaceholder(config.Id, err)
+		return err
+	}
+
+	pluginConfig, err := manager.readConfig(pluginInstance, config.Config)
+	if err != nil {
+		err = fmt.Errorf("could not create plugin config for plugin '%s': %w", pluginInstance.Metadata().Id(), err)
+		manager.setPlaceholder(config.Id, err)
+		return err
+	}
+
+	comp, err := pluginInstance.Instantiate(config.Id, pluginConfig, manager.persistedState(config.Id), manager.dataStore())
+	if comp == nil {
+		manager.setPlaceholder(config.Id, err)
+		return err
+	}
+
+	manager.clearPlaceholder(config.Id)
+	manager.registerComponent(comp)
+	manager.migrateSecrets(pluginInstance.Metadata(), config)
+
+	return err
+}
+
+// Keep a stored component which could not be created, faulted until it is retried
+func (manager *componentManager) setPlaceholder(id string, err error) {
+	manager.placeholders[id] = struct{}{}
+
+	manager.faultsMux.Lock()
+	manager.faults[id] = err.Error()
+	manager.faultsMux.Unlock()
+
+	manager.faultChanges.Notify(&componentFaultChange{id: id, fault: err.Error()})
+}
+
+func (manager *componentManager) clearPlaceholder(id string) {
+	if _, exists := manager.placeholders[id]; !exists {
+		return
+	}
+
+	delete(manager.placeholders, id)
+	manager.unwatchFault(id)
+}
+
+func (manager *componentManager) registerComponent(comp *plugins.Component) {
+	manager.components[comp.Id()] = comp
+	manager.registry.AddComponent("", comp)
+	manager.watchState(comp)
+	manager.watchFault(comp)
+}
+
 func (manager *componentManager) Terminate() {
-	for _, binding := range manager.bindings {
-		binding.Terminate()
+	if manager.states != nil {
+		manager.states.Terminate()
 	}
+
+	manager.bindingsMux.Lock()
+	bindings := maps.Values(manager.bindings)
 	clear(manager.bindings)
+	manager.bindingsMux.Unlock()
+
+	for _, binding := range bindings {
+		binding.Terminate()
+	}
 
-	for _, component := range manager.components {
+	for id, component := range manager.components {
 		manager.registry.RemoveComponent("", component)
+		manager.unwatchFault(id)
 		component.Terminate()
 	}
 	clear(manager.components)
+
+	for id := range manager.placeholders {
+		manager.unwatchFault(id)
+	}
+	clear(manager.placeholders)
+
+	if manager.autoSaver != nil {
+		manager.autoSaver.Terminate()
+	}
+
+	manager.store.Terminate()
 }
 
 func (manager *componentManager) AddComponent(id string, plugin string, config map[string]json.RawMessage) error {
-	if _, exists := manager.components[id]; exists {
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
+
+	return manager.addComponent(id, plugin, config)
+}
+
+func (manager *componentManager) addComponent(id string, plugin string, config map[string]json.RawMessage) error {
+	if manager.exists(id) {
 		return fmt.Errorf("component id duplicate: '%s'", id)
 	}
 
@@ -94,67 +204,261 @@ func (manager *componentManager) AddComponent(id string, plugin string, config m
 		return fmt.Errorf("plugin does not exists: '%s'", plugin)
 	}
 
-	pluginConfig, err := manager.buildConfig(pluginInstance.Metadata(), config)
+	stored, err := manager.sealSecrets(pluginInstance.Metadata(), config, nil)
+	if err != nil {
+		return fmt.Errorf("could not create plugin config for plugin '%s': %w", pluginInstance.Metadata().Id(), err)
+	}
+
+	pluginConfig, err := manager.readConfig(pluginInstance, stored)
 	if err != nil {
 		return fmt.Errorf("could not create plugin config for plugin '%s': %w", pluginInstance.Metadata().Id(), err)
 	}
 
-	comp, err := pluginInstance.Instantiate(id, pluginConfig)
+	comp, err := pluginInstance.Instantiate(id, pluginConfig, manager.persistedState(id), manager.dataStore())
 	if err != nil {
+		if comp != nil {
+			comp.Terminate()
+		}
+
 		return err
 	}
 
-	manager.components[id] = comp
-	manager.registry.AddComponent("", comp)
+	manager.registerComponent(comp)
 	manager.store.SetComponent(&store.ComponentConfig{
 		Id:     id,
 		Plugin: plugin,
-		Config: config,
+		Config: stored,
 	})
+	manager.storeChanged()
 
 	return nil
 }
 
-func (manager *componentManager) RemoveComponent(id string) error {
+// Apply a new configuration to an existing component, keeping it registered and bound.
+// A component which could not be created is created again with the new configuration.
+//
+// Note: plugin is optional, but must match the component plugin if provided
+func (manager *componentManager) UpdateComponent(id string, plugin string, config map[string]json.RawMessage) error {
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
+
+	return manager.updateComponent(id, plugin, config)
+}
+
+func (manager *componentManager) updateComponent(id string, plugin string, config map[string]json.RawMessage) error {
+	if !manager.exists(id) {
+		return fmt.Errorf("component id does not exist: '%s'", id)
+	}
+
+	current := manager.store.GetComponent(id)
+	if plugin != "" && plugin != current.Plugin {
+		return fmt.Errorf("cannot change plugin of component '%s' from '%s' to '%s'", id, current.Plugin, plugin)
+	}
+
+	pluginInstance := plugins.GetPlugin(current.Plugin)
+	if pluginInstance == nil {
+		return fmt.Errorf("plugin does not exists: '%s'", current.Plugin)
+	}
+
+	stored, err := manager.sealSecrets(pluginInstance.Metadata(), config, current.Config)
+	if err != nil {
+		return fmt.Errorf("could not create plugin config for plugin '%s': %w", pluginInstance.Metadata().Id(), err)
+	}
+
+	pluginConfig, err := manager.readConfig(pluginInstance, stored)
+	if err != nil {
+		return fmt.Errorf("could not create plugin config for plugin '%s': %w", pluginInstance.Metadata().Id(), err)
+	}
+
+	updated := &store.ComponentConfig{
+		Id:     id,
+		Plugin: current.Plugin,
+		Config: stored,
+	}
+
 	comp, exists := manager.components[id]
 	if !exists {
+		// Placeholder: the fixed config may allow to create it now
+		manager.store.SetComponent(updated)
+		manager.storeChanged()
+
+		return manager.createStoredComponent(updated)
+	}
+
+	if err := comp.Reconfigure(pluginConfig); err != nil {
+		return err
+	}
+
+	manager.store.SetComponent(updated)
+	manager.storeChanged()
+
+	return nil
+}
+
+func (manager *componentManager) RemoveComponent(id string) error {
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
+
+	return manager.removeComponent(id)
+}
+
+func (manager *componentManager) removeComponent(id string) error {
+	if !manager.exists(id) {
 		return fmt.Errorf("component id does not exist: '%s'", id)
 	}
 
-	manager.registry.RemoveComponent("", comp)
-	comp.Terminate()
+	comp, created := manager.components[id]
+	if created {
+		manager.registry.RemoveComponent("", comp)
+	}
+	if manager.states != nil {
+		manager.states.Remove(id)
+	}
+	manager.unwatchFault(id)
+	if created {
+		comp.Terminate()
+	}
 	delete(manager.components, id)
+	delete(manager.placeholders, id)
 	manager.store.RemoveComponent(id)
+	manager.storeChanged()
 
 	return nil
 }
 
+// Create again the plugin instance of a faulted component, with its stored configuration
+func (manager *componentManager) RetryComponent(id string) error {
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
+
+	return manager.retryComponent(id)
+}
+
+func (manager *componentManager) retryComponent(id string) error {
+	if _, exists := manager.placeholders[id]; exists {
+		// Note: the plugin may not be known yet, or the config may not have been valid
+		return manager.createStoredComponent(manager.store.GetComponent(id))
+	}
+
+	comp, exists := manager.components[id]
+	if !exists {
+		return fmt.Errorf("component id does not exist: '%s'", id)
+	}
+
+	pluginInstance := plugins.GetPlugin(comp.Plugin().Id())
+	config := manager.store.GetComponent(id)
+
+	// Note: read again, eg: environment variables of secret items may have been fixed
+	pluginConfig, err := manager.readConfig(pluginInstance, config.Config)
+	if err != nil {
+		return fmt.Errorf("could not create plugin config for plugin '%s': %w", pluginInstance.Metadata().Id(), err)
+	}
+
+	return comp.Retry(pluginConfig)
+}
+
+// Note: secret config items are redacted
 func (manager *componentManager) GetComponents() []*store.ComponentConfig {
-	return slices.Clone(manager.store.GetComponents())
+	list := make([]*store.ComponentConfig, 0)
+
+	for _, config := range manager.store.GetComponents() {
+		redacted := *config
+		if pluginInstance := plugins.GetPlugin(config.Plugin); pluginInstance != nil {
+			redacted.Config = redactSecrets(pluginInstance.Metadata(), config.Config)
+		}
+
+		list = append(list, &redacted)
+	}
+
+	return list
+}
+
+// Health of the component, as reported by its plugin instance
+func (manager *componentManager) GetHealth(id string) components.Health {
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
+
+	comp, exists := manager.components[id]
+	if !exists {
+		if _, exists := manager.placeholders[id]; exists {
+			return components.Health{Status: components.HealthError, Message: "faulted: " + manager.GetFaults()[id]}
+		}
+
+		return components.Health{}
+	}
+
+	return comp.Health().Get()
+}
+
+// Count of invalid action values dropped by the component, by action name
+func (manager *componentManager) GetDroppedActions(id string) map[string]int64 {
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
+
+	comp, exists := manager.components[id]
+	if !exists {
+		return nil
+	}
+
+	return comp.DroppedActions()
+}
+
+// Created component or placeholder
+func (manager *componentManager) exists(id string) bool {
+	if _, exists := manager.components[id]; exists {
+		return true
+	}
+
+	_, exists := manager.placeholders[id]
+	return exists
 }
 
 func (manager *componentManager) AddBinding(config *store.BindingConfig) error {
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
+
+	return manager.addBinding(config)
+}
+
+func (manager *componentManager) addBinding(config *store.BindingConfig) error {
 	key := manager.buildBindingKey(config)
 	if _, exists := manager.bindings[key]; exists {
 		return fmt.Errorf("binding already exists: '%s'", config)
 	}
 
-	manager.bindings[key] = makeBinding(manager.registry, config)
+	binding := makeBinding(manager.registry, config, key, manager.bindingsStatus)
+
+	manager.bindingsMux.Lock()
+	manager.bindings[key] = binding
+	manager.bindingsMux.Unlock()
+
 	manager.store.AddBinding(config)
+	manager.storeChanged()
 
 	return nil
 }
 
 func (manager *componentManager) RemoveBinding(config *store.BindingConfig) error {
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
+
+	return manager.removeBinding(config)
+}
+
+func (manager *componentManager) removeBinding(config *store.BindingConfig) error {
 	key := manager.buildBindingKey(config)
 	binding, exists := manager.bindings[key]
 	if !exists {
 		return fmt.Errorf("binding does not exist: %s", config)
 	}
 
-	binding.Terminate()
+	manager.bindingsMux.Lock()
 	delete(manager.bindings, key)
+	manager.bindingsMux.Unlock()
+
+	binding.Terminate()
 	manager.store.RemoveBinding(config)
+	manager.storeChanged()
 
 	return nil
 }
@@ -163,70 +467,84 @@ func (manager *componentManager) GetBindings() []*store.BindingConfig {
 	return slices.Clone(manager.store.GetBindings())
 }
 
+// Indexed by binding key
+func (manager *componentManager) GetBindingsStatus() map[string]*bindingStatus {
+	// Note: the bindings notify their status changes, do not hold the lock while reading them
+	manager.bindingsMux.Lock()
+	bindings := maps.Clone(manager.bindings)
+	manager.bindingsMux.Unlock()
+
+	statuses := make(map[string]*bindingStatus)
+
+	for key, binding := range bindings {
+		statuses[key] = binding.Status()
+	}
+
+	return statuses
+}
+
+func (manager *componentManager) OnBindingStatusChange() tools.Observable[*bindingStatusChange] {
+	return manager.bindingsStatus
+}
+
 func (manager *componentManager) Save() error {
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
+
 	return manager.store.Save()
 }
 
-func (manager *componentManager) buildBindingKey(config *store.BindingConfig) string {
-	return strings.Join([]string{config.SourceComponent, config.SourceState, config.TargetComponent, config.TargetAction}, ":")
+func (manager *componentManager) persistedState(id string) map[string]json.RawMessage {
+	if manager.states == nil {
+		return nil
+	}
+
+	return manager.states.Get(id)
 }
 
-// Deserialize properly. go unmarshaller does not differentiate properly int vs float
-func (manager *componentManager) buildConfig(plugin *metadata.Plugin, config map[string]json.RawMessage) (map[string]any, error) {
-	result := make(map[string]any)
+func (manager *componentManager) restoreState(id string, values map[string]json.RawMessage) {
+	if manager.states != nil {
+		manager.states.Restore(id, values)
+	}
+}
 
-	for _, name := range plugin.ConfigNames() {
-		item := plugin.Config(name)
-		raw, ok := config[name]
-		if !ok {
-			return nil, fmt.Errorf("missing config value for item '%s'", name)
-		}
+// Note: components data is kept in memory without state file
+func (manager *componentManager) dataStore() plugins.DataStore {
+	if manager.states == nil {
+		return nil
+	}
 
-		switch item.ValueType() {
-		case metadata.String:
-			{
-				var value string
-				if err := json.Unmarshal(raw, &value); err != nil {
-					return nil, fmt.Errorf("could not read value '%s' for item '%s': %w", string(raw), name, err)
-				}
-
-				result[name] = value
-			}
-
-		case metadata.Bool:
-			{
-				var value bool
-				if err := json.Unmarshal(raw, &value); err != nil {
-					return nil, fmt.Errorf("could not read value '%s' for item '%s': %w", string(raw), name, err)
-				}
-
-				result[name] = value
-			}
-
-		case metadata.Integer:
-			{
-				var value int64
-				if err := json.Unmarshal(raw, &value); err != nil {
-					return nil, fmt.Errorf("could not read value '%s' for item '%s': %w", string(raw), name, err)
-				}
-
-				result[name] = value
-			}
-
-		case metadata.Float:
-			{
-				var value float64
-				if err := json.Unmarshal(raw, &value); err != nil {
-					return nil, fmt.Errorf("could not read value '%s' for item '%s': %w", string(raw), name, err)
-				}
-
-				result[name] = value
-			}
-
-		default:
-			return nil, fmt.Errorf("unhandled value type '%s' for item '%s'", item.ValueType(), name)
-		}
+	return manager.states
+}
+
+func (manager *componentManager) watchState(comp *plugins.Component) {
+	if manager.states != nil {
+		manager.states.Watch(comp)
 	}
+}
 
-	return result, nil
+func (manager *componentManager) storeChanged() {
+	if manager.autoSaver != nil {
+		manager.autoSaver.Changed()
+	}
+}
+
+func (manager *componentManager) StoreDirty() tools.ObservableValue[bool] {
+	return manager.store.Dirty()
+}
+
+func (manager *componentManager) IsAutoSaveEnabled() bool {
+	return manager.autoSaver != nil
+}
+
+func (manager *componentManager) ListSnapshots() ([]*store.SnapshotInfo, error) {
+	return manager.store.ListSnapshots()
+}
+
+func (manager *componentManager) DiffSnapshots(from string, to string) (*store.Diff, error) {
+	return manager.store.DiffSnapshots(from, to)
+}
+
+func (manager *componentManager) buildBindingKey(config *store.BindingConfig) string {
+	return strings.Join([]string{config.SourceComponent, config.SourceState, config.TargetComponent, config.TargetAction}, ":")
 }
@@ -0,0 +1,150 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"mylife-home-common/components"
+	"mylife-home-common/components/metadata"
+	"mylife-home-common/config"
+	"mylife-home-core-library/definitions"
+	"mylife-home-core-library/registry"
+	"mylife-home-core/pkg/plugins"
+	"mylife-home-core/pkg/store"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type counterPlugin struct {
+	Count int64
+
+	Value definitions.State[int64]
+}
+
+func (component *counterPlugin) Init(runtime definitions.Runtime) error {
+	return nil
+}
+
+func (component *counterPlugin) Terminate() {
+}
+
+func (component *counterPlugin) SetValue(arg int64) {
+	component.Value.Set(arg)
+}
+
+// Constraints as added to deployed plugins (eg: logic-base 'bool-and', logic-clim 'constant-fan-mode')
+type constrainedPlugin struct {
+	UsedCount int64
+	FanMode   string
+}
+
+func (component *constrainedPlugin) Init(runtime definitions.Runtime) error {
+	return nil
+}
+
+func (component *constrainedPlugin) Terminate() {
+}
+
+func init() {
+	counter := registry.MakePluginTypeBuilder[counterPlugin]("test", "counter", "", metadata.Logic, "1.0.0")
+	counter.AddPersistentState("Value", "value", "", metadata.MakeTypeRange(0, 100))
+	counter.AddAction("SetValue", "setValue", "", metadata.MakeTypeRange(0, 100))
+	counter.AddConstrainedConfig("Count", "count", "", metadata.Integer, metadata.MakeConfigConstraints().SetMin(1).SetMax(4))
+	registry.RegisterPlugin(counter.Build())
+
+	constrained := registry.MakePluginTypeBuilder[constrainedPlugin]("test", "constrained", "", metadata.Logic, "1.0.0")
+	constrained.AddConstrainedConfig("UsedCount", "usedCount", "", metadata.Integer, metadata.MakeConfigConstraints().SetMin(0).SetMax(8))
+	constrained.AddConstrainedConfig("FanMode", "fanMode", "", metadata.String, metadata.MakeConfigConstraints().SetEnum("auto", "high", "low", "medium", "quiet"))
+	registry.RegisterPlugin(constrained.Build())
+
+	plugins.Build()
+}
+
+func makeTestComponentManager(t *testing.T, items string) *componentManager {
+	dir := t.TempDir()
+	storePath := path.Join(dir, "store.json")
+	if err := os.WriteFile(storePath, []byte(items), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	configFile := path.Join(dir, "config.yaml")
+	content := fmt.Sprintf("store:\n  type: fs\n  path: %s\n  history: 0\n", storePath)
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config.Init(configFile)
+
+	manager := newComponentManager(components.NewRegistry(), store.MakeStore(nil), false, path.Join(dir, "state.json"))
+	t.Cleanup(manager.Terminate)
+
+	return manager
+}
+
+func makeTestComponentItem(id string, plugin string, count int) string {
+	return fmt.Sprintf(`{"type":"component","config":{"id":"%s","plugin":"%s","config":{"count":%d}}}`, id, plugin, count)
+}
+
+func TestComponentManagerBootFaults(t *testing.T) {
+	manager := makeTestComponentManager(t, "["+
+		makeTestComponentItem("valid", "test.counter", 2)+","+
+		makeTestComponentItem("invalid", "test.counter", 9)+","+
+		makeTestComponentItem("unknown", "test.missing", 2)+"]")
+
+	assert.Contains(t, manager.components, "valid")
+	assert.NotContains(t, manager.components, "invalid")
+	assert.NotContains(t, manager.components, "unknown")
+	assert.Len(t, manager.GetComponents(), 3)
+
+	faults := manager.GetFaults()
+	assert.NotContains(t, faults, "valid")
+	assert.Contains(t, faults["invalid"], "count")
+	assert.Contains(t, faults["unknown"], "plugin does not exists: 'test.missing'")
+	assert.Equal(t, components.HealthError, manager.GetHealth("invalid").Status)
+
+	assert.Error(t, manager.AddComponent("invalid", "test.counter", map[string]json.RawMessage{"count": json.RawMessage("3")}), "duplicate")
+
+	// Config fixed in the store, then retried
+	assert.Error(t, manager.RetryComponent("invalid"))
+	manager.store.SetComponent(&store.ComponentConfig{Id: "invalid", Plugin: "test.counter", Config: map[string]json.RawMessage{"count": json.RawMessage("3")}})
+	assert.NoError(t, manager.RetryComponent("invalid"))
+	assert.Contains(t, manager.components, "invalid")
+	assert.Eventually(t, func() bool { _, faulted := manager.GetFaults()["invalid"]; return !faulted }, time.Second, 10*time.Millisecond)
+
+	assert.Error(t, manager.RetryComponent("unknown"))
+	assert.Contains(t, manager.GetFaults()["unknown"], "plugin does not exists")
+
+	assert.NoError(t, manager.RemoveComponent("unknown"))
+	assert.NotContains(t, manager.GetFaults(), "unknown")
+	assert.Len(t, manager.GetComponents(), 2)
+}
+
+func TestComponentManagerUpdateFaulted(t *testing.T) {
+	manager := makeTestComponentManager(t, "["+makeTestComponentItem("comp", "test.counter", 0)+"]")
+
+	assert.Contains(t, manager.GetFaults(), "comp")
+
+	assert.Error(t, manager.UpdateComponent("comp", "", map[string]json.RawMessage{"count": json.RawMessage("5")}))
+	assert.NoError(t, manager.UpdateComponent("comp", "", map[string]json.RawMessage{"count": json.RawMessage("1")}))
+	assert.Contains(t, manager.components, "comp")
+	assert.Eventually(t, func() bool { _, faulted := manager.GetFaults()["comp"]; return !faulted }, time.Second, 10*time.Millisecond)
+}
+
+// Store written before config constraints were checked: its values were valid then
+func TestComponentManagerBootConstraintViolations(t *testing.T) {
+	manager := makeTestComponentManager(t, `[
+		{"type":"component","config":{"id":"valid","plugin":"test.constrained","config":{"usedCount":8,"fanMode":"auto"}}},
+		{"type":"component","config":{"id":"count","plugin":"test.constrained","config":{"usedCount":9,"fanMode":"auto"}}},
+		{"type":"component","config":{"id":"mode","plugin":"test.constrained","config":{"usedCount":2,"fanMode":"turbo"}}}
+	]`)
+
+	assert.Contains(t, manager.components, "valid")
+
+	faults := manager.GetFaults()
+	assert.NotContains(t, faults, "valid")
+	assert.Contains(t, faults["count"], "item 'usedCount': value 9 is greater than maximum 8")
+	assert.Contains(t, faults["mode"], "item 'fanMode': value 'turbo' is not one of")
+}
@@ -12,7 +12,8 @@ import (
 var logger = log.CreateLogger("mylife:home:core:manager")
 
 type managerConfig struct {
-	SupportsBindings bool `mapstructure:"supportsBindings"`
+	SupportsBindings bool   `mapstructure:"supportsBindings"`
+	StatePath        string `mapstructure:"statePath"` // file where persistent component states are kept, disabled if empty
 }
 
 type Manager struct {
@@ -22,9 +23,12 @@ type Manager struct {
 	api       *rpcApi
 	publisher components.BusPublisher
 	listener  components.BusListener
+	bindings  *metadataPublisher
+	store     *metadataPublisher
+	faults    *metadataPublisher
 }
 
-func MakeManager() *Manager {
+func MakeManager() (*Manager, error) {
 	conf := managerConfig{}
 	config.BindStructure("manager", &conf)
 
@@ -35,25 +39,39 @@ func MakeManager() *Manager {
 	// static?
 	manager.addPluginsInstanceInfo()
 
-	manager.transport = bus.NewTransport()
+	transport, err := bus.NewTransport()
+	if err != nil {
+		return nil, err
+	}
+
+	manager.transport = transport
 	manager.registry = components.NewRegistry()
-	manager.cm = makeComponentManager(manager.registry, supportsBindings)
+	manager.cm = makeComponentManager(manager.registry, manager.transport, supportsBindings, conf.StatePath)
 	manager.api = makeRpcApi(manager.transport, manager.cm, supportsBindings)
 	manager.publisher = components.PublishBus(manager.transport, manager.registry)
+	manager.store = makeStoreStatusPublisher(manager.transport, manager.cm)
+	manager.faults = makeFaultsPublisher(manager.transport, manager.cm)
 
 	if supportsBindings {
 		manager.listener = components.ListenBus(manager.transport, manager.registry)
+		manager.bindings = makeBindingsStatusPublisher(manager.transport, manager.cm)
 	}
 
-	return manager
+	return manager, nil
 }
 
 func (manager *Manager) Terminate() {
 
+	if manager.bindings != nil {
+		manager.bindings.Terminate()
+	}
+
 	if manager.listener != nil {
 		manager.listener.Terminate()
 	}
 
+	manager.faults.Terminate()
+	manager.store.Terminate()
 	manager.publisher.Terminate()
 	manager.api.Terminate()
 	manager.cm.Terminate()
@@ -0,0 +1,145 @@
+package manager
+
+import (
+	"mylife-home-common/bus"
+	"mylife-home-common/tools"
+)
+
+type metadataChange struct {
+	key   string
+	value any // nil when the value is removed
+}
+
+// Publish keyed values as instance metadata, at '<path><key>'.
+//
+// All values are published again when the transport comes online, and cleared on terminate.
+type metadataPublisher struct {
+	transport   *bus.Transport
+	path        string
+	values      func() map[string]any // current values, by key
+	unsubscribe func()
+	changeChan  <-chan *metadataChange
+	onlineChan  chan bool
+	exited      chan struct{}
+	published   map[string]struct{}
+	online      bool // only accessed by the worker, the online subject is locked while it notifies
+}
+
+func makeMetadataPublisher[Change any](transport *bus.Transport, path string, values func() map[string]any, changes tools.Observable[Change], mapper func(change Change) *metadataChange) *metadataPublisher {
+	changeChan := make(chan Change)
+
+	publisher := &metadataPublisher{
+		transport:  transport,
+		path:       path,
+		values:     values,
+		changeChan: tools.MapChannel(changeChan, mapper),
+		onlineChan: make(chan bool),
+		exited:     make(chan struct{}),
+		published:  make(map[string]struct{}),
+	}
+
+	publisher.unsubscribe = func() {
+		changes.Unsubscribe(changeChan)
+		close(changeChan)
+	}
+
+	go publisher.worker()
+
+	publisher.transport.Online().Subscribe(publisher.onlineChan, true)
+	changes.Subscribe(changeChan)
+
+	return publisher
+}
+
+func (publisher *metadataPublisher) Terminate() {
+	publisher.transport.Online().Unsubscribe(publisher.onlineChan)
+	publisher.unsubscribe()
+
+	close(publisher.onlineChan)
+	<-publisher.exited
+}
+
+func (publisher *metadataPublisher) worker() {
+	defer close(publisher.exited)
+	defer publisher.onClose()
+
+	onlineChan := publisher.onlineChan
+	changeChan := publisher.changeChan
+
+	// Both channels are drained, so that no pending change is left blocked
+	for onlineChan != nil || changeChan != nil {
+		select {
+		case online, ok := <-onlineChan:
+			if !ok {
+				onlineChan = nil
+				continue
+			}
+
+			publisher.online = online
+			if online {
+				publisher.publishAll()
+			}
+
+		case change, ok := <-changeChan:
+			if !ok {
+				changeChan = nil
+				continue
+			}
+
+			if publisher.online {
+				// else will be published when online
+				publisher.update(change.key, change.value)
+			}
+		}
+	}
+}
+
+func (publisher *metadataPublisher) publishAll() {
+	values := publisher.values()
+
+	// removed while offline
+	for key := range publisher.published {
+		if _, exists := values[key]; !exists {
+			publisher.update(key, nil)
+		}
+	}
+
+	for key, value := range values {
+		publisher.update(key, value)
+	}
+}
+
+func (publisher *metadataPublisher) onClose() {
+	if !publisher.online {
+		return
+	}
+
+	for key := range publisher.published {
+		publisher.update(key, nil)
+	}
+}
+
+func (publisher *metadataPublisher) update(key string, value any) {
+	path := publisher.path + key
+
+	if value == nil {
+		if _, exists := publisher.published[key]; !exists {
+			return
+		}
+
+		if err := publisher.transport.Metadata().Clear(path); err != nil {
+			logger.WithError(err).Errorf("Could not unpublish metadata '%s'", path)
+			return
+		}
+
+		delete(publisher.published, key)
+		return
+	}
+
+	if err := publisher.transport.Metadata().Set(path, value); err != nil {
+		logger.WithError(err).Errorf("Could not publish metadata '%s'", path)
+		return
+	}
+
+	publisher.published[key] = struct{}{}
+}
@@ -0,0 +1,229 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"mylife-home-core/pkg/plugins"
+	"mylife-home-core/pkg/store"
+
+	"golang.org/x/exp/slices"
+)
+
+// Desired set of components and bindings of the instance
+type projectConfig struct {
+	Components []*store.ComponentConfig `json:"components"`
+	Bindings   []*store.BindingConfig   `json:"bindings"`
+}
+
+// Operations done so far, to undo on failure
+type transaction struct {
+	undos []func() error
+}
+
+func (tx *transaction) done(undo func() error) {
+	tx.undos = append(tx.undos, undo)
+}
+
+func (tx *transaction) rollback() {
+	for index := len(tx.undos) - 1; index >= 0; index -= 1 {
+		if err := tx.undos[index](); err != nil {
+			logger.WithError(err).Error("Error while rolling back project apply")
+		}
+	}
+}
+
+// Make the instance match the project: either all changes are applied, or none.
+//
+// Updated components are reconfigured in place, or recreated if their plugin changes or if they could not be created (keeping their persisted states and data).
+// Updated bindings are removed then added again.
+//
+// On failure, removed components are put back as they were stored, even if they were faulted.
+func (manager *componentManager) ApplyProject(project *projectConfig, dryRun bool) (*store.Diff, error) {
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
+
+	components, bindings, err := manager.indexProject(project)
+	if err != nil {
+		return nil, err
+	}
+
+	currentComponents := make(map[string]*store.ComponentConfig)
+	for _, config := range manager.store.GetComponents() {
+		currentComponents[config.Id] = config
+	}
+
+	currentBindings := make(map[string]*store.BindingConfig)
+	for key, binding := range manager.bindings {
+		currentBindings[key] = binding.config
+	}
+
+	if err := manager.sealProjectSecrets(components, currentComponents); err != nil {
+		return nil, err
+	}
+
+	diff := store.MakeDiff(currentComponents, currentBindings, components, bindings)
+
+	if dryRun || diff.IsEmpty() {
+		return diff, nil
+	}
+
+	// Components which change plugin cannot be reconfigured, placeholders have no plugin instance to reconfigure
+	recreated := make([]string, 0)
+	reconfigured := make([]string, 0)
+	for _, id := range diff.ComponentsUpdated {
+		if _, placeholder := manager.placeholders[id]; !placeholder && currentComponents[id].Plugin == components[id].Plugin {
+			reconfigured = append(reconfigured, id)
+		} else {
+			recreated = append(recreated, id)
+		}
+	}
+
+	tx := &transaction{}
+
+	apply := func() error {
+		for _, key := range append(slices.Clone(diff.BindingsRemoved), diff.BindingsUpdated...) {
+			config := currentBindings[key]
+			if err := manager.removeBinding(config); err != nil {
+				return err
+			}
+
+			tx.done(func() error { return manager.addBinding(config) })
+		}
+
+		// Note: removing a component forgets its persisted states and data, they are kept to create it again
+		removedStates := make(map[string]map[string]json.RawMessage)
+
+		for _, id := range append(slices.Clone(diff.ComponentsRemoved), recreated...) {
+			config := currentComponents[id]
+			states := manager.persistedState(id)
+			if err := manager.removeComponent(id); err != nil {
+				return err
+			}
+
+			removedStates[id] = states
+			tx.done(func() error { return manager.restoreComponent(config, states) })
+		}
+
+		for _, id := range reconfigured {
+			previous := currentComponents[id]
+			config := components[id]
+			if err := manager.updateComponent(id, config.Plugin, config.Config); err != nil {
+				return fmt.Errorf("could not update component '%s': %w", id, err)
+			}
+
+			tx.done(func() error { return manager.updateComponent(previous.Id, previous.Plugin, previous.Config) })
+		}
+
+		for _, id := range append(slices.Clone(recreated), diff.ComponentsAdded...) {
+			config := components[id]
+			manager.restoreState(id, removedStates[id])
+			if err := manager.addComponent(config.Id, config.Plugin, config.Config); err != nil {
+				return fmt.Errorf("could not add component '%s': %w", id, err)
+			}
+
+			tx.done(func() error { return manager.removeComponent(config.Id) })
+		}
+
+		for _, key := range append(slices.Clone(diff.BindingsUpdated), diff.BindingsAdded...) {
+			config := bindings[key]
+			if err := manager.addBinding(config); err != nil {
+				return fmt.Errorf("could not add binding '%s': %w", config, err)
+			}
+
+			tx.done(func() error { return manager.removeBinding(config) })
+		}
+
+		return nil
+	}
+
+	if err := apply(); err != nil {
+		logger.WithError(err).Error("Project apply failed, rolling back")
+		tx.rollback()
+		return nil, err
+	}
+
+	logger.Infof("Project applied: %+v", diff)
+
+	return diff, nil
+}
+
+// Put back a removed component with its stored entry, states and data.
+//
+// Note: it is not validated again, it is kept faulted if it cannot be created
+func (manager *componentManager) restoreComponent(config *store.ComponentConfig, states map[string]json.RawMessage) error {
+	if manager.exists(config.Id) {
+		return fmt.Errorf("component id duplicate: '%s'", config.Id)
+	}
+
+	manager.restoreState(config.Id, states)
+	manager.store.SetComponent(config)
+	manager.storeChanged()
+
+	if err := manager.createStoredComponent(config); err != nil {
+		logger.WithError(err).Errorf("Component '%s' (plugin='%s') is faulted, it can be retried with 'components.retry'", config.Id, config.Plugin)
+	}
+
+	return nil
+}
+
+// Make the instance match a store snapshot, the same way as a project
+func (manager *componentManager) RestoreSnapshot(id string, dryRun bool) (*store.Diff, error) {
+	components, bindings, err := manager.store.GetSnapshot(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return manager.ApplyProject(&projectConfig{Components: components, Bindings: bindings}, dryRun)
+}
+
+// Seal secret config items like the store does, so that unchanged or redacted values compare equal to the stored ones
+func (manager *componentManager) sealProjectSecrets(components map[string]*store.ComponentConfig, currentComponents map[string]*store.ComponentConfig) error {
+	for id, config := range components {
+		pluginInstance := plugins.GetPlugin(config.Plugin)
+		if pluginInstance == nil {
+			continue // reported when adding the component
+		}
+
+		var previous map[string]json.RawMessage
+		if current, exists := currentComponents[id]; exists && current.Plugin == config.Plugin {
+			previous = current.Config
+		}
+
+		sealed, err := manager.sealSecrets(pluginInstance.Metadata(), config.Config, previous)
+		if err != nil {
+			return fmt.Errorf("could not read config of component '%s': %w", id, err)
+		}
+
+		components[id] = &store.ComponentConfig{Id: config.Id, Plugin: config.Plugin, Config: sealed}
+	}
+
+	return nil
+}
+
+// Index by id/key, and check what can be checked before any change
+func (manager *componentManager) indexProject(project *projectConfig) (map[string]*store.ComponentConfig, map[string]*store.BindingConfig, error) {
+	components := make(map[string]*store.ComponentConfig)
+	for _, config := range project.Components {
+		if _, exists := components[config.Id]; exists {
+			return nil, nil, fmt.Errorf("component id duplicate: '%s'", config.Id)
+		}
+
+		components[config.Id] = config
+	}
+
+	if len(project.Bindings) > 0 && !manager.supportsBindings {
+		return nil, nil, fmt.Errorf("project has bindings but configuration does not activate its support")
+	}
+
+	bindings := make(map[string]*store.BindingConfig)
+	for _, config := range project.Bindings {
+		key := manager.buildBindingKey(config)
+		if _, exists := bindings[key]; exists {
+			return nil, nil, fmt.Errorf("binding duplicate: '%s'", config)
+		}
+
+		bindings[key] = config
+	}
+
+	return components, bindings, nil
+}
@@ -0,0 +1,69 @@
+package manager
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"mylife-home-core/pkg/store"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyProjectRollbackKeepsStates(t *testing.T) {
+	manager := makeTestComponentManager(t, "["+makeTestComponentItem("comp", "test.counter", 2)+"]")
+
+	manager.components["comp"].Action("setValue") <- int64(42)
+	assert.Eventually(t, func() bool { return string(manager.states.Get("comp")["value"]) == "42" }, time.Second, 10*time.Millisecond)
+	manager.states.SetData("comp", "key", json.RawMessage(`"data"`))
+
+	// 'comp' is removed, then adding 'other' fails
+	project := &projectConfig{
+		Components: []*store.ComponentConfig{
+			{Id: "other", Plugin: "test.counter", Config: map[string]json.RawMessage{"count": json.RawMessage("9")}},
+		},
+	}
+
+	_, err := manager.ApplyProject(project, false)
+	assert.Error(t, err)
+
+	assert.Contains(t, manager.components, "comp")
+	assert.NotContains(t, manager.components, "other")
+	assert.Equal(t, int64(42), manager.components["comp"].StateItem("value").Get())
+
+	data, ok := manager.states.GetData("comp", "key")
+	assert.True(t, ok)
+	assert.Equal(t, `"data"`, string(data))
+}
+
+func TestApplyProjectRollbackKeepsFaulted(t *testing.T) {
+	manager := makeTestComponentManager(t, "["+makeTestComponentItem("faulted", "test.counter", 9)+"]")
+	stored := manager.store.GetComponent("faulted")
+
+	// 'faulted' is removed, then adding 'other' fails
+	project := &projectConfig{
+		Components: []*store.ComponentConfig{
+			{Id: "other", Plugin: "test.counter", Config: map[string]json.RawMessage{"count": json.RawMessage("9")}},
+		},
+	}
+
+	_, err := manager.ApplyProject(project, false)
+	assert.Error(t, err)
+
+	assert.Equal(t, stored, manager.store.GetComponent("faulted"))
+	assert.Contains(t, manager.placeholders, "faulted")
+	assert.Contains(t, manager.GetFaults()["faulted"], "count")
+	assert.Nil(t, manager.store.GetComponent("other"))
+
+	// Fixed by the project: created again
+	project = &projectConfig{
+		Components: []*store.ComponentConfig{
+			{Id: "faulted", Plugin: "test.counter", Config: map[string]json.RawMessage{"count": json.RawMessage("3")}},
+		},
+	}
+
+	_, err = manager.ApplyProject(project, false)
+	assert.NoError(t, err)
+	assert.Contains(t, manager.components, "faulted")
+	assert.NotContains(t, manager.placeholders, "faulted")
+}
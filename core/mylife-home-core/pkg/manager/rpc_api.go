@@ -1,9 +1,14 @@
 package manager
 
 import (
+	"fmt"
 	"mylife-home-common/bus"
+	"mylife-home-common/components"
 	"mylife-home-common/instance_info"
 	"mylife-home-core/pkg/store"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
 type rpcApi struct {
@@ -20,8 +25,10 @@ func makeRpcApi(transport *bus.Transport, cm *componentManager, supportsBindings
 	}
 
 	api.transport.Rpc().Serve("components.add", bus.NewRpcService(api.componentAdd))
+	api.transport.Rpc().Serve("components.update", bus.NewRpcService(api.componentUpdate))
 	api.transport.Rpc().Serve("components.remove", bus.NewRpcService(api.componentRemove))
 	api.transport.Rpc().Serve("components.list", bus.NewRpcService(api.componentList))
+	api.transport.Rpc().Serve("components.retry", bus.NewRpcService(api.componentRetry))
 
 	instance_info.AddCapability("components-api")
 
@@ -29,11 +36,19 @@ func makeRpcApi(transport *bus.Transport, cm *componentManager, supportsBindings
 		api.transport.Rpc().Serve("bindings.add", bus.NewRpcService(api.bindingAdd))
 		api.transport.Rpc().Serve("bindings.remove", bus.NewRpcService(api.bindingRemove))
 		api.transport.Rpc().Serve("bindings.list", bus.NewRpcService(api.bindingList))
+		api.transport.Rpc().Serve("bindings.status", bus.NewRpcService(api.bindingStatus))
 
 		instance_info.AddCapability("bindings-api")
 	}
 
+	api.transport.Rpc().Serve("project.apply", bus.NewRpcService(api.projectApply))
+
+	instance_info.AddCapability("project-api")
+
 	api.transport.Rpc().Serve("store.save", bus.NewRpcService(api.storeSave))
+	api.transport.Rpc().Serve("store.history.list", bus.NewRpcService(api.storeHistoryList))
+	api.transport.Rpc().Serve("store.history.diff", bus.NewRpcService(api.storeHistoryDiff))
+	api.transport.Rpc().Serve("store.history.restore", bus.NewRpcService(api.storeHistoryRestore))
 
 	instance_info.AddCapability("store-api")
 
@@ -42,16 +57,23 @@ func makeRpcApi(transport *bus.Transport, cm *componentManager, supportsBindings
 
 func (api *rpcApi) Terminate() {
 	api.transport.Rpc().Unserve("components.add")
+	api.transport.Rpc().Unserve("components.update")
 	api.transport.Rpc().Unserve("components.remove")
 	api.transport.Rpc().Unserve("components.list")
+	api.transport.Rpc().Unserve("components.retry")
 
 	if api.supportsBindings {
 		api.transport.Rpc().Unserve("bindings.add")
 		api.transport.Rpc().Unserve("bindings.remove")
 		api.transport.Rpc().Unserve("bindings.list")
+		api.transport.Rpc().Unserve("bindings.status")
 	}
 
+	api.transport.Rpc().Unserve("project.apply")
 	api.transport.Rpc().Unserve("store.save")
+	api.transport.Rpc().Unserve("store.history.list")
+	api.transport.Rpc().Unserve("store.history.diff")
+	api.transport.Rpc().Unserve("store.history.restore")
 }
 
 func (api *rpcApi) componentAdd(config *store.ComponentConfig) (struct{}, error) {
@@ -59,6 +81,11 @@ func (api *rpcApi) componentAdd(config *store.ComponentConfig) (struct{}, error)
 	return struct{}{}, err
 }
 
+func (api *rpcApi) componentUpdate(config *store.ComponentConfig) (struct{}, error) {
+	err := api.cm.UpdateComponent(config.Id, config.Plugin, config.Config)
+	return struct{}{}, err
+}
+
 func (api *rpcApi) componentRemove(input struct {
 	Id string `json:"id"`
 }) (struct{}, error) {
@@ -66,11 +93,37 @@ func (api *rpcApi) componentRemove(input struct {
 	return struct{}{}, err
 }
 
-func (api *rpcApi) componentList(input struct{}) ([]*store.ComponentConfig, error) {
-	list := api.cm.GetComponents()
+// Component as listed, with its status
+type componentInfo struct {
+	*store.ComponentConfig
+	Fault          string            `json:"fault,omitempty"` // reason, if the component is faulted
+	Health         components.Health `json:"health"`
+	DroppedActions map[string]int64  `json:"droppedActions,omitempty"` // count of invalid values, by action
+}
+
+func (api *rpcApi) componentList(input struct{}) ([]*componentInfo, error) {
+	faults := api.cm.GetFaults()
+	list := make([]*componentInfo, 0)
+
+	for _, config := range api.cm.GetComponents() {
+		list = append(list, &componentInfo{
+			ComponentConfig: config,
+			Fault:           faults[config.Id],
+			Health:          api.cm.GetHealth(config.Id),
+			DroppedActions:  api.cm.GetDroppedActions(config.Id),
+		})
+	}
+
 	return list, nil
 }
 
+func (api *rpcApi) componentRetry(input struct {
+	Id string `json:"id"`
+}) (struct{}, error) {
+	err := api.cm.RetryComponent(input.Id)
+	return struct{}{}, err
+}
+
 func (api *rpcApi) bindingAdd(config *store.BindingConfig) (struct{}, error) {
 	err := api.cm.AddBinding(config)
 	return struct{}{}, err
@@ -86,7 +139,71 @@ func (api *rpcApi) bindingList(input struct{}) ([]*store.BindingConfig, error) {
 	return list, nil
 }
 
+func (api *rpcApi) bindingStatus(input struct{}) ([]*bindingStatus, error) {
+	statuses := api.cm.GetBindingsStatus()
+	keys := maps.Keys(statuses)
+	slices.Sort(keys)
+
+	list := make([]*bindingStatus, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, statuses[key])
+	}
+
+	return list, nil
+}
+
+type projectApplyInput struct {
+	projectConfig
+	Save   bool `json:"save,omitempty"`   // save the store once applied
+	DryRun bool `json:"dryRun,omitempty"` // only compute the diff
+}
+
+func (api *rpcApi) projectApply(input *projectApplyInput) (*store.Diff, error) {
+	diff, err := api.cm.ApplyProject(&input.projectConfig, input.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Save && !input.DryRun {
+		if err := api.cm.Save(); err != nil {
+			return nil, fmt.Errorf("project applied but could not save store: %w", err)
+		}
+	}
+
+	return diff, nil
+}
+
 func (api *rpcApi) storeSave(input struct{}) (struct{}, error) {
 	err := api.cm.Save()
 	return struct{}{}, err
 }
+
+func (api *rpcApi) storeHistoryList(input struct{}) ([]*store.SnapshotInfo, error) {
+	return api.cm.ListSnapshots()
+}
+
+func (api *rpcApi) storeHistoryDiff(input struct {
+	From string `json:"from"` // empty for the current content of the store
+	To   string `json:"to"`   // empty for the current content of the store
+}) (*store.Diff, error) {
+	return api.cm.DiffSnapshots(input.From, input.To)
+}
+
+func (api *rpcApi) storeHistoryRestore(input struct {
+	Id     string `json:"id"`
+	Save   bool   `json:"save,omitempty"`   // save the store once restored
+	DryRun bool   `json:"dryRun,omitempty"` // only compute the diff
+}) (*store.Diff, error) {
+	diff, err := api.cm.RestoreSnapshot(input.Id, input.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Save && !input.DryRun {
+		if err := api.cm.Save(); err != nil {
+			return nil, fmt.Errorf("snapshot restored but could not save store: %w", err)
+		}
+	}
+
+	return diff, nil
+}
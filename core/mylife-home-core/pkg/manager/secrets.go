@@ -0,0 +1,94 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"mylife-home-common/components/metadata"
+	"mylife-home-core/pkg/plugins"
+	"mylife-home-core/pkg/store"
+
+	"golang.org/x/exp/maps"
+)
+
+// Plugin config from the stored config
+func (manager *componentManager) readConfig(pluginInstance *plugins.Plugin, stored map[string]json.RawMessage) (map[string]any, error) {
+	plain, err := manager.openSecrets(pluginInstance.Metadata(), stored)
+	if err != nil {
+		return nil, err
+	}
+
+	return pluginInstance.ReadConfig(plain)
+}
+
+// Encrypt the secret items stored in clear (store written before secrets support, or secret key configured since)
+func (manager *componentManager) migrateSecrets(plugin *metadata.Plugin, config *store.ComponentConfig) {
+	if !manager.store.Secrets().Enabled() {
+		return
+	}
+
+	sealed, err := manager.sealSecrets(plugin, config.Config, nil)
+	if err != nil {
+		logger.WithError(err).Errorf("Could not encrypt secret config items of component '%s'", config.Id)
+		return
+	}
+
+	for name, value := range sealed {
+		if string(value) != string(config.Config[name]) {
+			manager.store.SetComponent(&store.ComponentConfig{Id: config.Id, Plugin: config.Plugin, Config: sealed})
+			manager.storeChanged()
+			logger.Infof("Secret config items of component '%s' encrypted, they will be written at the next store save", config.Id)
+			return
+		}
+	}
+}
+
+// Config as kept in the store: secret items encrypted (or kept as references).
+//
+// Previous is the stored config of the component (nil if none), its secret values are kept when they did not change
+func (manager *componentManager) sealSecrets(plugin *metadata.Plugin, config map[string]json.RawMessage, previous map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	return transformSecrets(plugin, config, func(name string, value json.RawMessage) (json.RawMessage, error) {
+		return manager.store.Secrets().Seal(value, previous[name])
+	})
+}
+
+// Config with the plain values of secret items, to configure the component
+func (manager *componentManager) openSecrets(plugin *metadata.Plugin, config map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	return transformSecrets(plugin, config, func(name string, value json.RawMessage) (json.RawMessage, error) {
+		plain, err := manager.store.Secrets().Open(value)
+		if err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(plain)
+	})
+}
+
+// Config to send in RPC responses
+func redactSecrets(plugin *metadata.Plugin, config map[string]json.RawMessage) map[string]json.RawMessage {
+	redacted, _ := transformSecrets(plugin, config, func(name string, value json.RawMessage) (json.RawMessage, error) {
+		return store.RedactSecret(value), nil
+	})
+
+	return redacted
+}
+
+// Note: config is not modified, a copy is returned
+func transformSecrets(plugin *metadata.Plugin, config map[string]json.RawMessage, transform func(name string, value json.RawMessage) (json.RawMessage, error)) (map[string]json.RawMessage, error) {
+	result := maps.Clone(config)
+
+	for _, name := range plugin.ConfigNames() {
+		value, ok := config[name]
+		if !ok || !plugin.Config(name).Secret() {
+			continue
+		}
+
+		transformed, err := transform(name, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for secret item '%s': %w", name, err)
+		}
+
+		result[name] = transformed
+	}
+
+	return result, nil
+}
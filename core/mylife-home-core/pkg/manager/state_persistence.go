@@ -0,0 +1,183 @@
+package manager
+
+import (
+	"encoding/json"
+	"mylife-home-core/pkg/plugins"
+	"mylife-home-core/pkg/store"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/maps"
+)
+
+const stateSaveDelay = 5 * time.Second
+
+// Components data is kept with the states of the component, with this prefix (not valid in state names)
+const dataKeyPrefix = "$"
+
+var _ plugins.DataStore = (*statePersistence)(nil)
+
+// Keep the values of persistent component states in the state file, to restore them at component creation.
+// Also keeps the components data (definitions.Runtime.Data)
+type statePersistence struct {
+	file    *store.StateFile
+	saver   *autoSaver
+	values  store.StateValues
+	watches map[string][]func() // unwatch functions, by component id
+	mux     sync.Mutex
+}
+
+func makeStatePersistence(path string) *statePersistence {
+	persistence := &statePersistence{
+		file:    store.MakeStateFile(path),
+		watches: make(map[string][]func()),
+	}
+
+	persistence.values = persistence.file.Load()
+	persistence.saver = makeAutoSaver("state file", stateSaveDelay, plugins.SystemClock(), persistence.save)
+
+	return persistence
+}
+
+// Save pending changes
+func (persistence *statePersistence) Terminate() {
+	for id := range persistence.watches {
+		persistence.unwatch(id)
+	}
+
+	persistence.saver.Terminate()
+}
+
+// Persisted values of the component
+func (persistence *statePersistence) Get(id string) map[string]json.RawMessage {
+	persistence.mux.Lock()
+	defer persistence.mux.Unlock()
+
+	return maps.Clone(persistence.values[id])
+}
+
+// Record changes of the persistent states of the component
+func (persistence *statePersistence) Watch(comp *plugins.Component) {
+	id := comp.Id()
+	unwatches := make([]func(), 0)
+
+	for _, name := range plugins.GetPlugin(comp.Plugin().Id()).PersistentStates() {
+		name := name
+		observable := comp.StateItem(name)
+		ch := make(chan any)
+
+		go func() {
+			for value := range ch {
+				persistence.set(id, name, value)
+			}
+		}()
+
+		// Note: current value too, Init may have changed the restored one
+		observable.Subscribe(ch, true)
+
+		unwatches = append(unwatches, func() {
+			observable.Unsubscribe(ch)
+			close(ch)
+		})
+	}
+
+	if len(unwatches) > 0 {
+		persistence.watches[id] = unwatches
+	}
+}
+
+// Stop recording changes and forget the values of the component
+func (persistence *statePersistence) Remove(id string) {
+	persistence.unwatch(id)
+
+	persistence.mux.Lock()
+	_, exists := persistence.values[id]
+	delete(persistence.values, id)
+	persistence.mux.Unlock()
+
+	if exists {
+		persistence.saver.Changed()
+	}
+}
+
+// Set back the values of a removed component (as returned by Get), eg: to create it again
+func (persistence *statePersistence) Restore(id string, values map[string]json.RawMessage) {
+	if len(values) == 0 {
+		return
+	}
+
+	persistence.mux.Lock()
+	persistence.values[id] = maps.Clone(values)
+	persistence.mux.Unlock()
+
+	persistence.saver.Changed()
+}
+
+func (persistence *statePersistence) unwatch(id string) {
+	for _, unwatch := range persistence.watches[id] {
+		unwatch()
+	}
+
+	delete(persistence.watches, id)
+}
+
+func (persistence *statePersistence) GetData(id string, key string) (json.RawMessage, bool) {
+	persistence.mux.Lock()
+	defer persistence.mux.Unlock()
+
+	value, ok := persistence.values[id][dataKeyPrefix+key]
+	return value, ok
+}
+
+func (persistence *statePersistence) SetData(id string, key string, value json.RawMessage) {
+	persistence.setRaw(id, dataKeyPrefix+key, value)
+}
+
+func (persistence *statePersistence) DeleteData(id string, key string) {
+	persistence.mux.Lock()
+	_, exists := persistence.values[id][dataKeyPrefix+key]
+	delete(persistence.values[id], dataKeyPrefix+key)
+	persistence.mux.Unlock()
+
+	if exists {
+		persistence.saver.Changed()
+	}
+}
+
+func (persistence *statePersistence) set(id string, name string, value any) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		logger.WithError(err).Errorf("Could not persist state '%s' of component '%s'", name, id)
+		return
+	}
+
+	persistence.setRaw(id, name, raw)
+}
+
+func (persistence *statePersistence) setRaw(id string, name string, raw json.RawMessage) {
+	persistence.mux.Lock()
+	componentValues, exists := persistence.values[id]
+	if !exists {
+		componentValues = make(map[string]json.RawMessage)
+		persistence.values[id] = componentValues
+	}
+
+	changed := string(componentValues[name]) != string(raw)
+	componentValues[name] = raw
+	persistence.mux.Unlock()
+
+	if changed {
+		persistence.saver.Changed()
+	}
+}
+
+func (persistence *statePersistence) save() error {
+	persistence.mux.Lock()
+	values := make(store.StateValues)
+	for id, componentValues := range persistence.values {
+		values[id] = maps.Clone(componentValues)
+	}
+	persistence.mux.Unlock()
+
+	return persistence.file.Save(values)
+}
@@ -0,0 +1,43 @@
+package manager
+
+import (
+	"mylife-home-common/bus"
+	"mylife-home-common/tools"
+)
+
+type storeStatus struct {
+	Dirty    bool `json:"dirty"`    // changes not saved yet
+	AutoSave bool `json:"autoSave"` // changes are saved automatically
+}
+
+const storeMetadataPath = "store"
+
+// Publish the store status as instance metadata, at 'store'
+func makeStoreStatusPublisher(transport *bus.Transport, cm *componentManager) *metadataPublisher {
+	status := func(dirty bool) *storeStatus {
+		return &storeStatus{Dirty: dirty, AutoSave: cm.IsAutoSaveEnabled()}
+	}
+
+	values := func() map[string]any {
+		return map[string]any{"": status(cm.StoreDirty().Get())}
+	}
+
+	mapper := func(dirty bool) *metadataChange {
+		return &metadataChange{value: status(dirty)}
+	}
+
+	return makeMetadataPublisher(transport, storeMetadataPath, values, valueChanges[bool]{cm.StoreDirty()}, mapper)
+}
+
+// Changes of an observable value, the current value is not sent on subscribe
+type valueChanges[T any] struct {
+	value tools.ObservableValue[T]
+}
+
+func (changes valueChanges[T]) Subscribe(observer chan<- T) {
+	changes.value.Subscribe(observer, false)
+}
+
+func (changes valueChanges[T]) Unsubscribe(observer chan<- T) {
+	changes.value.Unsubscribe(observer)
+}
@@ -1,22 +1,48 @@
 package plugins
 
 import (
+	"errors"
+	"fmt"
 	"maps"
 	"mylife-home-common/components"
 	"mylife-home-common/components/metadata"
 	"mylife-home-common/tools"
 	"mylife-home-core-library/definitions"
+	"mylife-home-core-library/registry"
+	"reflect"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
 )
 
 var _ components.Component = (*Component)(nil)
+var _ components.HealthReporter = (*Component)(nil)
 
 type Component struct {
 	// metadata/direct component management
 	id      string
 	plugin  *Plugin
-	target  definitions.Plugin
-	state   map[string]tools.ObservableValue[any]
+	state   map[string]untypedState
 	actions map[string]chan any
+	control chan func()
+	exit    chan struct{} // closed on terminate
+	data    DataStore
+	clock   definitions.Clock          // for the runtime and the action limits
+	dropped map[string]*atomic.Int64   // count of invalid values, by action name
+	fault   tools.SubjectValue[string] // reason, empty if the component is not faulted
+	health  tools.SubjectValue[components.Health]
+
+	// health reported by the plugin instance, overridden by the fault
+	reported  components.Health
+	healthMux sync.Mutex
+
+	// updated on reconfiguration, only accessed by the dispatcher (or before it starts)
+	config   map[string]any
+	target   definitions.Plugin
+	handlers map[string]func(any)
+	limiters map[string]*registry.ActionLimiter // only for limited actions, kept across reconfigurations
+	runtime  *registry.PluginRuntime
+	alive    bool // target initialized (even if Init failed) and not terminated yet
 }
 
 type actionDispatch struct {
@@ -24,31 +50,50 @@ type actionDispatch struct {
 	value any
 }
 
-func newComponent(id string, plugin *Plugin, target definitions.Plugin, actions map[string]func(any), state map[string]tools.ObservableValue[any]) *Component {
-	// for stability since it's kept by dispatcher
-	actions = maps.Clone(actions)
-
+func newComponent(id string, plugin *Plugin, config map[string]any, target definitions.Plugin, handlers map[string]func(any), state map[string]untypedState, data DataStore, clock definitions.Clock) *Component {
 	comp := &Component{
-		id:      id,
-		plugin:  plugin,
-		target:  target,
-		actions: make(map[string]chan any),
-		state:   state,
+		id:       id,
+		plugin:   plugin,
+		state:    state,
+		actions:  make(map[string]chan any),
+		control:  make(chan func()),
+		exit:     make(chan struct{}),
+		data:     data,
+		clock:    clock,
+		dropped:  make(map[string]*atomic.Int64),
+		fault:    tools.MakeSubjectValue(""),
+		health:   tools.MakeSubjectValue(components.Health{Status: components.HealthOk}),
+		reported: components.Health{Status: components.HealthOk},
+		config:   config,
+		target:   target,
+		handlers: maps.Clone(handlers),
+		limiters: make(map[string]*registry.ActionLimiter),
+	}
+
+	for name, action := range plugin.actions {
+		comp.dropped[name] = &atomic.Int64{}
+
+		if action.limit != nil {
+			name := name
+			comp.limiters[name] = registry.MakeActionLimiter(action.limit, comp.clock, comp.postToDispatcher, func(value any) {
+				comp.invokeAction(name, value)
+			})
+		}
 	}
 
 	// make actions dispatch sequentially:
 	// create one channel as unique action receiver, then dispatch
-	ch := comp.initActionMerger(actions)
-	go comp.dispatcher(ch, actions)
+	ch := comp.initActionMerger()
+	go comp.dispatcher(ch)
 
 	return comp
 }
 
-func (comp *Component) initActionMerger(actions map[string]func(any)) <-chan actionDispatch {
+func (comp *Component) initActionMerger() <-chan actionDispatch {
 	dummy := make(chan actionDispatch)
 	merger := tools.MakeChannelMerger(dummy)
 
-	for name := range actions {
+	for name := range comp.handlers {
 		input := make(chan any)
 		comp.actions[name] = input
 
@@ -66,13 +111,137 @@ func (comp *Component) initActionMerger(actions map[string]func(any)) <-chan act
 	return merger.Out()
 }
 
-func (comp *Component) dispatcher(input <-chan actionDispatch, actions map[string]func(any)) {
-	for ad := range input {
-		action := actions[ad.name]
-		action(ad.value)
+func (comp *Component) dispatcher(input <-chan actionDispatch) {
+	for {
+		select {
+		case ad, ok := <-input:
+			if !ok {
+				// Closed right away if the plugin has no action: keep running control functions until terminated
+				input = nil
+				continue
+			}
+
+			if err := comp.plugin.actions[ad.name].validate(ad.value); err != nil {
+				comp.dropped[ad.name].Add(1)
+				logger.WithError(err).Warnf("Dropping value of action '%s' on component '%s'", ad.name, comp.id)
+				continue
+			}
+
+			if limiter, ok := comp.limiters[ad.name]; ok {
+				limiter.Submit(ad.value)
+				continue
+			}
+
+			comp.invokeAction(ad.name, ad.value)
+
+		case fn := <-comp.control:
+			fn()
+
+		case <-comp.exit:
+			return
+		}
+	}
+}
+
+// Note: call on the dispatcher
+func (comp *Component) invokeAction(name string, value any) {
+	if comp.fault.Get() != "" {
+		logger.Debugf("Component '%s' is faulted, dropping action '%s'", comp.id, name)
+		return
+	}
+
+	action := comp.handlers[name]
+	if err := comp.protect("action '"+name+"'", func() error { action(value); return nil }); err != nil {
+		comp.setFaulted(err)
 	}
 }
 
+// Run fn on the dispatcher, between actions
+func (comp *Component) runOnDispatcher(fn func()) {
+	done := make(chan struct{})
+
+	comp.control <- func() {
+		defer close(done)
+		fn()
+	}
+
+	<-done
+}
+
+// Run fn on the dispatcher without waiting for it, dropped if the component is terminated.
+//
+// Used for runtime callbacks: a panic faults the component
+func (comp *Component) postToDispatcher(fn func()) {
+	protected := func() {
+		if err := comp.protect("callback", func() error { fn(); return nil }); err != nil {
+			comp.setFaulted(err)
+		}
+	}
+
+	select {
+	case comp.control <- protected:
+	case <-comp.exit:
+	}
+}
+
+// Run fn, a panic is returned as error
+func (comp *Component) protect(context string, fn func() error) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			logger.Errorf("Panic in %s of component '%s': %v\n%s", context, comp.id, value, debug.Stack())
+			err = fmt.Errorf("panic in %s: %v", context, value)
+		}
+	}()
+
+	return fn()
+}
+
+// Terminate the plugin instance and drop actions until the component is retried or reconfigured.
+//
+// Note: call on the dispatcher (or before it starts)
+func (comp *Component) setFaulted(err error) {
+	logger.WithError(err).Errorf("Component '%s' is faulted", comp.id)
+
+	comp.terminateTarget()
+	comp.updateFault(err.Error())
+}
+
+func (comp *Component) updateFault(reason string) {
+	comp.fault.Update(reason)
+
+	comp.healthMux.Lock()
+	defer comp.healthMux.Unlock()
+	comp.refreshHealth()
+}
+
+// Called by the runtime, from any goroutine
+func (comp *Component) reportHealth(status definitions.HealthStatus, message string) {
+	comp.healthMux.Lock()
+	defer comp.healthMux.Unlock()
+
+	comp.reported = components.Health{Status: components.HealthStatus(status), Message: message}
+	comp.refreshHealth()
+}
+
+// Call inside the health lock
+func (comp *Component) refreshHealth() {
+	if reason := comp.fault.Get(); reason != "" {
+		comp.health.Update(components.Health{Status: components.HealthError, Message: "faulted: " + reason})
+	} else {
+		comp.health.Update(comp.reported)
+	}
+}
+
+// Reason of the fault (empty if the component runs normally)
+func (comp *Component) Fault() tools.ObservableValue[string] {
+	return comp.fault
+}
+
+// Health reported by the plugin instance, error if the component is faulted
+func (comp *Component) Health() tools.ObservableValue[components.Health] {
+	return comp.health
+}
+
 func (comp *Component) Id() string {
 	return comp.id
 }
@@ -82,23 +251,169 @@ func (comp *Component) Plugin() *metadata.Plugin {
 }
 
 func (comp *Component) StateItem(name string) tools.ObservableValue[any] {
-	return comp.state[name]
+	state, ok := comp.state[name]
+	if !ok {
+		return nil
+	}
+
+	return state.Value()
 }
 
 func (comp *Component) Action(name string) chan<- any {
 	return comp.actions[name]
 }
 
+// Count of invalid values dropped, by action name (only actions which dropped values)
+func (comp *Component) DroppedActions() map[string]int64 {
+	counts := make(map[string]int64)
+
+	for name, counter := range comp.dropped {
+		if count := counter.Load(); count > 0 {
+			counts[name] = count
+		}
+	}
+
+	return counts
+}
+
 func (comp *Component) Init() error {
-	rt := makeRuntime(comp.id)
-	return comp.target.Init(rt)
+	// New plugin instance: forget what the previous one reported
+	comp.reportHealth(definitions.HealthOk, "")
+
+	comp.runtime = makeRuntime(comp.id, comp.clock, comp.postToDispatcher, comp.reportHealth, comp.data)
+	comp.alive = true
+
+	return comp.protect("init", func() error {
+		return comp.target.Init(comp.runtime)
+	})
+}
+
+// Terminate the plugin instance, and stop what its runtime still runs
+func (comp *Component) terminateTarget() {
+	if !comp.alive {
+		return
+	}
+
+	comp.alive = false
+
+	if err := comp.protect("terminate", func() error { comp.target.Terminate(); return nil }); err != nil {
+		logger.WithError(err).Errorf("Component '%s' could not be terminated properly", comp.id)
+	}
+
+	comp.runtime.Terminate()
+}
+
+// Apply a new configuration without changing the component seen from outside (id, state, actions).
+//
+// Plugins implementing definitions.Reconfigurable are updated in place,
+// others are recreated, keeping the current state values.
+func (comp *Component) Reconfigure(config map[string]any) error {
+	if err := comp.plugin.validateConfig(config); err != nil {
+		return err
+	}
+
+	var err error
+	comp.runOnDispatcher(func() {
+		err = comp.reconfigure(config)
+	})
+
+	return err
+}
+
+func (comp *Component) reconfigure(config map[string]any) error {
+	if comp.fault.Get() != "" {
+		return comp.retry(config)
+	}
+
+	if reconfigurable, ok := comp.target.(definitions.Reconfigurable); ok {
+		comp.plugin.configure(reflect.ValueOf(comp.target), config)
+
+		err := comp.protect("reconfigure", reconfigurable.Reconfigure)
+		if err == nil {
+			comp.config = config
+			logger.Infof("Component reconfigured: '%s'", comp.id)
+			return nil
+		}
+
+		logger.WithError(err).Warnf("Component '%s' could not be reconfigured, recreating it", comp.id)
+	}
+
+	comp.terminateTarget()
+
+	if err := comp.recreate(config); err != nil {
+		logger.WithError(err).Errorf("Component '%s' could not be recreated with new configuration, restoring previous configuration", comp.id)
+
+		if err := comp.recreate(comp.config); err != nil {
+			logger.WithError(err).Errorf("Component '%s' could not be restored", comp.id)
+			comp.updateFault(err.Error())
+		}
+
+		return err
+	}
+
+	comp.config = config
+	logger.Infof("Component recreated: '%s'", comp.id)
+	logger.Debugf("Configuration applied (component='%s'): %+v", comp.id, comp.plugin.redactConfig(config))
+
+	return nil
+}
+
+func (comp *Component) recreate(config map[string]any) error {
+	comp.target, comp.handlers = comp.plugin.createTarget(comp.state, config)
+
+	if err := comp.Init(); err != nil {
+		comp.terminateTarget()
+		return err
+	}
+
+	return nil
+}
+
+// Recreate a faulted component, with the given configuration
+func (comp *Component) Retry(config map[string]any) error {
+	if err := comp.plugin.validateConfig(config); err != nil {
+		return err
+	}
+
+	var err error
+	comp.runOnDispatcher(func() {
+		err = comp.retry(config)
+	})
+
+	return err
+}
+
+func (comp *Component) retry(config map[string]any) error {
+	if comp.fault.Get() == "" {
+		return errors.New("component is not faulted")
+	}
+
+	if err := comp.recreate(config); err != nil {
+		comp.updateFault(err.Error())
+		return err
+	}
+
+	comp.config = config
+	comp.updateFault("")
+	logger.Infof("Component recovered: '%s'", comp.id)
+
+	return nil
 }
 
 func (comp *Component) Terminate() {
-	comp.target.Terminate()
+	comp.runOnDispatcher(func() {
+		// Pending values are dropped
+		for _, limiter := range comp.limiters {
+			limiter.Stop()
+		}
+
+		comp.terminateTarget()
+	})
 
 	// Do not permit actions after terminate + properly close channels handlers
 	for _, ch := range comp.actions {
 		close(ch)
 	}
+
+	close(comp.exit)
 }
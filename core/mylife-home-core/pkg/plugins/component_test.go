@@ -0,0 +1,231 @@
+package plugins
+
+import (
+	"mylife-home-common/components"
+	"mylife-home-common/components/metadata"
+	"mylife-home-core-library/definitions"
+	"mylife-home-core-library/plugintest"
+	"mylife-home-core-library/registry"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type faultyPlugin struct {
+	FailInit bool
+
+	Value definitions.State[int64]
+}
+
+func (component *faultyPlugin) Init(runtime definitions.Runtime) error {
+	if component.FailInit {
+		panic("init failure")
+	}
+
+	runtime.SetHealth(definitions.HealthDegraded, "warming up")
+	return nil
+}
+
+func (component *faultyPlugin) Terminate() {
+}
+
+func (component *faultyPlugin) SetValue(arg int64) {
+	if arg < 0 {
+		panic("negative value")
+	}
+
+	component.Value.Set(arg)
+}
+
+// Debounced
+func (component *faultyPlugin) SetDebounced(arg int64) {
+	component.Value.Set(arg)
+}
+
+// Rate limited
+func (component *faultyPlugin) SetLimited(arg int64) {
+	component.Value.Set(arg)
+}
+
+func makeFaultyPlugin() *Plugin {
+	builder := registry.MakePluginTypeBuilder[faultyPlugin]("test", "faulty", "", metadata.Logic, "1.0.0")
+	builder.AddState("Value", "value", "", metadata.MakeTypeRange(-10, 10))
+	builder.AddAction("SetValue", "setValue", "", metadata.MakeTypeRange(-10, 10))
+	builder.AddLimitedAction("SetDebounced", "setDebounced", "", metadata.MakeTypeRange(-10, 10), registry.MakeActionLimit().SetDebounce(100*time.Millisecond))
+	builder.AddLimitedAction("SetLimited", "setLimited", "", metadata.MakeTypeRange(-10, 10), registry.MakeActionLimit().SetRateLimit(time.Second))
+	builder.AddConfig("FailInit", "failInit", "", metadata.Bool)
+	return buildPlugin(builder.Build())
+}
+
+func TestComponentActionPanic(t *testing.T) {
+	plugin := makeFaultyPlugin()
+	comp, err := plugin.Instantiate("comp", map[string]any{"failInit": false}, nil, nil)
+	assert.NoError(t, err)
+	defer comp.Terminate()
+
+	comp.Action("setValue") <- int64(5)
+	comp.Action("setValue") <- int64(-1)
+	comp.Action("setValue") <- int64(7) // dropped
+
+	assert.Eventually(t, func() bool { return comp.Fault().Get() != "" }, time.Second, 10*time.Millisecond)
+	assert.Contains(t, comp.Fault().Get(), "negative value")
+	assert.Equal(t, int64(5), comp.StateItem("value").Get())
+
+	assert.NoError(t, comp.Retry(map[string]any{"failInit": false}))
+	assert.Equal(t, "", comp.Fault().Get())
+
+	comp.Action("setValue") <- int64(7)
+	assert.Eventually(t, func() bool { return comp.StateItem("value").Get() == int64(7) }, time.Second, 10*time.Millisecond)
+
+	assert.Error(t, comp.Retry(map[string]any{"failInit": false}), "not faulted")
+}
+
+func TestComponentInitPanic(t *testing.T) {
+	plugin := makeFaultyPlugin()
+	comp, err := plugin.Instantiate("comp", map[string]any{"failInit": true}, nil, nil)
+	assert.Error(t, err)
+	assert.NotNil(t, comp)
+	defer comp.Terminate()
+
+	assert.Contains(t, comp.Fault().Get(), "init failure")
+
+	assert.Error(t, comp.Retry(map[string]any{"failInit": true}))
+	assert.NotEqual(t, "", comp.Fault().Get())
+
+	assert.NoError(t, comp.Reconfigure(map[string]any{"failInit": false}))
+	assert.Equal(t, "", comp.Fault().Get())
+}
+
+func TestComponentInvalidActionValues(t *testing.T) {
+	plugin := makeFaultyPlugin()
+	comp, err := plugin.Instantiate("comp", map[string]any{"failInit": false}, nil, nil)
+	assert.NoError(t, err)
+	defer comp.Terminate()
+
+	comp.Action("setValue") <- int64(50)
+	comp.Action("setValue") <- "5"
+	comp.Action("setValue") <- nil
+	comp.Action("setValue") <- int64(3)
+
+	assert.Eventually(t, func() bool { return comp.StateItem("value").Get() == int64(3) }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, map[string]int64{"setValue": 3}, comp.DroppedActions())
+	assert.Equal(t, "", comp.Fault().Get())
+}
+
+func TestComponentHealth(t *testing.T) {
+	plugin := makeFaultyPlugin()
+	comp, err := plugin.Instantiate("comp", map[string]any{"failInit": false}, nil, nil)
+	assert.NoError(t, err)
+	defer comp.Terminate()
+
+	assert.Equal(t, components.Health{Status: components.HealthDegraded, Message: "warming up"}, comp.Health().Get())
+
+	comp.Action("setValue") <- int64(-1)
+	assert.Eventually(t, func() bool { return comp.Health().Get().Status == components.HealthError }, time.Second, 10*time.Millisecond)
+	assert.Contains(t, comp.Health().Get().Message, "negative value")
+
+	assert.NoError(t, comp.Retry(map[string]any{"failInit": false}))
+	assert.Equal(t, components.Health{Status: components.HealthDegraded, Message: "warming up"}, comp.Health().Get())
+
+	comp.runtime.SetHealth(definitions.HealthOk, "")
+	assert.Equal(t, components.Health{Status: components.HealthOk}, comp.Health().Get())
+
+	assert.Panics(t, func() { comp.runtime.SetHealth("unknown", "") })
+}
+
+// Send an invalid value and wait for it to be dropped: the values sent before on the action have been processed
+func syncAction(t *testing.T, comp *Component, name string) {
+	dropped := comp.DroppedActions()[name]
+	comp.Action(name) <- nil
+	assert.Eventually(t, func() bool { return comp.DroppedActions()[name] == dropped+1 }, time.Second, time.Millisecond)
+}
+
+func TestComponentActionDebounce(t *testing.T) {
+	clock := plugintest.NewClock(plugintest.Epoch)
+	comp, err := makeFaultyPlugin().instantiate("comp", map[string]any{"failInit": false}, nil, nil, clock)
+	assert.NoError(t, err)
+
+	comp.Action("setDebounced") <- int64(1)
+	syncAction(t, comp, "setDebounced")
+	clock.Advance(50 * time.Millisecond)
+	comp.Action("setDebounced") <- int64(2)
+	syncAction(t, comp, "setDebounced")
+
+	clock.Advance(50 * time.Millisecond)
+	comp.runOnDispatcher(func() {})
+	assert.Equal(t, int64(0), comp.StateItem("value").Get())
+
+	clock.Advance(50 * time.Millisecond)
+	assert.Eventually(t, func() bool { return comp.StateItem("value").Get() == int64(2) }, time.Second, time.Millisecond)
+	assert.Equal(t, 0, clock.Pending())
+
+	// pending value dropped
+	comp.Action("setDebounced") <- int64(3)
+	syncAction(t, comp, "setDebounced")
+	comp.Terminate()
+	clock.Advance(time.Second)
+	assert.Equal(t, int64(2), comp.StateItem("value").Get())
+	assert.Equal(t, 0, clock.Pending())
+}
+
+func TestComponentActionRateLimit(t *testing.T) {
+	clock := plugintest.NewClock(plugintest.Epoch)
+	comp, err := makeFaultyPlugin().instantiate("comp", map[string]any{"failInit": false}, nil, nil, clock)
+	assert.NoError(t, err)
+	defer comp.Terminate()
+
+	comp.Action("setLimited") <- int64(1)
+	syncAction(t, comp, "setLimited")
+	assert.Equal(t, int64(1), comp.StateItem("value").Get())
+
+	clock.Advance(200 * time.Millisecond)
+	comp.Action("setLimited") <- int64(2)
+	comp.Action("setLimited") <- int64(3)
+	syncAction(t, comp, "setLimited")
+	assert.Equal(t, int64(1), comp.StateItem("value").Get())
+	assert.Equal(t, 1, clock.Pending())
+
+	clock.Advance(800 * time.Millisecond)
+	assert.Eventually(t, func() bool { return comp.StateItem("value").Get() == int64(3) }, time.Second, time.Millisecond)
+
+	clock.Advance(2 * time.Second)
+	comp.Action("setLimited") <- int64(4)
+	syncAction(t, comp, "setLimited")
+	assert.Equal(t, int64(4), comp.StateItem("value").Get())
+	assert.Equal(t, 0, clock.Pending())
+}
+
+type structuredPlugin struct {
+	Record definitions.State[map[string]any]
+	Array  definitions.State[[]any]
+}
+
+func (component *structuredPlugin) Init(runtime definitions.Runtime) error {
+	return nil
+}
+
+func (component *structuredPlugin) Terminate() {
+}
+
+func TestComponentStructuredStateDefaults(t *testing.T) {
+	recordType := metadata.MakeTypeRecord(metadata.MakeRecordField("name", metadata.MakeTypeText()), metadata.MakeRecordField("items", metadata.MakeTypeArray(metadata.MakeTypeBool())))
+	arrayType := metadata.MakeTypeArray(metadata.MakeTypeBool())
+
+	builder := registry.MakePluginTypeBuilder[structuredPlugin]("test", "structured", "", metadata.Logic, "1.0.0")
+	builder.AddState("Record", "record", "", recordType)
+	builder.AddState("Array", "array", "", arrayType)
+	plugin := buildPlugin(builder.Build())
+
+	comp, err := plugin.Instantiate("comp", map[string]any{}, nil, nil)
+	assert.NoError(t, err)
+	defer comp.Terminate()
+
+	record := comp.StateItem("record").Get()
+	assert.Equal(t, map[string]any{"name": "", "items": []any{}}, record)
+	assert.True(t, recordType.Validate(record))
+
+	array := comp.StateItem("array").Get()
+	assert.Equal(t, []any{}, array)
+	assert.True(t, arrayType.Validate(array))
+}
@@ -0,0 +1,54 @@
+package plugins
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// Invalid items of a component configuration, with the reason for each of them.
+//
+// Sent as RPC error details: '{"configErrors": {"<item>": "<message>"}}'
+type ConfigError struct {
+	items map[string]string
+}
+
+func newConfigError() *ConfigError {
+	return &ConfigError{items: make(map[string]string)}
+}
+
+func (err *ConfigError) add(item string, message string) {
+	err.items[item] = message
+}
+
+// Nil if no item is invalid
+func (err *ConfigError) orNil() error {
+	if len(err.items) == 0 {
+		return nil
+	}
+
+	return err
+}
+
+// Message by item name
+func (err *ConfigError) Items() map[string]string {
+	return maps.Clone(err.items)
+}
+
+func (err *ConfigError) Error() string {
+	names := maps.Keys(err.items)
+	slices.Sort(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("item '%s': %s", name, err.items[name]))
+	}
+
+	return "invalid configuration: " + strings.Join(parts, ", ")
+}
+
+func (err *ConfigError) RpcErrorDetails() any {
+	return map[string]any{"configErrors": err.items}
+}
@@ -1,13 +1,18 @@
 package plugins
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"mylife-home-common/bus"
 	"mylife-home-common/components/metadata"
 	"mylife-home-common/log"
-	"mylife-home-common/tools"
 	"mylife-home-core-library/definitions"
 	"mylife-home-core-library/registry"
 	"reflect"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
 var logger = log.CreateLogger("mylife:home:core:plugins")
@@ -56,102 +61,245 @@ func (plugin *Plugin) Metadata() *metadata.Plugin {
 	return plugin.meta
 }
 
-func (plugin *Plugin) Instantiate(id string, config map[string]any) (*Component, error) {
+// Persisted holds the last values of persistent states (JSON encoded), restored before Init.
+//
+// Data keeps the component data (definitions.Runtime.Data), in memory if nil.
+//
+// If Init fails (error or panic), the component is returned faulted along with the error:
+// it can be kept and retried, or terminated.
+func (plugin *Plugin) Instantiate(id string, config map[string]any, persisted map[string]json.RawMessage, data DataStore) (*Component, error) {
+	return plugin.instantiate(id, config, persisted, data, &systemClock{})
+}
+
+// Clock drives the component runtime and its action limits (virtual clock in tests)
+func (plugin *Plugin) instantiate(id string, config map[string]any, persisted map[string]json.RawMessage, data DataStore, clock definitions.Clock) (*Component, error) {
 	if err := plugin.validateConfig(config); err != nil {
 		return nil, err
 	}
 
-	// Create instance
+	// State is owned by the component, so that it survives reconfiguration
+	state := make(map[string]untypedState)
+	for name, stateItem := range plugin.state {
+		state[name] = stateItem.makeImpl()
+
+		if raw, ok := persisted[name]; ok && stateItem.persistent {
+			stateItem.restore(id, state[name], raw)
+		}
+	}
+
+	target, actions := plugin.createTarget(state, config)
+
+	if data == nil {
+		data = makeMemoryDataStore()
+	}
+
+	comp := newComponent(id, plugin, config, target, actions, state, data, clock)
+
+	logger.Infof("Component created: '%s'", comp.id)
+	logger.Debugf("Configuration applied (component='%s'): %+v", comp.id, plugin.redactConfig(config))
+
+	// Initialize the component, serialized with the runtime callbacks it may start
+	var err error
+	comp.runOnDispatcher(func() {
+		if err = comp.Init(); err != nil {
+			comp.setFaulted(err)
+		}
+	})
+
+	if err != nil {
+		return comp, err
+	}
+
+	return comp, nil
+}
+
+// Names of the states whose values are persisted
+func (plugin *Plugin) PersistentStates() []string {
+	names := make([]string, 0)
+
+	for name, stateItem := range plugin.state {
+		if stateItem.persistent {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+// Create the plugin instance, bound to the given state
+func (plugin *Plugin) createTarget(state map[string]untypedState, config map[string]any) (definitions.Plugin, map[string]func(any)) {
 	compPtr := reflect.New(plugin.target)
 
-	// Prepare it
 	actions := make(map[string]func(any))
-	state := make(map[string]tools.ObservableValue[any])
 
 	for name, action := range plugin.actions {
 		actions[name] = action.init(compPtr)
 	}
 
 	for name, stateItem := range plugin.state {
-		state[name] = stateItem.init(compPtr)
+		stateItem.attach(compPtr, state[name])
 	}
 
+	plugin.configure(compPtr, config)
+
+	return compPtr.Interface().(definitions.Plugin), actions
+}
+
+// Note: config must have been validated
+func (plugin *Plugin) configure(compPtr reflect.Value, config map[string]any) {
 	for name, configItem := range plugin.config {
-		// Note: already checked in validateConfig
-		value := config[name]
-		configItem.configure(compPtr, value)
+		configItem.configure(compPtr, config[name])
 	}
+}
 
-	target := compPtr.Interface().(definitions.Plugin)
+// Build the configuration from its JSON values: omitted optional items get their default value, then all items are validated.
+//
+// Errors are reported for each invalid item (see ConfigError)
+func (plugin *Plugin) ReadConfig(raw map[string]json.RawMessage) (map[string]any, error) {
+	config := make(map[string]any)
+	configErr := newConfigError()
 
-	comp := newComponent(id, plugin, target, actions, state)
+	for name, item := range plugin.config {
+		value, err := item.read(raw)
+		if err == nil {
+			err = item.meta.Validate(value)
+		}
 
-	logger.Infof("Component created: '%s'", comp.id)
-	logger.Debugf("Configuration applied (component='%s'): %+v", comp.id, config)
+		if err != nil {
+			message := err.Error()
+			if item.meta.Secret() {
+				message = "invalid value" // do not show it
+			}
+
+			configErr.add(name, message)
+			continue
+		}
+
+		config[name] = value
+	}
 
-	// Initialize the component
-	if err := comp.Init(); err != nil {
-		comp.Terminate() // need to do this to exit the component loop actually
+	if err := configErr.orNil(); err != nil {
 		return nil, err
 	}
 
-	return comp, nil
+	return config, nil
+}
+
+// Config to log, with secret items hidden
+func (plugin *Plugin) redactConfig(config map[string]any) map[string]any {
+	redacted := maps.Clone(config)
+
+	for name, item := range plugin.config {
+		if _, ok := redacted[name]; ok && item.meta.Secret() {
+			redacted[name] = "******"
+		}
+	}
+
+	return redacted
 }
 
 func (plugin *Plugin) validateConfig(config map[string]any) error {
+	configErr := newConfigError()
+
 	for name, item := range plugin.config {
 		value, ok := config[name]
 		if !ok {
-			return fmt.Errorf("missing value for configuration '%s'", name)
+			configErr.add(name, "missing value")
+			continue
 		}
 
-		if err := item.validate(value); err != nil {
-			return err
+		if err := item.meta.Validate(value); err != nil {
+			configErr.add(name, err.Error())
 		}
 	}
 
-	return nil
+	return configErr.orNil()
 }
 
 type pluginStateItem struct {
-	target *reflect.StructField
-	meta   *metadata.Member
+	target     *reflect.StructField
+	meta       *metadata.Member
+	persistent bool
 }
 
 func makeStateItem(stateType *registry.StateType) *pluginStateItem {
 	return &pluginStateItem{
-		target: stateType.Target(),
-		meta:   stateType.Metadata(),
+		target:     stateType.Target(),
+		meta:       stateType.Metadata(),
+		persistent: stateType.Persistent(),
 	}
 }
 
-func (s *pluginStateItem) init(compPtr reflect.Value) tools.ObservableValue[any] {
-	impl := makeStateImpl(s.meta.ValueType())
+func (s *pluginStateItem) makeImpl() untypedState {
+	return makeStateImpl(s.meta.ValueType())
+}
+
+// Note: values which do not match the type anymore (eg: plugin updated) are ignored
+func (s *pluginStateItem) restore(id string, impl untypedState, raw json.RawMessage) {
+	value, err := bus.Encoding.ReadJsonValue(s.meta.ValueType(), raw)
+	if err != nil {
+		logger.WithError(err).Warnf("Could not restore state '%s' of component '%s', ignored", s.meta.Name(), id)
+		return
+	}
+
+	impl.restore(value)
+	logger.Debugf("State '%s' of component '%s' restored: %v", s.meta.Name(), id, value)
+}
 
+func (s *pluginStateItem) attach(compPtr reflect.Value, impl untypedState) {
 	target := compPtr.Elem()
 	target.FieldByName(s.target.Name).Set(reflect.ValueOf(impl))
-
-	return impl.Value()
 }
 
 type pluginAction struct {
 	target *reflect.Method
 	meta   *metadata.Member
+	limit  *registry.ActionLimit // nil if not limited
 }
 
 func makeAction(actionType *registry.ActionType) *pluginAction {
 	return &pluginAction{
 		target: actionType.Target(),
 		meta:   actionType.Metadata(),
+		limit:  actionType.Limit(),
 	}
 }
 
+// Values come from the bus or bindings: check them before calling the plugin
+func (a *pluginAction) validate(value any) error {
+	typ := a.meta.ValueType()
+	if !typ.Validate(value) {
+		return fmt.Errorf("invalid value %+v (expected type '%s')", value, typ.String())
+	}
+
+	return nil
+}
+
 func (a *pluginAction) init(compPtr reflect.Value) func(any) {
 	fn := a.target.Func
+	argType := fn.Type().In(1)
 
 	return func(arg any) {
-		fn.Call([]reflect.Value{compPtr, reflect.ValueOf(arg)})
+		fn.Call([]reflect.Value{compPtr, makeActionArg(argType, arg)})
+	}
+}
+
+// Nullable values are nil or the inner value, and the action takes a pointer
+func makeActionArg(argType reflect.Type, arg any) reflect.Value {
+	if arg == nil {
+		return reflect.Zero(argType)
+	}
+
+	value := reflect.ValueOf(arg)
+
+	if argType.Kind() == reflect.Pointer && value.Type() == argType.Elem() {
+		ptr := reflect.New(argType.Elem())
+		ptr.Elem().Set(value)
+		return ptr
 	}
+
+	return value
 }
 
 type pluginConfigItem struct {
@@ -171,10 +319,15 @@ func (c *pluginConfigItem) configure(compPtr reflect.Value, value any) {
 	comp.FieldByName(c.target.Name).Set(reflect.ValueOf(value))
 }
 
-func (c *pluginConfigItem) validate(value any) error {
-	if !c.meta.ValueType().Validate(value) {
-		return fmt.Errorf("invalid value '%v' for configuration '%s'", value, c.meta.Name())
+func (c *pluginConfigItem) read(raw map[string]json.RawMessage) (any, error) {
+	value, ok := raw[c.meta.Name()]
+	if !ok {
+		if !c.meta.Optional() {
+			return nil, errors.New("missing value")
+		}
+
+		return c.meta.Default(), nil
 	}
 
-	return nil
+	return c.meta.ValueType().Read(value)
 }
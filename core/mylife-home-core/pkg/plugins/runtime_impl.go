@@ -1,21 +1,115 @@
 package plugins
 
 import (
+	"encoding/json"
+	"mylife-home-common/log"
 	"mylife-home-core-library/definitions"
+	"mylife-home-core-library/registry"
+	"sync"
+	"time"
 )
 
-var _ definitions.Runtime = (*runtimeImpl)(nil)
+var _ definitions.Clock = (*systemClock)(nil)
+var _ definitions.Data = (*runtimeData)(nil)
 
-type runtimeImpl struct {
-	id string
+// Storage of the components data (definitions.Runtime.Data), by component id
+type DataStore interface {
+	GetData(id string, key string) (json.RawMessage, bool)
+	SetData(id string, key string, value json.RawMessage)
+	DeleteData(id string, key string)
 }
 
-func (rt *runtimeImpl) ComponentId() string {
-	return rt.id
+// Runtime of one plugin instance, terminated with it
+func makeRuntime(componentId string, clock definitions.Clock, execute func(fn func()), health func(status definitions.HealthStatus, message string), dataStore DataStore) *registry.PluginRuntime {
+	logger := log.CreateLogger("mylife:home:core:components:" + componentId)
+	data := &runtimeData{id: componentId, store: dataStore}
+
+	return registry.MakePluginRuntime(componentId, logger, clock, execute, health, data)
+}
+
+// Time source of the core
+func SystemClock() definitions.Clock {
+	return &systemClock{}
+}
+
+type systemClock struct{}
+
+func (clock *systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (clock *systemClock) AfterFunc(delay time.Duration, callback func()) definitions.Timer {
+	return time.AfterFunc(delay, callback)
+}
+
+type runtimeData struct {
+	id    string
+	store DataStore
 }
 
-func makeRuntime(componentId string) definitions.Runtime {
-	return &runtimeImpl{
-		id: componentId,
+func (data *runtimeData) Get(key string, value any) (bool, error) {
+	raw, ok := data.store.GetData(data.id, key)
+	if !ok {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(raw, value); err != nil {
+		return false, err
 	}
+
+	return true, nil
+}
+
+func (data *runtimeData) Set(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	data.store.SetData(data.id, key, raw)
+	return nil
+}
+
+func (data *runtimeData) Delete(key string) {
+	data.store.DeleteData(data.id, key)
+}
+
+// Data of the components kept in memory, when there is no state file
+type memoryDataStore struct {
+	values map[string]map[string]json.RawMessage
+	mux    sync.Mutex
+}
+
+func makeMemoryDataStore() DataStore {
+	return &memoryDataStore{
+		values: make(map[string]map[string]json.RawMessage),
+	}
+}
+
+func (store *memoryDataStore) GetData(id string, key string) (json.RawMessage, bool) {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	value, ok := store.values[id][key]
+	return value, ok
+}
+
+func (store *memoryDataStore) SetData(id string, key string, value json.RawMessage) {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	componentValues, exists := store.values[id]
+	if !exists {
+		componentValues = make(map[string]json.RawMessage)
+		store.values[id] = componentValues
+	}
+
+	componentValues[key] = value
+}
+
+func (store *memoryDataStore) DeleteData(id string, key string) {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	delete(store.values[id], key)
 }
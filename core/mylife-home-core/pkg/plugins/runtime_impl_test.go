@@ -0,0 +1,70 @@
+package plugins
+
+import (
+	"mylife-home-core-library/definitions"
+	"mylife-home-core-library/registry"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeTestRuntime() *registry.PluginRuntime {
+	return makeRuntime("test", &systemClock{}, func(fn func()) { fn() }, func(definitions.HealthStatus, string) {}, makeMemoryDataStore())
+}
+
+func TestRuntimeTimers(t *testing.T) {
+	rt := makeTestRuntime()
+
+	var fired atomic.Int32
+	var ticks atomic.Int32
+	rt.AfterFunc(20*time.Millisecond, func() { fired.Add(1) })
+	rt.TickFunc(20*time.Millisecond, func() { ticks.Add(1) })
+	stopped := rt.AfterFunc(20*time.Millisecond, func() { fired.Add(10) })
+
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop())
+
+	time.Sleep(110 * time.Millisecond)
+	assert.Equal(t, int32(1), fired.Load())
+	assert.GreaterOrEqual(t, ticks.Load(), int32(3))
+
+	rt.Terminate()
+	assert.Error(t, rt.Context().Err())
+
+	count := ticks.Load()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, count, ticks.Load())
+
+	// Timers created after termination never fire
+	rt.AfterFunc(0, func() { fired.Add(1) })
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(1), fired.Load())
+}
+
+func TestRuntimeData(t *testing.T) {
+	rt := makeTestRuntime()
+	data := rt.Data()
+
+	var value map[string]int
+	found, err := data.Get("key", &value)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	assert.NoError(t, data.Set("key", map[string]int{"count": 42}))
+
+	found, err = data.Get("key", &value)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, map[string]int{"count": 42}, value)
+
+	var wrongType string
+	_, err = data.Get("key", &wrongType)
+	assert.Error(t, err)
+
+	data.Delete("key")
+	found, err = data.Get("key", &value)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
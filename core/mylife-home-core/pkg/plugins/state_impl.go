@@ -9,13 +9,16 @@ import (
 
 type untypedState interface {
 	Value() tools.ObservableValue[any]
+	restore(value any)
 }
 
 var _ definitions.State[int64] = (*stateImpl[int64])(nil)
 var _ untypedState = (*stateImpl[int64])(nil)
 
-type stateImpl[T comparable] struct {
-	value tools.SubjectValue[any]
+// Note: structured values (maps, slices) must not be modified after Set
+type stateImpl[T any] struct {
+	value    tools.SubjectValue[any]
+	restored bool
 }
 
 func (state *stateImpl[T]) Get() T {
@@ -30,21 +33,76 @@ func (state *stateImpl[T]) Value() tools.ObservableValue[any] {
 	return state.value
 }
 
-func (state *stateImpl[T]) init() {
+// Note: value must be valid for the state type, as read by the bus encoding
+func (state *stateImpl[T]) restore(value any) {
+	state.value.Update(value)
+	state.restored = true
+}
+
+func (state *stateImpl[T]) Restored() bool {
+	return state.restored
+}
+
+func (state *stateImpl[T]) init(typ metadata.Type) {
 	// Note: the default value may be invalid but we should change it at init,
 	// before anything should start to observe
-	var defaultValue T
-	state.value = tools.MakeSubjectValue[any](defaultValue)
+	state.value = tools.MakeSubjectValue[any](metadata.ZeroValue(typ))
+}
+
+var _ definitions.State[*int64] = (*nullableStateImpl[int64])(nil)
+var _ untypedState = (*nullableStateImpl[int64])(nil)
+
+// Plugin side, the value is a pointer (nil for no value).
+// Observable side, the value is nil or the inner value, so that unchanged values are not notified.
+type nullableStateImpl[T comparable] struct {
+	value    tools.SubjectValue[any]
+	restored bool
+}
+
+func (state *nullableStateImpl[T]) Get() *T {
+	value := state.value.Get()
+	if value == nil {
+		return nil
+	}
+
+	typedValue := value.(T)
+	return &typedValue
+}
+
+func (state *nullableStateImpl[T]) Set(value *T) {
+	if value == nil {
+		state.value.Update(nil)
+	} else {
+		state.value.Update(*value)
+	}
+}
+
+func (state *nullableStateImpl[T]) Value() tools.ObservableValue[any] {
+	return state.value
+}
+
+// Note: value must be valid for the state type, as read by the bus encoding
+func (state *nullableStateImpl[T]) restore(value any) {
+	state.value.Update(value)
+	state.restored = true
+}
+
+func (state *nullableStateImpl[T]) Restored() bool {
+	return state.restored
+}
+
+func (state *nullableStateImpl[T]) init(typ metadata.Type) {
+	state.value = tools.MakeSubjectValue[any](nil)
 }
 
 type privateState interface {
 	untypedState
-	init()
+	init(typ metadata.Type)
 }
 
 func makeStateImpl(typ metadata.Type) untypedState {
 	var state privateState
-	switch typ.(type) {
+	switch typ := typ.(type) {
 	case *metadata.RangeType:
 		state = &stateImpl[int64]{}
 	case *metadata.TextType:
@@ -57,11 +115,34 @@ func makeStateImpl(typ metadata.Type) untypedState {
 		state = &stateImpl[string]{}
 	case *metadata.ComplexType:
 		state = &stateImpl[any]{}
+	case *metadata.NullableType:
+		state = makeNullableStateImpl(typ.Inner())
+	case *metadata.RecordType:
+		state = &stateImpl[map[string]any]{}
+	case *metadata.ArrayType:
+		state = &stateImpl[[]any]{}
 	default:
 		panic(fmt.Sprintf("Unexpected type '%s'", typ.String()))
 	}
 
-	state.init()
+	state.init(typ)
 
 	return state
 }
+
+func makeNullableStateImpl(inner metadata.Type) privateState {
+	switch inner.(type) {
+	case *metadata.RangeType:
+		return &nullableStateImpl[int64]{}
+	case *metadata.TextType:
+		return &nullableStateImpl[string]{}
+	case *metadata.FloatType:
+		return &nullableStateImpl[float64]{}
+	case *metadata.BoolType:
+		return &nullableStateImpl[bool]{}
+	case *metadata.EnumType:
+		return &nullableStateImpl[string]{}
+	default:
+		panic(fmt.Sprintf("Unexpected nullable type '%s'", inner.String()))
+	}
+}
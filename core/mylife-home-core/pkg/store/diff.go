@@ -0,0 +1,110 @@
+package store
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// Changes between two sets of components and bindings, as sorted component ids and binding keys
+type Diff struct {
+	ComponentsRemoved []string `json:"componentsRemoved"`
+	ComponentsUpdated []string `json:"componentsUpdated"`
+	ComponentsAdded   []string `json:"componentsAdded"`
+	BindingsRemoved   []string `json:"bindingsRemoved"`
+	BindingsUpdated   []string `json:"bindingsUpdated"`
+	BindingsAdded     []string `json:"bindingsAdded"`
+}
+
+// Components indexed by id, bindings indexed by key
+func MakeDiff(fromComponents map[string]*ComponentConfig, fromBindings map[string]*BindingConfig, toComponents map[string]*ComponentConfig, toBindings map[string]*BindingConfig) *Diff {
+	diff := &Diff{}
+	diff.ComponentsRemoved, diff.ComponentsUpdated, diff.ComponentsAdded = diffItems(fromComponents, toComponents, componentConfigEquals)
+	diff.BindingsRemoved, diff.BindingsUpdated, diff.BindingsAdded = diffItems(fromBindings, toBindings, bindingConfigEquals)
+	return diff
+}
+
+func (diff *Diff) IsEmpty() bool {
+	return len(diff.ComponentsRemoved)+len(diff.ComponentsUpdated)+len(diff.ComponentsAdded)+len(diff.BindingsRemoved)+len(diff.BindingsUpdated)+len(diff.BindingsAdded) == 0
+}
+
+func BuildBindingKey(config *BindingConfig) string {
+	return strings.Join([]string{config.SourceComponent, config.SourceState, config.TargetComponent, config.TargetAction}, ":")
+}
+
+// Sorted lists of removed, updated and added keys
+func diffItems[T any](current map[string]T, desired map[string]T, equals func(a T, b T) bool) (removed []string, updated []string, added []string) {
+	removed = make([]string, 0)
+	updated = make([]string, 0)
+	added = make([]string, 0)
+
+	for key, currentItem := range current {
+		desiredItem, exists := desired[key]
+
+		switch {
+		case !exists:
+			removed = append(removed, key)
+		case !equals(currentItem, desiredItem):
+			updated = append(updated, key)
+		}
+	}
+
+	for key := range desired {
+		if _, exists := current[key]; !exists {
+			added = append(added, key)
+		}
+	}
+
+	slices.Sort(removed)
+	slices.Sort(updated)
+	slices.Sort(added)
+
+	return removed, updated, added
+}
+
+func componentConfigEquals(a *ComponentConfig, b *ComponentConfig) bool {
+	if a.Plugin != b.Plugin {
+		return false
+	}
+
+	if !slices.Equal(sortedKeys(a.Config), sortedKeys(b.Config)) {
+		return false
+	}
+
+	// Note: compare decoded values, raw JSON may differ by formatting
+	for name, rawA := range a.Config {
+		var valueA, valueB any
+
+		if err := json.Unmarshal(rawA, &valueA); err != nil {
+			return false
+		}
+
+		if err := json.Unmarshal(b.Config[name], &valueB); err != nil {
+			return false
+		}
+
+		if !reflect.DeepEqual(valueA, valueB) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func bindingConfigEquals(a *BindingConfig, b *BindingConfig) bool {
+	// Note: the key fields are already equal
+	if len(a.Transforms) == 0 && len(b.Transforms) == 0 {
+		return true
+	}
+
+	return reflect.DeepEqual(a.Transforms, b.Transforms)
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := maps.Keys(m)
+	slices.Sort(keys)
+	return keys
+}
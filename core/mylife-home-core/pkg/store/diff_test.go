@@ -0,0 +1,38 @@
+package store
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffItems(t *testing.T) {
+	current := map[string]*ComponentConfig{
+		"kept":    {Id: "kept", Plugin: "p", Config: map[string]json.RawMessage{"a": json.RawMessage(`{"x": 1, "y": 2}`)}},
+		"changed": {Id: "changed", Plugin: "p", Config: map[string]json.RawMessage{"a": json.RawMessage(`1`)}},
+		"removed": {Id: "removed", Plugin: "p", Config: map[string]json.RawMessage{}},
+	}
+
+	desired := map[string]*ComponentConfig{
+		"kept":    {Id: "kept", Plugin: "p", Config: map[string]json.RawMessage{"a": json.RawMessage(`{"y":2,"x":1}`)}},
+		"changed": {Id: "changed", Plugin: "p", Config: map[string]json.RawMessage{"a": json.RawMessage(`2`)}},
+		"added":   {Id: "added", Plugin: "p", Config: map[string]json.RawMessage{}},
+	}
+
+	removed, updated, added := diffItems(current, desired, componentConfigEquals)
+
+	assert.Equal(t, []string{"removed"}, removed)
+	assert.Equal(t, []string{"changed"}, updated)
+	assert.Equal(t, []string{"added"}, added)
+}
+
+func TestBindingConfigEquals(t *testing.T) {
+	plain := &BindingConfig{SourceComponent: "a", SourceState: "s", TargetComponent: "b", TargetAction: "a"}
+	empty := &BindingConfig{SourceComponent: "a", SourceState: "s", TargetComponent: "b", TargetAction: "a", Transforms: []*BindingTransformConfig{}}
+	inverted := &BindingConfig{SourceComponent: "a", SourceState: "s", TargetComponent: "b", TargetAction: "a", Transforms: []*BindingTransformConfig{{Type: TransformInvert}}}
+
+	assert.True(t, bindingConfigEquals(plain, empty))
+	assert.False(t, bindingConfigEquals(plain, inverted))
+	assert.True(t, bindingConfigEquals(inverted, inverted))
+}
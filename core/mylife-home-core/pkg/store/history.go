@@ -0,0 +1,199 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+const defaultHistorySize = 10
+
+// Note: ids sort chronologically, and are used as file names
+const snapshotIdLayout = "20060102-150405.000000"
+
+type SnapshotInfo struct {
+	Id   string    `json:"id"`
+	Time time.Time `json:"time"`
+	Diff *Diff     `json:"diff,omitempty"` // changes from the previous snapshot, nil for the oldest one
+}
+
+// Content of a snapshot, components indexed by id, bindings indexed by key
+type snapshotContent struct {
+	components map[string]*ComponentConfig
+	bindings   map[string]*BindingConfig
+}
+
+func indexItems(items []storeItem) (*snapshotContent, error) {
+	content := &snapshotContent{
+		components: make(map[string]*ComponentConfig),
+		bindings:   make(map[string]*BindingConfig),
+	}
+
+	for _, item := range items {
+		switch item.Type {
+		case storeItemTypeComponent:
+			config := item.Config.(*ComponentConfig)
+			content.components[config.Id] = config
+
+		case storeItemTypeBinding:
+			config := item.Config.(*BindingConfig)
+			content.bindings[BuildBindingKey(config)] = config
+
+		default:
+			return nil, fmt.Errorf("unsupported type: '%s'", item.Type)
+		}
+	}
+
+	return content, nil
+}
+
+// Keep a copy of saved data, unless it did not change since the last snapshot
+func (store *Store) snapshot(data []byte) error {
+	ids, err := store.history.ListSnapshots()
+	if err != nil {
+		return err
+	}
+
+	if len(ids) > 0 {
+		last, err := store.history.LoadSnapshot(ids[len(ids)-1])
+		if err == nil && bytes.Equal(last, data) {
+			return nil
+		}
+	}
+
+	id := time.Now().UTC().Format(snapshotIdLayout)
+
+	var obsolete []string
+	if count := len(ids) + 1 - store.historySize; count > 0 {
+		obsolete = ids[:count]
+	}
+
+	if err := store.history.SaveSnapshot(id, data, obsolete); err != nil {
+		return err
+	}
+
+	logger.Infof("Snapshot '%s' saved (%d removed)", id, len(obsolete))
+
+	return nil
+}
+
+// List saved snapshots, oldest first
+func (store *Store) ListSnapshots() ([]*SnapshotInfo, error) {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	if err := store.checkHistory(); err != nil {
+		return nil, err
+	}
+
+	ids, err := store.history.ListSnapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*SnapshotInfo, 0, len(ids))
+	var previous *snapshotContent
+
+	for _, id := range ids {
+		content, err := store.loadSnapshot(id)
+		if err != nil {
+			return nil, err
+		}
+
+		info := &SnapshotInfo{Id: id}
+		info.Time, _ = time.Parse(snapshotIdLayout, id)
+
+		if previous != nil {
+			info.Diff = MakeDiff(previous.components, previous.bindings, content.components, content.bindings)
+		}
+
+		list = append(list, info)
+		previous = content
+	}
+
+	return list, nil
+}
+
+// Changes between two snapshots, an empty id stands for the current content of the store
+func (store *Store) DiffSnapshots(from string, to string) (*Diff, error) {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	if err := store.checkHistory(); err != nil {
+		return nil, err
+	}
+
+	fromContent, err := store.loadSnapshotOrCurrent(from)
+	if err != nil {
+		return nil, err
+	}
+
+	toContent, err := store.loadSnapshotOrCurrent(to)
+	if err != nil {
+		return nil, err
+	}
+
+	return MakeDiff(fromContent.components, fromContent.bindings, toContent.components, toContent.bindings), nil
+}
+
+// Get the components and bindings of a snapshot
+func (store *Store) GetSnapshot(id string) ([]*ComponentConfig, []*BindingConfig, error) {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	if err := store.checkHistory(); err != nil {
+		return nil, nil, err
+	}
+
+	content, err := store.loadSnapshot(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	components := make([]*ComponentConfig, 0, len(content.components))
+	for _, id := range sortedKeys(content.components) {
+		components = append(components, content.components[id])
+	}
+
+	bindings := make([]*BindingConfig, 0, len(content.bindings))
+	for _, key := range sortedKeys(content.bindings) {
+		bindings = append(bindings, content.bindings[key])
+	}
+
+	return components, bindings, nil
+}
+
+func (store *Store) checkHistory() error {
+	if store.history == nil {
+		return fmt.Errorf("store history is not enabled")
+	}
+
+	return nil
+}
+
+func (store *Store) loadSnapshotOrCurrent(id string) (*snapshotContent, error) {
+	if id == "" {
+		return &snapshotContent{components: store.components, bindings: store.bindings}, nil
+	}
+
+	return store.loadSnapshot(id)
+}
+
+func (store *Store) loadSnapshot(id string) (*snapshotContent, error) {
+	// Note: also prevents ids from referencing other files
+	if _, err := time.Parse(snapshotIdLayout, id); err != nil {
+		return nil, fmt.Errorf("invalid snapshot id '%s'", id)
+	}
+
+	data, err := store.history.LoadSnapshot(id)
+	if err != nil {
+		return nil, fmt.Errorf("could not load snapshot '%s': %w", id, err)
+	}
+
+	items, err := modelDeserialize(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not read snapshot '%s': %w", id, err)
+	}
+
+	return indexItems(items)
+}
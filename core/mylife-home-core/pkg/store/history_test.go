@@ -0,0 +1,70 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeTestStore(t *testing.T, historySize int) *Store {
+	operations := &FsOperations{path: filepath.Join(t.TempDir(), "store.json")}
+
+	store := newStore(operations)
+	store.history = operations
+	store.historySize = historySize
+	return store
+}
+
+func TestHistory(t *testing.T) {
+	store := makeTestStore(t, 10)
+
+	store.SetComponent(&ComponentConfig{Id: "a", Plugin: "p", Config: map[string]json.RawMessage{"value": json.RawMessage(`1`)}})
+	assert.NoError(t, store.Save())
+
+	// unchanged: no new snapshot
+	assert.NoError(t, store.Save())
+
+	store.SetComponent(&ComponentConfig{Id: "a", Plugin: "p", Config: map[string]json.RawMessage{"value": json.RawMessage(`2`)}})
+	store.AddBinding(&BindingConfig{SourceComponent: "a", SourceState: "s", TargetComponent: "a", TargetAction: "t"})
+	assert.NoError(t, store.Save())
+
+	list, err := store.ListSnapshots()
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Nil(t, list[0].Diff)
+	assert.Equal(t, []string{"a"}, list[1].Diff.ComponentsUpdated)
+	assert.Equal(t, []string{"a:s:a:t"}, list[1].Diff.BindingsAdded)
+
+	diff, err := store.DiffSnapshots(list[0].Id, "")
+	assert.NoError(t, err)
+	assert.Equal(t, list[1].Diff, diff)
+
+	components, bindings, err := store.GetSnapshot(list[0].Id)
+	assert.NoError(t, err)
+	assert.Len(t, components, 1)
+	assert.JSONEq(t, `1`, string(components[0].Config["value"]))
+	assert.Empty(t, bindings)
+
+	_, _, err = store.GetSnapshot("../store")
+	assert.Error(t, err)
+}
+
+func TestHistoryBounded(t *testing.T) {
+	store := makeTestStore(t, 2)
+
+	for index := 0; index < 4; index++ {
+		store.SetComponent(&ComponentConfig{Id: "a", Plugin: "p", Config: map[string]json.RawMessage{"value": json.RawMessage(fmt.Sprint(index))}})
+		assert.NoError(t, store.Save())
+	}
+
+	list, err := store.ListSnapshots()
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	components, _, err := store.GetSnapshot(list[1].Id)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `3`, string(components[0].Config["value"]))
+}
@@ -3,6 +3,9 @@ package store
 // TODO: check config float vs int (for colors)
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 )
@@ -35,19 +38,97 @@ func (config *ComponentConfig) String() string {
 }
 
 type BindingConfig struct {
-	SourceComponent string `json:"sourceComponent"`
-	SourceState     string `json:"sourceState"`
-	TargetComponent string `json:"targetComponent"`
-	TargetAction    string `json:"targetAction"`
+	SourceComponent string                    `json:"sourceComponent"`
+	SourceState     string                    `json:"sourceState"`
+	TargetComponent string                    `json:"targetComponent"`
+	TargetAction    string                    `json:"targetAction"`
+	Transforms      []*BindingTransformConfig `json:"transforms,omitempty"` // applied in order on the source state value
 }
 
 func (config *BindingConfig) String() string {
-	return fmt.Sprintf("%s.%s -> %s.%s", config.SourceComponent, config.SourceState, config.TargetComponent, config.TargetAction)
+	str := fmt.Sprintf("%s.%s -> %s.%s", config.SourceComponent, config.SourceState, config.TargetComponent, config.TargetAction)
+
+	for _, transform := range config.Transforms {
+		str += " | " + transform.String()
+	}
+
+	return str
+}
+
+const (
+	TransformInvert    = "invert"    // bool -> bool
+	TransformScale     = "scale"     // range/float -> range[to], linear, from defaults to the source range
+	TransformThreshold = "threshold" // range/float -> bool, value >= threshold
+	TransformMap       = "map"       // text/enum/bool/range -> enum/bool/range, unmapped values are dropped
+	TransformClamp     = "clamp"     // range/float -> same type, bounded to min/max
+)
+
+type BindingTransformConfig struct {
+	Type      string         `json:"type"`
+	From      []float64      `json:"from,omitempty"`      // scale
+	To        []float64      `json:"to,omitempty"`        // scale
+	Threshold *float64       `json:"threshold,omitempty"` // threshold
+	Values    map[string]any `json:"values,omitempty"`    // map, keys are the source values as strings
+	Min       *float64       `json:"min,omitempty"`       // clamp
+	Max       *float64       `json:"max,omitempty"`       // clamp
+}
+
+func (config *BindingTransformConfig) String() string {
+	switch config.Type {
+	case TransformScale:
+		return fmt.Sprintf("%s(from=%v, to=%v)", config.Type, config.From, config.To)
+	case TransformThreshold:
+		return fmt.Sprintf("%s(%v)", config.Type, formatOptional(config.Threshold))
+	case TransformMap:
+		return fmt.Sprintf("%s(%v)", config.Type, config.Values)
+	case TransformClamp:
+		return fmt.Sprintf("%s(min=%v, max=%v)", config.Type, formatOptional(config.Min), formatOptional(config.Max))
+	default:
+		return config.Type
+	}
+}
+
+func formatOptional(value *float64) string {
+	if value == nil {
+		return "<none>"
+	}
+
+	return fmt.Sprint(*value)
+}
+
+// File content, the checksum is computed on the compact JSON of items
+type storeFile struct {
+	Checksum string          `json:"checksum"`
+	Items    json.RawMessage `json:"items"`
 }
 
 func modelDeserialize(data []byte) ([]storeItem, error) {
 	var items []storeItem
-	if err := json.Unmarshal(data, &items); err != nil {
+
+	// Note: files written before checksums were added only contain the items
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+
+		return items, nil
+	}
+
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	checksum, err := modelChecksum(file.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	if checksum != file.Checksum {
+		return nil, fmt.Errorf("checksum mismatch (expected '%s', got '%s')", file.Checksum, checksum)
+	}
+
+	if err := json.Unmarshal(file.Items, &items); err != nil {
 		return nil, err
 	}
 
@@ -55,7 +136,27 @@ func modelDeserialize(data []byte) ([]storeItem, error) {
 }
 
 func modelSerialize(items []storeItem) ([]byte, error) {
-	return json.MarshalIndent(items, "", "  ")
+	raw, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum, err := modelChecksum(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(&storeFile{Checksum: checksum, Items: raw}, "", "  ")
+}
+
+func modelChecksum(items json.RawMessage) (string, error) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, items); err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(compact.Bytes())
+	return "sha256:" + hex.EncodeToString(hash[:]), nil
 }
 
 func (item *storeItem) UnmarshalJSON(data []byte) error {
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"mylife-home-common/bus"
 	"reflect"
 
 	"github.com/gookit/goutil/errorx/panics"
@@ -11,21 +12,53 @@ type storeOperations interface {
 	Save(data []byte) error
 }
 
-type storeOperationsFactory = func(config map[string]any) storeOperations
+// Implemented by operations which can keep snapshots of saved data
+type historyOperations interface {
+	ListSnapshots() ([]string, error) // sorted ids, oldest first
+	LoadSnapshot(id string) ([]byte, error)
+	SaveSnapshot(id string, data []byte, obsolete []string) error // also remove obsolete snapshots
+}
+
+// Implemented by operations which keep the previous version of saved data
+type backupOperations interface {
+	LoadBackup() ([]byte, error)
+}
+
+// Implemented by operations which store each component and binding as its own record, so that only changes are written on save
+type recordsOperations interface {
+	SaveRecords(changes *recordChanges) error
+}
+
+type recordChanges struct {
+	components        map[string]*ComponentConfig // added or updated, by id
+	bindings          map[string]*BindingConfig   // added or updated, by key
+	removedComponents []string
+	removedBindings   []string
+}
+
+type storeOperationsFactory = func(config map[string]any, transport *bus.Transport) storeOperations
 
 var operationsRegistry = make(map[string]storeOperationsFactory)
 
-func makeOperations(typ string, config map[string]any) storeOperations {
+func makeOperations(typ string, config map[string]any, transport *bus.Transport) storeOperations {
 	factory, ok := operationsRegistry[typ]
 	panics.IsTrue(ok, "invalid store operations type: '%s'", typ)
 
-	return factory(config)
+	return factory(config, transport)
 }
 
 func registerOperations(typ string, factory storeOperationsFactory) {
 	operationsRegistry[typ] = factory
 }
 
+func getOptionalConfigValue[T any](config map[string]any, key string, defaultValue T) T {
+	if _, ok := config[key]; !ok {
+		return defaultValue
+	}
+
+	return getConfigValue[T](config, key)
+}
+
 func getConfigValue[T any](config map[string]any, key string) T {
 	var defaultValue T
 
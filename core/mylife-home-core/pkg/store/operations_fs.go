@@ -1,10 +1,18 @@
 package store
 
 import (
+	"errors"
+	"mylife-home-common/bus"
 	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/exp/slices"
 )
 
 var _ storeOperations = (*FsOperations)(nil)
+var _ historyOperations = (*FsOperations)(nil)
+var _ backupOperations = (*FsOperations)(nil)
 
 type FsOperations struct {
 	path string
@@ -15,11 +23,134 @@ func (operations *FsOperations) Load() ([]byte, error) {
 }
 
 func (operations *FsOperations) Save(data []byte) error {
-	return os.WriteFile(operations.path, data, 0644)
+	return writeFileAtomic(operations.path, data, true)
+}
+
+func (operations *FsOperations) LoadBackup() ([]byte, error) {
+	return os.ReadFile(backupFile(operations.path))
+}
+
+func (operations *FsOperations) ListSnapshots() ([]string, error) {
+	return listSnapshotFiles(operations.path)
+}
+
+func (operations *FsOperations) LoadSnapshot(id string) ([]byte, error) {
+	return os.ReadFile(snapshotFile(operations.path, id))
+}
+
+func (operations *FsOperations) SaveSnapshot(id string, data []byte, obsolete []string) error {
+	return saveSnapshotFile(operations.path, id, data, obsolete)
+}
+
+func backupFile(path string) string {
+	return path + ".bak"
+}
+
+// Write to a temporary file, sync it, then rename it over path, so that path is never partially written.
+//
+// With backup, the previous file is kept as '<path>.bak'.
+func writeFileAtomic(path string, data []byte, backup bool) error {
+	tmpPath := path + ".tmp"
+
+	if err := writeFileSync(tmpPath, data); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if backup {
+		// Note: if interrupted before the next rename, path is missing and the backup is loaded
+		if err := os.Rename(path, backupFile(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	return syncDirectory(filepath.Dir(path))
+}
+
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Make renames durable
+func syncDirectory(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// Snapshots are stored as '<store path>.history/<id>.json'
+func snapshotDirectory(path string) string {
+	return path + ".history"
+}
+
+func snapshotFile(path string, id string) string {
+	return filepath.Join(snapshotDirectory(path), id+".json")
+}
+
+func listSnapshotFiles(path string) ([]string, error) {
+	entries, err := os.ReadDir(snapshotDirectory(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if ok && !entry.IsDir() {
+			ids = append(ids, id)
+		}
+	}
+
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func saveSnapshotFile(path string, id string, data []byte, obsolete []string) error {
+	if err := os.MkdirAll(snapshotDirectory(path), 0755); err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(snapshotFile(path, id), data, false); err != nil {
+		return err
+	}
+
+	for _, id := range obsolete {
+		if err := os.Remove(snapshotFile(path, id)); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func init() {
-	registerOperations("fs", func(config map[string]any) storeOperations {
+	registerOperations("fs", func(config map[string]any, transport *bus.Transport) storeOperations {
 		return &FsOperations{
 			path: getConfigValue[string](config, "path"),
 		}
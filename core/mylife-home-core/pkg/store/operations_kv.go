@@ -0,0 +1,166 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"mylife-home-common/bus"
+	"time"
+
+	"github.com/gookit/goutil/errorx/panics"
+	bolt "go.etcd.io/bbolt"
+)
+
+var _ storeOperations = (*KvOperations)(nil)
+var _ recordsOperations = (*KvOperations)(nil)
+
+var componentsBucket = []byte("components")
+var bindingsBucket = []byte("bindings")
+
+// Embedded key/value database, with one record per component (by id) and per binding (by key)
+type KvOperations struct {
+	db *bolt.DB
+}
+
+// Get all records
+func (operations *KvOperations) Load() ([]byte, error) {
+	items := make([]storeItem, 0)
+
+	err := operations.db.View(func(tx *bolt.Tx) error {
+		err := forEachRecord(tx, componentsBucket, func(data []byte) error {
+			config := &ComponentConfig{}
+			if err := json.Unmarshal(data, config); err != nil {
+				return err
+			}
+
+			items = append(items, storeItem{Type: storeItemTypeComponent, Config: config})
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		return forEachRecord(tx, bindingsBucket, func(data []byte) error {
+			config := &BindingConfig{}
+			if err := json.Unmarshal(data, config); err != nil {
+				return err
+			}
+
+			items = append(items, storeItem{Type: storeItemTypeBinding, Config: config})
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return modelSerialize(items)
+}
+
+// Replace all records
+func (operations *KvOperations) Save(data []byte) error {
+	items, err := modelDeserialize(data)
+	if err != nil {
+		return err
+	}
+
+	content, err := indexItems(items)
+	if err != nil {
+		return err
+	}
+
+	return operations.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{componentsBucket, bindingsBucket} {
+			if err := tx.DeleteBucket(name); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+		}
+
+		return putRecords(tx, &recordChanges{components: content.components, bindings: content.bindings})
+	})
+}
+
+// Only write changed records, in one transaction
+func (operations *KvOperations) SaveRecords(changes *recordChanges) error {
+	return operations.db.Update(func(tx *bolt.Tx) error {
+		return putRecords(tx, changes)
+	})
+}
+
+func (operations *KvOperations) Close() error {
+	return operations.db.Close()
+}
+
+func forEachRecord(tx *bolt.Tx, name []byte, callback func(data []byte) error) error {
+	bucket := tx.Bucket(name)
+	if bucket == nil {
+		return nil
+	}
+
+	return bucket.ForEach(func(key []byte, data []byte) error {
+		if err := callback(data); err != nil {
+			return fmt.Errorf("invalid record '%s/%s': %w", name, key, err)
+		}
+
+		return nil
+	})
+}
+
+func putRecords(tx *bolt.Tx, changes *recordChanges) error {
+	components, err := tx.CreateBucketIfNotExists(componentsBucket)
+	if err != nil {
+		return err
+	}
+
+	bindings, err := tx.CreateBucketIfNotExists(bindingsBucket)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range changes.removedComponents {
+		if err := components.Delete([]byte(id)); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range changes.removedBindings {
+		if err := bindings.Delete([]byte(key)); err != nil {
+			return err
+		}
+	}
+
+	for id, config := range changes.components {
+		if err := putRecord(components, id, config); err != nil {
+			return err
+		}
+	}
+
+	for key, config := range changes.bindings {
+		if err := putRecord(bindings, key, config); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func putRecord(bucket *bolt.Bucket, key string, config itemConfig) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	return bucket.Put([]byte(key), data)
+}
+
+func init() {
+	registerOperations("kv", func(config map[string]any, transport *bus.Transport) storeOperations {
+		path := getConfigValue[string](config, "path")
+
+		db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 5 * time.Second})
+		panics.IsTrue(err == nil, "could not open store database '%s': %s", path, err)
+
+		return &KvOperations{db: db}
+	})
+}
@@ -1,11 +1,14 @@
 package store
 
 import (
+	"mylife-home-common/bus"
 	"os"
 	"os/exec"
 )
 
 var _ storeOperations = (*MountedFsOperations)(nil)
+var _ historyOperations = (*MountedFsOperations)(nil)
+var _ backupOperations = (*MountedFsOperations)(nil)
 
 type MountedFsOperations struct {
 	path       string
@@ -17,11 +20,36 @@ func (operations *MountedFsOperations) Load() ([]byte, error) {
 }
 
 func (operations *MountedFsOperations) Save(data []byte) error {
+	return operations.writable(func() error {
+		return writeFileAtomic(operations.path, data, true)
+	})
+}
+
+func (operations *MountedFsOperations) LoadBackup() ([]byte, error) {
+	return os.ReadFile(backupFile(operations.path))
+}
+
+func (operations *MountedFsOperations) ListSnapshots() ([]string, error) {
+	return listSnapshotFiles(operations.path)
+}
+
+func (operations *MountedFsOperations) LoadSnapshot(id string) ([]byte, error) {
+	return os.ReadFile(snapshotFile(operations.path, id))
+}
+
+func (operations *MountedFsOperations) SaveSnapshot(id string, data []byte, obsolete []string) error {
+	return operations.writable(func() error {
+		return saveSnapshotFile(operations.path, id, data, obsolete)
+	})
+}
+
+// Run write with the mount point remounted read-write
+func (operations *MountedFsOperations) writable(write func() error) error {
 	if mountErr := operations.remount("rw"); mountErr != nil {
 		return mountErr
 	}
 
-	err := os.WriteFile(operations.path, data, 0644)
+	err := write()
 	mountErr := operations.remount("ro")
 
 	if err != nil {
@@ -36,7 +64,7 @@ func (operations *MountedFsOperations) remount(mountType string) error {
 }
 
 func init() {
-	registerOperations("mounted-fs", func(config map[string]any) storeOperations {
+	registerOperations("mounted-fs", func(config map[string]any, transport *bus.Transport) storeOperations {
 		return &MountedFsOperations{
 			path:       getConfigValue[string](config, "path"),
 			mountPoint: getConfigValue[string](config, "mountPoint"),
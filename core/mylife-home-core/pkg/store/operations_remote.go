@@ -0,0 +1,79 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mylife-home-common/bus"
+	"mylife-home-common/defines"
+	"time"
+
+	"github.com/gookit/goutil/errorx/panics"
+)
+
+var _ storeOperations = (*RemoteOperations)(nil)
+
+const remoteCallTimeout = 10 * time.Second
+const remoteRetryDelay = 2 * time.Second
+
+type remoteLoadInput struct {
+	Instance string `json:"instance"`
+}
+
+type remoteSaveInput struct {
+	Instance string          `json:"instance"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// Store kept by another instance (see store 'serve' config), through RPC
+type RemoteOperations struct {
+	transport   *bus.Transport
+	server      string
+	loadTimeout time.Duration
+}
+
+// Note: at startup the server may not be reachable yet, retry until loadTimeout.
+// Errors returned by the server (eg: invalid store) are not retried.
+func (operations *RemoteOperations) Load() ([]byte, error) {
+	input := &remoteLoadInput{Instance: defines.InstanceName()}
+	deadline := time.Now().Add(operations.loadTimeout)
+
+	for {
+		data, err := bus.RpcCall[*remoteLoadInput, json.RawMessage](operations.transport.Rpc(), operations.server, "store.remote.load", input, remoteCallTimeout)
+		if err == nil {
+			return data, nil
+		}
+
+		var remoteErr *bus.RemoteError
+		if errors.As(err, &remoteErr) || time.Now().After(deadline) {
+			return nil, fmt.Errorf("could not load store from '%s': %w", operations.server, err)
+		}
+
+		logger.WithError(err).Warnf("Could not load store from '%s', retrying", operations.server)
+		time.Sleep(remoteRetryDelay)
+	}
+}
+
+func (operations *RemoteOperations) Save(data []byte) error {
+	input := &remoteSaveInput{Instance: defines.InstanceName(), Data: data}
+
+	_, err := bus.RpcCall[*remoteSaveInput, struct{}](operations.transport.Rpc(), operations.server, "store.remote.save", input, remoteCallTimeout)
+	if err != nil {
+		return fmt.Errorf("could not save store to '%s': %w", operations.server, err)
+	}
+
+	return nil
+}
+
+func init() {
+	registerOperations("remote", func(config map[string]any, transport *bus.Transport) storeOperations {
+		loadTimeout, err := time.ParseDuration(getOptionalConfigValue(config, "loadTimeout", "1m"))
+		panics.IsTrue(err == nil, "invalid store loadTimeout: %s", err)
+
+		return &RemoteOperations{
+			transport:   transport,
+			server:      getConfigValue[string](config, "instance"),
+			loadTimeout: loadTimeout,
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mylife-home-common/bus"
+	"mylife-home-common/instance_info"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+var instanceNameParser = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// Keep the stores of instances using the 'remote' store type, as '<directory>/<instance>.json'
+type remoteServer struct {
+	transport *bus.Transport
+	directory string
+	mux       sync.Mutex
+}
+
+func makeRemoteServer(transport *bus.Transport, directory string) *remoteServer {
+	server := &remoteServer{
+		transport: transport,
+		directory: directory,
+	}
+
+	server.transport.Rpc().Serve("store.remote.load", bus.NewRpcService(server.load))
+	server.transport.Rpc().Serve("store.remote.save", bus.NewRpcService(server.save))
+
+	instance_info.AddCapability("store-server")
+
+	return server
+}
+
+func (server *remoteServer) Terminate() {
+	server.transport.Rpc().Unserve("store.remote.load")
+	server.transport.Rpc().Unserve("store.remote.save")
+}
+
+func (server *remoteServer) load(input *remoteLoadInput) (json.RawMessage, error) {
+	operations, err := server.operations(input.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	server.mux.Lock()
+	defer server.mux.Unlock()
+
+	// Note: check content here, the data would not be transmitted if truncated
+	data, err := operations.Load()
+	if err == nil {
+		_, err = modelDeserialize(data)
+	}
+
+	if err != nil {
+		loadErr := err
+		if !errors.Is(err, os.ErrNotExist) {
+			logger.WithError(err).Errorf("Could not load store of '%s', trying backup", input.Instance)
+		}
+
+		data, err = operations.LoadBackup()
+		if err == nil {
+			_, err = modelDeserialize(data)
+		}
+
+		if err != nil {
+			// New instance: it starts with an empty store, created at its first save
+			if errors.Is(loadErr, os.ErrNotExist) && errors.Is(err, os.ErrNotExist) {
+				logger.Infof("No store for '%s' yet, serving an empty store", input.Instance)
+				return modelSerialize(make([]storeItem, 0))
+			}
+
+			return nil, fmt.Errorf("no valid store found for '%s': %w", input.Instance, err)
+		}
+
+		logger.Warnf("!!! Store of '%s' served from backup !!!", input.Instance)
+	}
+
+	return data, nil
+}
+
+func (server *remoteServer) save(input *remoteSaveInput) (struct{}, error) {
+	operations, err := server.operations(input.Instance)
+	if err != nil {
+		return struct{}{}, err
+	}
+
+	if _, err := modelDeserialize(input.Data); err != nil {
+		return struct{}{}, fmt.Errorf("invalid store data: %w", err)
+	}
+
+	server.mux.Lock()
+	defer server.mux.Unlock()
+
+	if err := operations.Save(input.Data); err != nil {
+		return struct{}{}, err
+	}
+
+	logger.Infof("Store of '%s' saved", input.Instance)
+
+	return struct{}{}, nil
+}
+
+func (server *remoteServer) operations(instance string) (*FsOperations, error) {
+	if !instanceNameParser.MatchString(instance) || instance == "." || instance == ".." {
+		return nil, fmt.Errorf("invalid instance name '%s'", instance)
+	}
+
+	return &FsOperations{path: filepath.Join(server.directory, instance+".json")}, nil
+}
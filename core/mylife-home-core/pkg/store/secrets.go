@@ -0,0 +1,148 @@
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/gookit/goutil/errorx/panics"
+)
+
+const redactedSecret = "******"
+
+// Value shown instead of secret config items in RPC responses.
+// When it is sent back (eg: components.update with a listed config), the stored value is kept.
+var RedactedSecret = json.RawMessage(`"` + redactedSecret + `"`)
+
+// Stored form of secret config items, exactly one field is set:
+//   - encrypted: value encrypted with 'store.secretKey' (AES-GCM, base64 of nonce + ciphertext)
+//   - env: name of the environment variable holding the value, resolved when the component is created
+//
+// Plain strings are also accepted (no secret key configured, or store written before secrets support).
+type secretRef struct {
+	Encrypted string `json:"encrypted,omitempty"`
+	Env       string `json:"env,omitempty"`
+}
+
+type Secrets struct {
+	aead cipher.AEAD // nil if no secret key is configured
+}
+
+func makeSecrets(key string) *Secrets {
+	secrets := &Secrets{}
+	if key == "" {
+		return secrets
+	}
+
+	hash := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(hash[:])
+	panics.IsTrue(err == nil, "could not create secrets cipher: %s", err)
+
+	secrets.aead, err = cipher.NewGCM(block)
+	panics.IsTrue(err == nil, "could not create secrets cipher: %s", err)
+
+	return secrets
+}
+
+// True if a secret key is configured, so that secret values are stored encrypted
+func (secrets *Secrets) Enabled() bool {
+	return secrets.aead != nil
+}
+
+// Stored form of the value provided for a secret item (plain string, reference, or redacted value).
+//
+// Previous is the stored value of the item (nil if none): it is kept if the value is redacted or did not change,
+// so that a value is not encrypted again at each update.
+func (secrets *Secrets) Seal(value json.RawMessage, previous json.RawMessage) (json.RawMessage, error) {
+	var plain string
+	if err := json.Unmarshal(value, &plain); err != nil {
+		// Already in stored form (eg: config from a snapshot), check it can be used
+		if _, err := secrets.Open(value); err != nil {
+			return nil, err
+		}
+
+		return value, nil
+	}
+
+	if plain == redactedSecret {
+		if previous == nil {
+			return nil, errors.New("redacted value provided but no value stored")
+		}
+
+		return previous, nil
+	}
+
+	if previous != nil {
+		if previousPlain, err := secrets.Open(previous); err == nil && previousPlain == plain {
+			return previous, nil
+		}
+	}
+
+	if !secrets.Enabled() {
+		return value, nil
+	}
+
+	nonce := make([]byte, secrets.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("could not generate nonce: %w", err)
+	}
+
+	encrypted := secrets.aead.Seal(nonce, nonce, []byte(plain), nil)
+
+	return json.Marshal(&secretRef{Encrypted: base64.StdEncoding.EncodeToString(encrypted)})
+}
+
+// Plain value of a secret item from its stored form
+func (secrets *Secrets) Open(stored json.RawMessage) (string, error) {
+	var plain string
+	if err := json.Unmarshal(stored, &plain); err == nil {
+		return plain, nil
+	}
+
+	var ref secretRef
+	if err := json.Unmarshal(stored, &ref); err != nil || (ref.Encrypted == "") == (ref.Env == "") {
+		return "", fmt.Errorf("invalid secret value '%s': expected a string, '{\"encrypted\": ...}' or '{\"env\": ...}'", string(stored))
+	}
+
+	if ref.Env != "" {
+		value, ok := os.LookupEnv(ref.Env)
+		if !ok {
+			return "", fmt.Errorf("environment variable '%s' is not set", ref.Env)
+		}
+
+		return value, nil
+	}
+
+	if !secrets.Enabled() {
+		return "", errors.New("encrypted value but no 'store.secretKey' configured")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(ref.Encrypted)
+	nonceSize := secrets.aead.NonceSize()
+	if err != nil || len(raw) < nonceSize {
+		return "", errors.New("invalid encrypted value")
+	}
+
+	decrypted, err := secrets.aead.Open(nil, raw[:nonceSize], raw[nonceSize:], nil)
+	if err != nil {
+		return "", errors.New("could not decrypt value (wrong 'store.secretKey'?)")
+	}
+
+	return string(decrypted), nil
+}
+
+// Value of a secret item in RPC responses: environment references are shown, values are redacted
+func RedactSecret(stored json.RawMessage) json.RawMessage {
+	var ref secretRef
+	if err := json.Unmarshal(stored, &ref); err == nil && ref.Env != "" && ref.Encrypted == "" {
+		return stored
+	}
+
+	return RedactedSecret
+}
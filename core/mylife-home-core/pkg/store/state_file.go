@@ -0,0 +1,68 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Last values of persistent component states, JSON encoded, by component id then state name.
+// Components data is kept along, with keys prefixed by '$'
+type StateValues = map[string]map[string]json.RawMessage
+
+// Local file holding persistent component states, written like 'fs' store files (atomic with backup)
+type StateFile struct {
+	path string
+}
+
+func MakeStateFile(path string) *StateFile {
+	return &StateFile{path: path}
+}
+
+// Note: a missing or invalid file gives no values (and its backup is tried), the state is not worth failing startup
+func (file *StateFile) Load() StateValues {
+	values, err := readStateFile(file.path)
+	if err == nil {
+		return values
+	}
+
+	if errors.Is(err, os.ErrNotExist) {
+		logger.Infof("No state file '%s'", file.path)
+		return make(StateValues)
+	}
+
+	logger.WithError(err).Errorf("Could not load state file '%s', trying backup", file.path)
+
+	values, err = readStateFile(backupFile(file.path))
+	if err != nil {
+		logger.WithError(err).Errorf("Could not load state file backup, persistent states are reset")
+		return make(StateValues)
+	}
+
+	logger.Warnf("State file loaded from backup")
+	return values
+}
+
+func (file *StateFile) Save(values StateValues) error {
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(file.path, data, true)
+}
+
+func readStateFile(path string) (StateValues, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(StateValues)
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("invalid state file '%s': %w", path, err)
+	}
+
+	return values, nil
+}
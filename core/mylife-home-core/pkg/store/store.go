@@ -2,12 +2,16 @@ package store
 
 import (
 	"fmt"
-	"strings"
+	"io"
 	"sync"
+	"time"
 
+	"mylife-home-common/bus"
 	"mylife-home-common/config"
 	"mylife-home-common/log"
+	"mylife-home-common/tools"
 
+	"github.com/gookit/goutil/errorx/panics"
 	"golang.org/x/exp/maps"
 )
 
@@ -15,83 +19,182 @@ var logger = log.CreateLogger("mylife:home:core:store")
 
 type storeConfig struct {
 	Type             string         `mapstructure:"type"`
+	History          *int           `mapstructure:"history"`   // number of snapshots to keep, 0 to disable (default 10)
+	Serve            string         `mapstructure:"serve"`     // directory where the stores of 'remote' instances are kept, if this instance serves them
+	AutoSave         string         `mapstructure:"autoSave"`  // delay after the last change to save automatically (eg: '5s'), disabled if empty
+	SecretKey        string         `mapstructure:"secretKey"` // key to encrypt secret config items, stored in clear if empty
 	OperationsConfig map[string]any `mapstructure:",remain"`
 }
 
 type Store struct {
-	operations storeOperations
-	components map[string]*ComponentConfig
-	bindings   map[string]*BindingConfig
-	mux        sync.Mutex // Need to sync because Save() is executed in its own goroutine
+	operations  storeOperations
+	history     historyOperations // nil if disabled
+	historySize int
+	server      *remoteServer // nil if not serving
+	components  map[string]*ComponentConfig
+	bindings    map[string]*BindingConfig
+	saved       *snapshotContent // content as last loaded/saved, for records operations
+	dirty       tools.SubjectValue[bool]
+	autoSave    time.Duration
+	secrets     *Secrets
+	mux         sync.Mutex // Need to sync because Save() is executed in its own goroutine
 }
 
-func MakeStore() *Store {
+func MakeStore(transport *bus.Transport) *Store {
 	conf := storeConfig{}
 	config.BindStructure("store", &conf)
 
-	operations := makeOperations(conf.Type, conf.OperationsConfig)
+	operations := makeOperations(conf.Type, conf.OperationsConfig, transport)
+	store := newStore(operations)
+	store.secrets = makeSecrets(conf.SecretKey)
 
-	store := &Store{
+	if conf.AutoSave != "" {
+		autoSave, err := time.ParseDuration(conf.AutoSave)
+		panics.IsTrue(err == nil && autoSave > 0, "invalid store autoSave: '%s'", conf.AutoSave)
+		store.autoSave = autoSave
+	}
+
+	store.historySize = defaultHistorySize
+	if conf.History != nil {
+		store.historySize = *conf.History
+	}
+
+	if store.historySize > 0 {
+		if history, ok := operations.(historyOperations); ok {
+			store.history = history
+		} else if conf.History != nil {
+			logger.Warnf("Store type '%s' does not support history", conf.Type)
+		}
+	}
+
+	if conf.Serve != "" {
+		store.server = makeRemoteServer(transport, conf.Serve)
+	}
+
+	return store
+}
+
+func newStore(operations storeOperations) *Store {
+	return &Store{
 		operations: operations,
 		components: make(map[string]*ComponentConfig),
 		bindings:   make(map[string]*BindingConfig),
+		dirty:      tools.MakeSubjectValue(false),
+		secrets:    makeSecrets(""),
 	}
+}
 
-	return store
+// Handling of secret config items
+func (store *Store) Secrets() *Secrets {
+	return store.secrets
+}
+
+func (store *Store) Terminate() {
+	if store.server != nil {
+		store.server.Terminate()
+	}
+
+	if closer, ok := store.operations.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logger.WithError(err).Error("Could not close store")
+		}
+	}
 }
 
 func (store *Store) Load() error {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
-	data, err := store.operations.Load()
+	items, err := loadItems(store.operations.Load)
 	if err != nil {
-		return err
+		logger.WithError(err).Error("Could not load store, trying backups")
+
+		items, err = store.loadFromBackups(err)
+		if err != nil {
+			return err
+		}
 	}
 
-	items, err := modelDeserialize(data)
+	content, err := indexItems(items)
 	if err != nil {
 		return err
 	}
 
-	for _, item := range items {
-		switch item.Type {
-		case storeItemTypeComponent:
-			config := item.Config.(*ComponentConfig)
-			store.components[config.Id] = config
+	store.components = content.components
+	store.bindings = content.bindings
+	store.saved = store.content()
+	store.dirty.Update(false)
 
-		case storeItemTypeBinding:
-			config := item.Config.(*BindingConfig)
-			key := store.buildBindingKey(config)
-			store.bindings[key] = config
+	logger.Infof("%d items loaded", len(items))
+
+	return nil
+}
 
-		default:
-			return fmt.Errorf("unsupported type: '%s'", item.Type)
+// Try the previous version of the store, then the snapshots from the newest
+func (store *Store) loadFromBackups(loadErr error) ([]storeItem, error) {
+	if backup, ok := store.operations.(backupOperations); ok {
+		items, err := loadItems(backup.LoadBackup)
+		if err == nil {
+			logger.Warnf("!!! STORE LOADED FROM BACKUP: the store file is invalid (%s), the previous version was loaded instead. Check the components and save the store. !!!", loadErr)
+			return items, nil
 		}
+
+		logger.WithError(err).Error("Could not load store backup")
 	}
 
-	logger.Infof("%d items loaded", len(items))
+	if store.history != nil {
+		ids, err := store.history.ListSnapshots()
+		if err != nil {
+			logger.WithError(err).Error("Could not list store snapshots")
+			ids = nil
+		}
 
-	return nil
+		for index := len(ids) - 1; index >= 0; index-- {
+			id := ids[index]
+			items, err := loadItems(func() ([]byte, error) { return store.history.LoadSnapshot(id) })
+			if err == nil {
+				logger.Warnf("!!! STORE LOADED FROM SNAPSHOT '%s': the store file is invalid (%s). Check the components and save the store. !!!", id, loadErr)
+				return items, nil
+			}
+
+			logger.WithError(err).Errorf("Could not load store snapshot '%s'", id)
+		}
+	}
+
+	return nil, fmt.Errorf("no valid store or backup found: %w", loadErr)
+}
+
+func loadItems(load func() ([]byte, error)) ([]storeItem, error) {
+	data, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	return modelDeserialize(data)
 }
 
 func (store *Store) Save() error {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
+	if records, ok := store.operations.(recordsOperations); ok {
+		return store.saveRecords(records)
+	}
+
 	items := make([]storeItem, 0, len(store.components)+len(store.bindings))
 
-	for _, config := range store.components {
+	// Note: sorted so that unchanged content gives the same data
+	for _, id := range sortedKeys(store.components) {
 		items = append(items, storeItem{
 			Type:   storeItemTypeComponent,
-			Config: config,
+			Config: store.components[id],
 		})
 	}
 
-	for _, config := range store.bindings {
+	for _, key := range sortedKeys(store.bindings) {
 		items = append(items, storeItem{
 			Type:   storeItemTypeBinding,
-			Config: config,
+			Config: store.bindings[key],
 		})
 	}
 
@@ -104,16 +207,70 @@ func (store *Store) Save() error {
 		return err
 	}
 
+	store.dirty.Update(false)
 	logger.Infof("%d items saved", len(items))
 
+	if store.history != nil {
+		// Note: the store is saved anyway
+		if err := store.snapshot(data); err != nil {
+			logger.WithError(err).Error("Could not save snapshot")
+		}
+	}
+
+	return nil
+}
+
+func (store *Store) saveRecords(records recordsOperations) error {
+	current := store.content()
+	diff := MakeDiff(store.saved.components, store.saved.bindings, current.components, current.bindings)
+
+	if diff.IsEmpty() {
+		store.dirty.Update(false)
+		logger.Info("No change to save")
+		return nil
+	}
+
+	changes := &recordChanges{
+		components:        make(map[string]*ComponentConfig),
+		bindings:          make(map[string]*BindingConfig),
+		removedComponents: diff.ComponentsRemoved,
+		removedBindings:   diff.BindingsRemoved,
+	}
+
+	for _, id := range append(diff.ComponentsUpdated, diff.ComponentsAdded...) {
+		changes.components[id] = current.components[id]
+	}
+
+	for _, key := range append(diff.BindingsUpdated, diff.BindingsAdded...) {
+		changes.bindings[key] = current.bindings[key]
+	}
+
+	if err := records.SaveRecords(changes); err != nil {
+		return err
+	}
+
+	store.saved = current
+	store.dirty.Update(false)
+
+	logger.Infof("%d items saved, %d removed", len(changes.components)+len(changes.bindings), len(changes.removedComponents)+len(changes.removedBindings))
+
 	return nil
 }
 
+// Copy of the current content
+func (store *Store) content() *snapshotContent {
+	return &snapshotContent{
+		components: maps.Clone(store.components),
+		bindings:   maps.Clone(store.bindings),
+	}
+}
+
 func (store *Store) SetComponent(config *ComponentConfig) {
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
 	store.components[config.Id] = config
+	store.dirty.Update(true)
 }
 
 func (store *Store) RemoveComponent(id string) {
@@ -121,28 +278,35 @@ func (store *Store) RemoveComponent(id string) {
 	defer store.mux.Unlock()
 
 	delete(store.components, id)
+	store.dirty.Update(true)
 }
 
 func (store *Store) AddBinding(config *BindingConfig) {
-	key := store.buildBindingKey(config)
+	key := BuildBindingKey(config)
 
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
 	store.bindings[key] = config
+	store.dirty.Update(true)
 }
 
 func (store *Store) RemoveBinding(config *BindingConfig) {
-	key := store.buildBindingKey(config)
+	key := BuildBindingKey(config)
 
 	store.mux.Lock()
 	defer store.mux.Unlock()
 
 	delete(store.bindings, key)
+	store.dirty.Update(true)
 }
 
-func (store *Store) buildBindingKey(config *BindingConfig) string {
-	return strings.Join([]string{config.SourceComponent, config.SourceState, config.TargetComponent, config.TargetAction}, ":")
+// Nil if not found
+func (store *Store) GetComponent(id string) *ComponentConfig {
+	store.mux.Lock()
+	defer store.mux.Unlock()
+
+	return store.components[id]
 }
 
 func (store *Store) GetComponents() []*ComponentConfig {
@@ -159,6 +323,18 @@ func (store *Store) GetBindings() []*BindingConfig {
 	return maps.Values(store.bindings)
 }
 
+// Changes not saved yet.
+//
+// Note: observers are notified while the store is locked, they must not call it back
+func (store *Store) Dirty() tools.ObservableValue[bool] {
+	return store.dirty
+}
+
+// Delay after the last change to save automatically, 0 if disabled
+func (store *Store) AutoSaveDelay() time.Duration {
+	return store.autoSave
+}
+
 func (store *Store) HasBindings() bool {
 	store.mux.Lock()
 	defer store.mux.Unlock()
@@ -0,0 +1,192 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestModelChecksum(t *testing.T) {
+	items := []storeItem{{Type: storeItemTypeComponent, Config: &ComponentConfig{Id: "a", Plugin: "p", Config: map[string]json.RawMessage{}}}}
+
+	data, err := modelSerialize(items)
+	assert.NoError(t, err)
+
+	loaded, err := modelDeserialize(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", loaded[0].Config.(*ComponentConfig).Id)
+
+	_, err = modelDeserialize([]byte(strings.Replace(string(data), `"p"`, `"q"`, 1)))
+	assert.ErrorContains(t, err, "checksum mismatch")
+
+	_, err = modelDeserialize(data[:len(data)/2])
+	assert.Error(t, err)
+
+	legacy, err := modelDeserialize([]byte(`[{"type": "component", "config": {"id": "a", "plugin": "p", "config": {}}}]`))
+	assert.NoError(t, err)
+	assert.Len(t, legacy, 1)
+}
+
+func TestLoadFallback(t *testing.T) {
+	store := makeTestStore(t, 10)
+	path := store.operations.(*FsOperations).path
+
+	store.SetComponent(&ComponentConfig{Id: "a", Plugin: "p", Config: map[string]json.RawMessage{}})
+	assert.NoError(t, store.Save())
+
+	store.SetComponent(&ComponentConfig{Id: "b", Plugin: "p", Config: map[string]json.RawMessage{}})
+	assert.NoError(t, store.Save())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	// truncated write: previous version is loaded
+	assert.NoError(t, os.WriteFile(path, data[:len(data)/2], 0644))
+	assert.NoError(t, store.Load())
+	assert.Len(t, store.GetComponents(), 1)
+
+	// no backup: last snapshot is loaded
+	assert.NoError(t, os.Remove(backupFile(path)))
+	assert.NoError(t, store.Load())
+	assert.Len(t, store.GetComponents(), 2)
+
+	store.history = nil
+	assert.Error(t, store.Load())
+}
+
+func TestRemoteServerLoad(t *testing.T) {
+	server := &remoteServer{directory: t.TempDir()}
+	input := &remoteLoadInput{Instance: "headless"}
+
+	// new instance
+	data, err := server.load(input)
+	assert.NoError(t, err)
+	items, err := modelDeserialize(data)
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(server.directory, "headless.json"), []byte(`[{"type": "comp`), 0644))
+	_, err = server.load(input)
+	assert.Error(t, err)
+}
+
+func TestKvRecords(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "store.db"), 0644, nil)
+	assert.NoError(t, err)
+
+	store := newStore(&KvOperations{db: db})
+	defer store.Terminate()
+
+	assert.NoError(t, store.Load())
+
+	store.SetComponent(&ComponentConfig{Id: "a", Plugin: "p", Config: map[string]json.RawMessage{}})
+	store.SetComponent(&ComponentConfig{Id: "b", Plugin: "p", Config: map[string]json.RawMessage{}})
+	store.AddBinding(&BindingConfig{SourceComponent: "a", SourceState: "s", TargetComponent: "b", TargetAction: "t"})
+	assert.NoError(t, store.Save())
+
+	store.RemoveComponent("a")
+	store.SetComponent(&ComponentConfig{Id: "b", Plugin: "q", Config: map[string]json.RawMessage{}})
+	assert.NoError(t, store.Save())
+
+	assert.NoError(t, store.Load())
+	components := store.GetComponents()
+	assert.Len(t, components, 1)
+	assert.Equal(t, "q", components[0].Plugin)
+	assert.Len(t, store.GetBindings(), 1)
+
+	err = db.View(func(tx *bolt.Tx) error {
+		assert.Nil(t, tx.Bucket(componentsBucket).Get([]byte("a")))
+		assert.NotNil(t, tx.Bucket(bindingsBucket).Get([]byte("a:s:b:t")))
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestDirty(t *testing.T) {
+	store := makeTestStore(t, 10)
+	assert.False(t, store.Dirty().Get())
+
+	store.SetComponent(&ComponentConfig{Id: "a", Plugin: "p", Config: map[string]json.RawMessage{}})
+	assert.True(t, store.Dirty().Get())
+
+	assert.NoError(t, store.Save())
+	assert.False(t, store.Dirty().Get())
+
+	store.RemoveComponent("a")
+	assert.True(t, store.Dirty().Get())
+
+	assert.NoError(t, store.Load())
+	assert.False(t, store.Dirty().Get())
+}
+
+func TestStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	file := MakeStateFile(path)
+
+	assert.Empty(t, file.Load())
+
+	assert.NoError(t, file.Save(StateValues{"a": {"value": json.RawMessage("true")}}))
+	assert.NoError(t, file.Save(StateValues{"a": {"value": json.RawMessage("false")}}))
+	assert.Equal(t, "false", string(file.Load()["a"]["value"]))
+
+	// truncated write: backup is loaded
+	assert.NoError(t, os.WriteFile(path, []byte("{"), 0644))
+	assert.Equal(t, "true", string(file.Load()["a"]["value"]))
+
+	assert.NoError(t, os.Remove(backupFile(path)))
+	assert.Empty(t, file.Load())
+}
+
+func TestSecrets(t *testing.T) {
+	secrets := makeSecrets("key")
+
+	sealed, err := secrets.Seal(json.RawMessage(`"pass"`), nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(sealed), "pass")
+
+	plain, err := secrets.Open(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, "pass", plain)
+
+	// unchanged or redacted: previous value kept
+	kept, err := secrets.Seal(json.RawMessage(`"pass"`), sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, sealed, kept)
+
+	kept, err = secrets.Seal(RedactedSecret, sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, sealed, kept)
+
+	_, err = secrets.Seal(RedactedSecret, nil)
+	assert.Error(t, err)
+
+	_, err = makeSecrets("other").Open(sealed)
+	assert.Error(t, err)
+
+	_, err = makeSecrets("").Open(sealed)
+	assert.Error(t, err)
+
+	// references are kept, and shown
+	t.Setenv("TEST_SECRET", "from-env")
+	ref := json.RawMessage(`{"env":"TEST_SECRET"}`)
+	kept, err = secrets.Seal(ref, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, ref, kept)
+
+	plain, err = secrets.Open(ref)
+	assert.NoError(t, err)
+	assert.Equal(t, "from-env", plain)
+
+	assert.Equal(t, ref, RedactSecret(ref))
+	assert.Equal(t, RedactedSecret, RedactSecret(sealed))
+
+	// no key: stored in clear
+	stored, err := makeSecrets("").Seal(json.RawMessage(`"pass"`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `"pass"`, string(stored))
+}
@@ -16,17 +16,19 @@ type Connection struct {
 	// @Config(description="Identifiant unique de la centrale")
 	Uid string
 
-	// @Config(description="Code pin de connexion")
+	// @Config(description="Code pin de connexion" secret="true")
 	Pin string
 
 	// @State(description="Indique si la connexion à la centrale est établie")
 	Connected definitions.State[bool]
 
+	runtime definitions.Runtime
 	service *engine.Service
 }
 
 func (component *Connection) Init(runtime definitions.Runtime) error {
-	component.Connected.Set(false)
+	component.runtime = runtime
+	component.connectedChanged(false)
 
 	state := engine.GetState(component.Key)
 	component.service = engine.NewService(component.ServerAddress, component.Uid, component.Pin, state, component.connectedChanged)
@@ -40,4 +42,10 @@ func (component *Connection) Terminate() {
 
 func (component *Connection) connectedChanged(newValue bool) {
 	component.Connected.Set(newValue)
+
+	if newValue {
+		component.runtime.SetHealth(definitions.HealthOk, "")
+	} else {
+		component.runtime.SetHealth(definitions.HealthError, "not connected to the panel")
+	}
 }
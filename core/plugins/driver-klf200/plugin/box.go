@@ -14,18 +14,20 @@ type Box struct {
 	// @Config(description="Format : 'IP/hostname:port'")
 	Address string
 
-	// @Config()
+	// @Config(secret="true")
 	Password string
 
 	// @State()
 	Online definitions.State[bool]
 
+	runtime                definitions.Runtime
 	store                  *engine.Store
 	client                 *engine.Client
 	storeOnlineChangedChan chan bool
 }
 
 func (component *Box) Init(runtime definitions.Runtime) error {
+	component.runtime = runtime
 	component.store = engine.GetStore(component.BoxKey)
 
 	component.storeOnlineChangedChan = make(chan bool)
@@ -63,4 +65,10 @@ func (component *Box) Reboot(arg bool) {
 
 func (component *Box) handleOnlineChanged(value bool) {
 	component.Online.Set(value)
+
+	if value {
+		component.runtime.SetHealth(definitions.HealthOk, "")
+	} else {
+		component.runtime.SetHealth(definitions.HealthError, "not connected to the box")
+	}
 }
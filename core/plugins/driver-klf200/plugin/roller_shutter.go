@@ -25,6 +25,7 @@ type RollerShutter struct {
 	// @State(type="range[0;100]")
 	Value definitions.State[int64]
 
+	runtime                definitions.Runtime
 	store                  *engine.Store
 	storeOnlineChangedChan chan bool
 	storeDeviceChangedChan chan *engine.DeviceChange
@@ -39,6 +40,7 @@ type RollerShutter struct {
 // TODO: add step up/down
 
 func (component *RollerShutter) Init(runtime definitions.Runtime) error {
+	component.runtime = runtime
 	component.store = engine.GetStore(component.BoxKey)
 
 	component.storeOnlineChangedChan = make(chan bool)
@@ -162,6 +164,15 @@ func (component *RollerShutter) refreshOnline() {
 	if !online {
 		component.Exec.Set(false)
 	}
+
+	switch {
+	case !component.storeOnline:
+		component.runtime.SetHealth(definitions.HealthError, "box offline")
+	case component.device == nil:
+		component.runtime.SetHealth(definitions.HealthError, "device '"+component.DeviceName+"' not found on the box")
+	default:
+		component.runtime.SetHealth(definitions.HealthOk, "")
+	}
 }
 
 func (component *RollerShutter) handleStateChanged(state *engine.DeviceState) {
@@ -14,13 +14,13 @@ type MailChannel struct {
 	// @Config(description="Serveur SMTP")
 	SmtpServer string
 
-	// @Config(description="Port SMTP")
+	// @Config(description="Port SMTP" min="1" max="65535")
 	SmtpPort int64
 
 	// @Config(description="Nom de connexion au serveur")
 	User string
 
-	// @Config(description="Mot de passe de connexion au serveur")
+	// @Config(description="Mot de passe de connexion au serveur" secret="true")
 	Pass string
 
 	// @Config(description="Emetteur")
@@ -14,32 +14,35 @@ type Box struct {
 	// @Config()
 	User string
 
-	// @Config()
+	// @Config(secret="true")
 	Password string
 
 	// @State()
 	Online definitions.State[bool]
 
+	runtime                definitions.Runtime
 	store                  *engine.Store
 	client                 *engine.Client
+	clientErr              error
 	storeOnlineChangedChan chan bool
 }
 
 func (component *Box) Init(runtime definitions.Runtime) error {
+	component.runtime = runtime
 	component.store = engine.GetStore(component.BoxKey)
 
-	component.storeOnlineChangedChan = make(chan bool)
-	tools.DispatchChannel(component.storeOnlineChangedChan, component.handleOnlineChanged)
-	component.store.Online().Subscribe(component.storeOnlineChangedChan, true)
-
 	client, err := engine.MakeClient(component.User, component.Password)
 	if err != nil {
 		logger.WithError(err).Error("Error at client init")
-		return nil
+		component.clientErr = err
+	} else {
+		component.client = client
+		component.store.SetClient(component.client)
 	}
 
-	component.client = client
-	component.store.SetClient(component.client)
+	component.storeOnlineChangedChan = make(chan bool)
+	tools.DispatchChannel(component.storeOnlineChangedChan, component.handleOnlineChanged)
+	component.store.Online().Subscribe(component.storeOnlineChangedChan, true)
 
 	return nil
 }
@@ -60,4 +63,12 @@ func (component *Box) Terminate() {
 
 func (component *Box) handleOnlineChanged(value bool) {
 	component.Online.Set(value)
+
+	if component.clientErr != nil {
+		component.runtime.SetHealth(definitions.HealthError, "client init failed: "+component.clientErr.Error())
+	} else if value {
+		component.runtime.SetHealth(definitions.HealthOk, "")
+	} else {
+		component.runtime.SetHealth(definitions.HealthError, "not connected to the box")
+	}
 }
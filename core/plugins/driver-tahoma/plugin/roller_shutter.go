@@ -27,6 +27,7 @@ type RollerShutter struct {
 	// @State(type="range[0;100]")
 	Value definitions.State[int64]
 
+	runtime                definitions.Runtime
 	store                  *engine.Store
 	storeOnlineChangedChan chan bool
 	storeDeviceChangedChan chan *engine.DeviceChange
@@ -39,6 +40,7 @@ type RollerShutter struct {
 }
 
 func (component *RollerShutter) Init(runtime definitions.Runtime) error {
+	component.runtime = runtime
 	component.store = engine.GetStore(component.BoxKey)
 
 	component.storeOnlineChangedChan = make(chan bool)
@@ -154,6 +156,15 @@ func (component *RollerShutter) refreshOnline() {
 		component.Value.Set(0)
 		component.Exec.Set(false)
 	}
+
+	switch {
+	case !component.storeOnline:
+		component.runtime.SetHealth(definitions.HealthError, "box offline")
+	case component.device == nil:
+		component.runtime.SetHealth(definitions.HealthError, "device '"+component.DeviceURL+"' not found on the box")
+	default:
+		component.runtime.SetHealth(definitions.HealthOk, "")
+	}
 }
 
 func (component *RollerShutter) handleStateChanged(state *engine.DeviceState) {
@@ -21,6 +21,7 @@ type SlidingGate struct {
 	// @State()
 	Exec definitions.State[bool]
 
+	runtime                definitions.Runtime
 	store                  *engine.Store
 	storeOnlineChangedChan chan bool
 	storeDeviceChangedChan chan *engine.DeviceChange
@@ -32,6 +33,7 @@ type SlidingGate struct {
 }
 
 func (component *SlidingGate) Init(runtime definitions.Runtime) error {
+	component.runtime = runtime
 	component.store = engine.GetStore(component.BoxKey)
 
 	component.storeOnlineChangedChan = make(chan bool)
@@ -119,6 +121,15 @@ func (component *SlidingGate) refreshOnline() {
 	if !online {
 		component.Exec.Set(false)
 	}
+
+	switch {
+	case !component.storeOnline:
+		component.runtime.SetHealth(definitions.HealthError, "box offline")
+	case component.device == nil:
+		component.runtime.SetHealth(definitions.HealthError, "device '"+component.DeviceURL+"' not found on the box")
+	default:
+		component.runtime.SetHealth(definitions.HealthOk, "")
+	}
 }
 
 func (component *SlidingGate) handleExecChanged(arg *engine.ExecChange) {
@@ -6,7 +6,7 @@ import (
 
 // @Plugin(usage="logic")
 type ConstantByte struct {
-	// @Config(name="value")
+	// @Config(name="value" min="0" max="255")
 	ConfigValue int64
 
 	// @State(type="range[0;255]")
@@ -6,7 +6,7 @@ import (
 
 // @Plugin(usage="logic")
 type ConstantPercent struct {
-	// @Config(name="value")
+	// @Config(name="value" min="0" max="100")
 	ConfigValue int64
 
 	// @State(type="range[0;100]")
@@ -7,7 +7,7 @@ import (
 
 // @Plugin(usage="logic")
 type FloatAverage struct {
-	// @Config(description="Nombre d'entrées qui sont utilisées (entre 0 et 8)")
+	// @Config(description="Nombre d'entrées qui sont utilisées (entre 0 et 8)" min="0" max="8")
 	UsedCount int64
 
 	state []float64
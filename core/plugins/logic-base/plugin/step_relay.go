@@ -7,7 +7,7 @@ import (
 // @Plugin(usage="logic")
 type StepRelay struct {
 
-	// @State()
+	// @State(persistent="true")
 	Value definitions.State[bool]
 }
 
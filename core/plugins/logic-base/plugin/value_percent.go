@@ -6,13 +6,13 @@ import (
 
 // @Plugin(usage="logic")
 type ValuePercent struct {
-	// @Config(name="toggleThreshold" description="Valeur partir de laquelle toggle passe à OFF ou ON. Typiquement 1 (Note: peut être écrasé par l'action 'setToggleThreshold'")
+	// @Config(name="toggleThreshold" description="Valeur partir de laquelle toggle passe à OFF ou ON. Typiquement 1 (Note: peut être écrasé par l'action 'setToggleThreshold'" default="1" min="0" max="100")
 	ConfigToggleThreshold int64
 
-	// @Config(name="onValue" description="Valeur définie lorsqu'on passe à ON. Typiquement 100 (Note: peut être écrasé par l'action 'setOnValue'")
+	// @Config(name="onValue" description="Valeur définie lorsqu'on passe à ON. Typiquement 100 (Note: peut être écrasé par l'action 'setOnValue'" default="100" min="0" max="100")
 	ConfigOnValue int64
 
-	// @Config(name="offValue" description="Valeur définie lorsqu'on passe à OFF. Typiquement 0 (Note: peut être écrasé par l'action 'setOffValue'")
+	// @Config(name="offValue" description="Valeur définie lorsqu'on passe à OFF. Typiquement 0 (Note: peut être écrasé par l'action 'setOffValue'" default="0" min="0" max="100")
 	ConfigOffValue int64
 
 	// @State(type="range[0;100]" description="Valeur partir de laquelle toggle passe à OFF ou ON. Typiquement 1")
@@ -24,7 +24,7 @@ type ValuePercent struct {
 	// @State(type="range[0;100]" description="Valeur définie lorsqu'on passe à OFF. Typiquement 0")
 	OffValue definitions.State[int64]
 
-	// @State(type="range[0;100]")
+	// @State(type="range[0;100]" persistent="true")
 	Value definitions.State[int64]
 }
 
@@ -33,7 +33,9 @@ func (component *ValuePercent) Init(runtime definitions.Runtime) error {
 	component.OnValue.Set(component.ConfigOnValue)
 	component.OffValue.Set(component.ConfigOffValue)
 
-	component.Value.Set(component.OffValue.Get())
+	if !component.Value.Restored() {
+		component.Value.Set(component.OffValue.Get())
+	}
 
 	return nil
 }
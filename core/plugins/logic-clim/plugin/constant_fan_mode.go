@@ -7,7 +7,7 @@ import (
 // @Plugin(usage="logic")
 type ConstantFanMode struct {
 
-	// @Config(name="value" description="One of \"auto\", \"high\", \"low\", \"medium\", \"quiet\"")
+	// @Config(name="value" description="One of \"auto\", \"high\", \"low\", \"medium\", \"quiet\"" enum="auto,high,low,medium,quiet")
 	ConfigValue string
 
 	// @State(type="enum{auto,high,low,medium,quiet}")
@@ -13,15 +13,18 @@ type ModeSelector struct {
 	// @State(type="enum{cool,dry,fan-only,heat,heat-cool,off}")
 	Mode definitions.State[string]
 
-	// @State()
+	// @State(persistent="true")
 	Active definitions.State[bool]
 
-	// @State(type="range[17;30]")
+	// @State(type="range[17;30]" persistent="true")
 	Temperature definitions.State[int64]
 }
 
 func (component *ModeSelector) Init(runtime definitions.Runtime) error {
-	component.Temperature.Set(17)
+	if !component.Temperature.Restored() {
+		component.Temperature.Set(17)
+	}
+
 	component.computeMode()
 	return nil
 }
@@ -7,7 +7,7 @@ import (
 // @Plugin(usage="logic")
 type ModeToBool struct {
 
-	// @Config(name="trueValue" description="One of 'cool', 'dry', 'fan-only', 'heat', 'heat-cool', 'off'")
+	// @Config(name="trueValue" description="One of 'cool', 'dry', 'fan-only', 'heat', 'heat-cool', 'off'" enum="cool,dry,fan-only,heat,heat-cool,off")
 	TrueValue string
 
 	// @State()
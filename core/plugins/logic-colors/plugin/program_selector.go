@@ -37,12 +37,14 @@ type ProgramSelector struct {
 	// @Config()
 	Program9 string
 
-	// @State()
+	// @State(persistent="true")
 	Program definitions.State[string]
 }
 
 func (component *ProgramSelector) Init(runtime definitions.Runtime) error {
-	component.Program.Set(component.Program0)
+	if !component.Program.Restored() {
+		component.Program.Set(component.Program0)
+	}
 
 	return nil
 }
@@ -2,54 +2,41 @@ package engine
 
 import (
 	"mylife-home-common/log"
-	"sync"
+	"mylife-home-core-library/definitions"
 	"time"
 )
 
-var logger = log.CreateLogger("mylife:home:core:plugins:logic-selectors:engine")
-
+// Note: timeouts are run by the runtime, serialized with the actions: no need to lock
 type InputManager struct {
+	runtime    definitions.Runtime
+	logger     log.Logger
 	config     map[string]func()
 	eventStack string
-	endWait    *time.Timer
+	endWait    definitions.Timer
 	lastDown   time.Time
-	mux        sync.Mutex
 }
 
-func NewInputManager() *InputManager {
+// Timers are bound to the runtime, they are stopped when the component terminates
+func NewInputManager(runtime definitions.Runtime) *InputManager {
 	return &InputManager{
-		config: make(map[string]func()),
+		runtime: runtime,
+		logger:  runtime.Logger(),
+		config:  make(map[string]func()),
 	}
 }
 
-func (manager *InputManager) Terminate() {
-	manager.mux.Lock()
-	defer manager.mux.Unlock()
-
-	manager.cancelTimeout()
-}
-
 func (manager *InputManager) AddConfig(trigger string, callback func()) {
-	manager.mux.Lock()
-	defer manager.mux.Unlock()
-
 	manager.config[trigger] = callback
 }
 
 func (manager *InputManager) Down() {
-	manager.mux.Lock()
-	defer manager.mux.Unlock()
-
 	// no input end for now
 	manager.cancelTimeout()
 
-	manager.lastDown = time.Now()
+	manager.lastDown = manager.runtime.Clock().Now()
 }
 
 func (manager *InputManager) Up() {
-	manager.mux.Lock()
-	defer manager.mux.Unlock()
-
 	// no input end for now
 	manager.cancelTimeout()
 
@@ -61,7 +48,7 @@ func (manager *InputManager) Up() {
 
 	// Prise en compte de l'event
 	downTs := manager.lastDown
-	upTs := time.Now()
+	upTs := manager.runtime.Clock().Now()
 	manager.lastDown = time.Time{}
 
 	// Ajout de l'event
@@ -72,7 +59,7 @@ func (manager *InputManager) Up() {
 	}
 
 	// Attente de la fin de saisie
-	manager.endWait = time.AfterFunc(300*time.Millisecond, manager.onTimeout)
+	manager.endWait = manager.runtime.AfterFunc(300*time.Millisecond, manager.onTimeout)
 
 }
 
@@ -84,9 +71,6 @@ func (manager *InputManager) cancelTimeout() {
 }
 
 func (manager *InputManager) onTimeout() {
-	manager.mux.Lock()
-	defer manager.mux.Unlock()
-
 	manager.executeEvents()
 
 	manager.eventStack = ""
@@ -94,7 +78,7 @@ func (manager *InputManager) onTimeout() {
 }
 
 func (manager *InputManager) executeEvents() {
-	logger.Debugf("Execute events : '%s'", manager.eventStack)
+	manager.logger.Debugf("Execute events : '%s'", manager.eventStack)
 
 	if callback, ok := manager.config[manager.eventStack]; ok {
 		callback()
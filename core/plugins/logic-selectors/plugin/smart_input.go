@@ -7,8 +7,6 @@ import (
 	"strings"
 )
 
-var logger = log.CreateLogger("mylife:home:core:plugins:logic-selectors:smart-input")
-
 // @Plugin(usage="logic")
 type SmartInput struct {
 
@@ -33,6 +31,7 @@ type SmartInput struct {
 	// @State()
 	Output3 definitions.State[bool]
 
+	logger  log.Logger
 	manager *engine.InputManager
 }
 
@@ -45,14 +44,15 @@ func (component *SmartInput) processTrigger(input string, callback func()) {
 		return
 	}
 
-	logger.Debugf("Configuring triggers: %+v", triggers)
+	component.logger.Debugf("Configuring triggers: %+v", triggers)
 	for _, trigger := range triggers {
 		component.manager.AddConfig(trigger, callback)
 	}
 }
 
 func (component *SmartInput) Init(runtime definitions.Runtime) error {
-	component.manager = engine.NewInputManager()
+	component.logger = runtime.Logger()
+	component.manager = engine.NewInputManager(runtime)
 
 	component.processTrigger(component.Triggers0, component.executeOutput0)
 	component.processTrigger(component.Triggers1, component.executeOutput1)
@@ -63,8 +63,7 @@ func (component *SmartInput) Init(runtime definitions.Runtime) error {
 }
 
 func (component *SmartInput) Terminate() {
-	component.manager.Terminate()
-	// Noop
+	// Noop: pending timeout is stopped by the runtime
 }
 
 // @Action()
@@ -0,0 +1,59 @@
+package plugin
+
+import (
+	"mylife-home-core-library/plugintest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSmartInput(t *testing.T) {
+	pluginType := plugintest.FindOrSkip(t, "logic-selectors.smart-input")
+
+	const short = 100 * time.Millisecond
+	const long = time.Second
+
+	tests := []struct {
+		name    string
+		presses []time.Duration
+		output  string // empty if none fires
+	}{
+		{"short press fires Output0", []time.Duration{short}, "output0"},
+		{"long press then short press fires Output1", []time.Duration{long, short}, "output1"},
+		{"two short presses fire Output2", []time.Duration{short, short}, "output2"},
+		{"long press fires Output3", []time.Duration{long}, "output3"},
+		{"unknown sequence fires nothing", []time.Duration{short, long}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := plugintest.New(t, pluginType, map[string]any{
+				"triggers0": "s",
+				"triggers1": "ls",
+				"triggers2": "ss",
+				"triggers3": "l|sss",
+			})
+
+			for _, duration := range test.presses {
+				h.Action("action", true)
+				h.Advance(duration)
+				h.Action("action", false)
+				h.Advance(100 * time.Millisecond)
+			}
+
+			// nothing before the end of the input
+			assert.Empty(t, h.Changes())
+
+			h.Advance(time.Second)
+
+			for _, output := range []string{"output0", "output1", "output2", "output3"} {
+				if output == test.output {
+					assert.Equal(t, []any{true, false}, h.Values(output), output)
+				} else {
+					assert.Empty(t, h.Values(output), output)
+				}
+			}
+		})
+	}
+}
@@ -1,14 +1,11 @@
 package engine
 
 import (
-	"context"
 	"mylife-home-common/log"
-	"mylife-home-common/tools"
+	"mylife-home-core-library/definitions"
 	"time"
 )
 
-var logger = log.CreateLogger("mylife:home:core:plugins:logic-timers:engine")
-
 var timerSuffixes = map[string]int{
 	"ms": 1,
 	"s":  1000,
@@ -44,21 +41,26 @@ func (arg *ProgressArg) ProgressTime() time.Duration {
 	return arg.progressTime
 }
 
+// Note: waits are run by the runtime timers, serialized with the actions: no need to lock
 type Program[Value any] struct {
 	// readonly after setup
+	runtime   definitions.Runtime
+	logger    log.Logger
 	steps     []step
 	totalTime time.Duration
 
-	onProgress tools.Subject[*ProgressArg]
-	onRunning  tools.SubjectValue[bool]
-	onOutput   tools.Subject[*OutputArg[Value]]
+	onProgress func(arg *ProgressArg)
+	onRunning  func(running bool)
+	onOutput   func(arg *OutputArg[Value])
+
+	run *runningData // nil if not running
 }
 
-func NewProgram[Value any](parseOutputValue func(value string) (Value, error), source string, canWait bool) *Program[Value] {
+// Timers are bound to the runtime, they are stopped when the component terminates
+func NewProgram[Value any](runtime definitions.Runtime, parseOutputValue func(value string) (Value, error), source string, canWait bool) *Program[Value] {
 	program := &Program[Value]{
-		onProgress: tools.MakeSubject[*ProgressArg](),
-		onRunning:  tools.MakeSubjectValue[bool](false),
-		onOutput:   tools.MakeSubject[*OutputArg[Value]](),
+		runtime: runtime,
+		logger:  runtime.Logger(),
 	}
 
 	parser := ProgramParser[Value]{
@@ -70,7 +72,7 @@ func NewProgram[Value any](parseOutputValue func(value string) (Value, error), s
 
 	steps, err := parser.parse()
 	if err != nil {
-		logger.WithError(err).Error("Invalid program. Will fallback to empty program.")
+		program.logger.WithError(err).Error("Invalid program. Will fallback to empty program.")
 		steps = make([]step, 0)
 	}
 
@@ -93,55 +95,75 @@ func (program *Program[Value]) TotalTime() time.Duration {
 	return program.totalTime
 }
 
-func (program *Program[Value]) OnProgress() tools.Observable[*ProgressArg] {
-	return program.onProgress
+func (program *Program[Value]) OnProgress(callback func(arg *ProgressArg)) {
+	program.onProgress = callback
 }
 
-func (program *Program[Value]) OnRunning() tools.ObservableValue[bool] {
-	return program.onRunning
+func (program *Program[Value]) OnRunning(callback func(running bool)) {
+	program.onRunning = callback
 }
 
-func (program *Program[Value]) OnOutput() tools.Observable[*OutputArg[Value]] {
-	return program.onOutput
+func (program *Program[Value]) OnOutput(callback func(arg *OutputArg[Value])) {
+	program.onOutput = callback
 }
 
-// true if run to the end, false if interrupted
-// Note: start  several runs concurrently may end up with Observables mismatches
-func (program *Program[Value]) Run(exit context.Context) bool {
-	program.onRunning.Update(true)
-	defer program.onRunning.Update(false)
-	defer program.updateProgress(0)
+func (program *Program[Value]) Running() bool {
+	return program.run != nil
+}
 
-	run := newRunningData(program, exit)
+// Execute the steps until the first wait, the next ones are executed by the runtime timers.
+// Programs without wait are run to the end before it returns.
+//
+// Note: the program must not be running
+func (program *Program[Value]) Start() {
+	program.run = newRunningData(program, program.end)
+	program.notifyRunning(true)
 
-	for _, step := range program.steps {
-		step.Execute(run)
+	program.run.resume()
+}
 
-		if exit.Err() != nil {
-			return false
-		}
+// Interrupt the program, returns false if it was not running
+func (program *Program[Value]) Stop() bool {
+	if program.run == nil {
+		return false
 	}
 
+	program.run.interrupt()
+	program.end()
+
 	return true
+}
 
+func (program *Program[Value]) end() {
+	program.run = nil
+
+	program.updateProgress(0)
+	program.notifyRunning(false)
+}
+
+func (program *Program[Value]) notifyRunning(running bool) {
+	if program.onRunning != nil {
+		program.onRunning(running)
+	}
 }
 
 func (program *Program[Value]) updateProgress(progressTime time.Duration) {
-	if program.totalTime == 0 {
+	if program.totalTime == 0 || program.onProgress == nil {
 		// do not emit progress on sync programs
 		return
 	}
 
-	program.onProgress.Notify(&ProgressArg{
+	program.onProgress(&ProgressArg{
 		percent:      float64(progressTime.Milliseconds()) / float64(program.totalTime.Milliseconds()) * 100,
 		progressTime: progressTime,
 	})
 }
 
-// Can be runned from any goroutine
 func (program *Program[Value]) setOutput(index int, value Value) {
-	program.onOutput.Notify(&OutputArg[Value]{
-		index: index,
-		value: value,
-	})
+	if program.onOutput != nil {
+		program.onOutput(&OutputArg[Value]{
+			index: index,
+			value: value,
+		})
+	}
 }
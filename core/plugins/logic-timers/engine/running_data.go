@@ -1,25 +1,94 @@
 package engine
 
 import (
-	"context"
+	"mylife-home-common/log"
+	"mylife-home-core-library/definitions"
 	"time"
 )
 
 type runningData struct {
 	// definition
+	runtime        definitions.Runtime
+	logger         log.Logger
+	steps          []step
 	startTime      time.Time
 	totalTime      time.Duration
 	updateProgress func(time.Duration)
-	exit           context.Context
+	onEnd          func()
+
+	// progress
+	next   int               // index of the next step to execute
+	wait   definitions.Timer // nil if no wait is pending
+	remain time.Duration     // remaining time of the pending wait
 }
 
-func newRunningData[Value any](program *Program[Value], exit context.Context) *runningData {
+func newRunningData[Value any](program *Program[Value], onEnd func()) *runningData {
 	return &runningData{
-		startTime:      time.Now(),
+		runtime:        program.runtime,
+		logger:         program.logger,
+		steps:          program.steps,
+		startTime:      program.runtime.Clock().Now(),
 		totalTime:      program.totalTime,
 		updateProgress: program.updateProgress,
-		exit:           exit,
+		onEnd:          onEnd,
+	}
+}
+
+// Execute the steps until a wait or the end of the program
+func (run *runningData) resume() {
+	for run.next < len(run.steps) {
+		step := run.steps[run.next]
+		run.next += 1
+
+		if waiting := step.Execute(run); waiting {
+			return
+		}
+	}
+
+	run.onEnd()
+}
+
+// Wait before executing the next steps
+func (run *runningData) sleep(delay time.Duration) {
+	run.remain = delay
+	run.sleepNext()
+}
+
+// Sleep by periods of one second at most, to report progress
+func (run *runningData) sleepNext() {
+	var sleep time.Duration
+	if run.remain < time.Second {
+		sleep = run.remain
+	} else {
+		sleep = time.Second
 	}
+
+	run.remain = run.remain - sleep
+	run.wait = run.runtime.AfterFunc(sleep, run.wake)
+}
+
+func (run *runningData) wake() {
+	run.wait = nil
+	run.computeProgress()
+
+	if run.remain > 0 {
+		run.sleepNext()
+		return
+	}
+
+	run.logger.Debug("WaitStep done")
+	run.resume()
+}
+
+// Stop the pending wait, the next steps are not executed
+func (run *runningData) interrupt() {
+	if run.wait == nil {
+		return
+	}
+
+	run.wait.Stop()
+	run.wait = nil
+	run.logger.Debug("WaitStep interrupted")
 }
 
 func (run *runningData) computeProgress() {
@@ -27,10 +96,6 @@ func (run *runningData) computeProgress() {
 		panic("computeProgress called but totalTime = 0")
 	}
 
-	progressTime := time.Since(run.startTime)
+	progressTime := run.runtime.Clock().Now().Sub(run.startTime)
 	run.updateProgress(progressTime)
 }
-
-func (run *runningData) interrupted() <-chan struct{} {
-	return run.exit.Done()
-}
@@ -8,7 +8,8 @@ import (
 )
 
 type step interface {
-	Execute(run *runningData)
+	// Returns true if the next steps must wait (they are resumed by the runtime timers)
+	Execute(run *runningData) bool
 }
 
 var _ step = (*waitStep)(nil)
@@ -51,33 +52,15 @@ func newWaitStep(arg string) (step, error) {
 	return s, nil
 }
 
-func (s *waitStep) Execute(run *runningData) {
-	logger.Debugf("Execute WaitStep: sleep %s", s.delay)
+func (s *waitStep) Execute(run *runningData) bool {
+	run.logger.Debugf("Execute WaitStep: sleep %s", s.delay)
 
-	remain := s.delay
-
-	for remain > 0 {
-		var sleep time.Duration
-		if remain < time.Second {
-			sleep = remain
-		} else {
-			sleep = time.Second
-		}
-
-		remain = remain - sleep
-
-		select {
-		case <-time.After(sleep):
-
-		case <-run.interrupted():
-			logger.Debug("WaitStep interrupted")
-			return
-		}
-
-		run.computeProgress()
+	if s.delay <= 0 {
+		return false
 	}
 
-	logger.Debug("WaitStep done")
+	run.sleep(s.delay)
+	return true
 }
 
 var _ step = (*setOutputStep[int])(nil)
@@ -96,10 +79,11 @@ func newSetOutputStep[Value any](setOutput func(index int, value Value), index i
 	}
 }
 
-func (s *setOutputStep[Value]) Execute(run *runningData) {
-	logger.Debugf("Execute SetOutputStep: set output #%d to '%+v'", s.index, s.value)
+func (s *setOutputStep[Value]) Execute(run *runningData) bool {
+	run.logger.Debugf("Execute SetOutputStep: set output #%d to '%+v'", s.index, s.value)
 
 	s.setOutput(s.index, s.value)
+	return false
 }
 
 var _ step = (*setAllOutputsStep[int])(nil)
@@ -116,10 +100,12 @@ func newSetAllOutputsStep[Value any](setOutput func(index int, value Value), val
 	}
 }
 
-func (s *setAllOutputsStep[Value]) Execute(run *runningData) {
-	logger.Debugf("Execute SetAllOutputStep: set all outputs to '%+v'", s.value)
+func (s *setAllOutputsStep[Value]) Execute(run *runningData) bool {
+	run.logger.Debugf("Execute SetAllOutputStep: set all outputs to '%+v'", s.value)
 
 	for index := 0; index < outputCount; index += 1 {
 		s.setOutput(index, s.value)
 	}
+
+	return false
 }
@@ -8,8 +8,6 @@ import (
 	"github.com/robfig/cron/v3"
 )
 
-var logger = log.CreateLogger("mylife:home:core:plugins:logic-timers")
-
 // @Plugin(usage="logic")
 type Scheduler struct {
 
@@ -28,24 +26,26 @@ type Scheduler struct {
 	// @State(description="Timestamp JS avant le prochain déclenchement")
 	NextDate definitions.State[float64]
 
-	cronEngine *cron.Cron
-	cronJobId  cron.EntryID // 0 = no job
+	logger   log.Logger
+	runtime  definitions.Runtime
+	schedule cron.Schedule
+	timer    definitions.Timer // next trigger
 }
 
+// Note: the job is run by the runtime timers, serialized with the actions
 func (component *Scheduler) Init(runtime definitions.Runtime) error {
+	component.logger = runtime.Logger()
+	component.runtime = runtime
 	component.Enabled.Set(true)
 
-	component.cronEngine = cron.New()
-
 	if err := component.setupScheduler(); err != nil {
-		logger.WithError(err).Error("Error initializing scheduler")
+		component.logger.WithError(err).Error("Error initializing scheduler")
 		return nil
 	}
 
-	component.cronEngine.Start()
-	component.refreshNextDate()
+	component.scheduleNext()
 
-	logger.Debug("Scheduler starting job")
+	component.logger.Debug("Scheduler starting job")
 	return nil
 }
 
@@ -63,56 +63,69 @@ func (component *Scheduler) setupScheduler() error {
 
 	component.Schedule.Set(*value)
 
-	cronJobId, err := component.cronEngine.AddFunc(component.Cron, component.onTick)
+	schedule, err := cron.ParseStandard(component.Cron)
 	if err != nil {
 		return err
 	}
 
-	component.cronJobId = cronJobId
+	component.schedule = schedule
 	return nil
 }
 
-func (component *Scheduler) Terminate() {
-	logger.Debug("Scheduler stopping job")
+// Apply the new Cron, keeping the enabled state
+func (component *Scheduler) Reconfigure() error {
+	if component.timer != nil {
+		component.timer.Stop()
+		component.timer = nil
+	}
+
+	if err := component.setupScheduler(); err != nil {
+		return err
+	}
+
+	component.scheduleNext()
 
-	ctx := component.cronEngine.Stop()
-	<-ctx.Done()
+	component.logger.Debug("Scheduler job reconfigured")
+	return nil
+}
+
+func (component *Scheduler) Terminate() {
+	// Noop: next trigger is stopped by the runtime
+	component.logger.Debug("Scheduler stopping job")
 }
 
 // @Action(description="Permet de désactiver le scheduler. Le trigger reste alors à \"false\"")
 func (component *Scheduler) Disable(arg bool) {
 	value := !arg
 	component.Enabled.Set(value)
-	logger.Debugf("Scheduler changed job state to '%t'", value)
+	component.logger.Debugf("Scheduler changed job state to '%t'", value)
 }
 
 func (component *Scheduler) onTick() {
 	if component.Enabled.Get() {
-		logger.Debug("Scheduler trigger")
+		component.logger.Debug("Scheduler trigger")
 
 		component.Trigger.Set(true)
 		component.Trigger.Set(false)
 	} else {
-		logger.Debug("Skipping scheduler trigger (disabled)")
+		component.logger.Debug("Skipping scheduler trigger (disabled)")
 	}
 
-	component.refreshNextDate()
+	component.scheduleNext()
 }
 
-func (component *Scheduler) refreshNextDate() {
-	// paranoia
-	if component.cronJobId == 0 {
-		return
-	}
+func (component *Scheduler) scheduleNext() {
+	now := component.runtime.Clock().Now()
+	next := component.schedule.Next(now)
 
-	entry := component.cronEngine.Entry(component.cronJobId)
-	// paranoia
-	if !entry.Valid() {
+	// paranoia: schedule that never triggers
+	if next.IsZero() {
+		component.timer = nil
 		return
 	}
 
-	// Javascript timestamp
-	nextTs := float64(entry.Next.UnixMilli())
+	component.timer = component.runtime.AfterFunc(next.Sub(now), component.onTick)
 
-	component.NextDate.Set(nextTs)
+	// Javascript timestamp
+	component.NextDate.Set(float64(next.UnixMilli()))
 }
@@ -1,12 +1,10 @@
 package plugin
 
 import (
-	"context"
 	"fmt"
 	"math"
 	"mylife-home-core-library/definitions"
 	"mylife-home-core-plugins-logic-timers/engine"
-	"sync"
 )
 
 // @Plugin(usage="logic")
@@ -67,14 +65,9 @@ type SmartTimerBinary struct {
 	triggerProgram *engine.Program[bool]
 	cancelProgram  *engine.Program[bool]
 	outputs        []definitions.State[bool] // easily address output
-	onProgressChan chan *engine.ProgressArg
-	onRunningChan  chan bool
-	onOutputChan   chan *engine.OutputArg[bool]
-	pumpExited     chan struct{}
-	runMux         sync.Mutex
-	run            *runData
 }
 
+// Note: programs run on the runtime timers, serialized with the actions: no need to lock
 func (component *SmartTimerBinary) Init(runtime definitions.Runtime) error {
 
 	component.TotalTime.Set(0)
@@ -94,83 +87,34 @@ func (component *SmartTimerBinary) Init(runtime definitions.Runtime) error {
 		component.Output9,
 	}
 
-	component.initProgram = engine.NewProgram[bool](component.parseOutputValue, component.ConfigInitProgram, false)
-	component.triggerProgram = engine.NewProgram[bool](component.parseOutputValue, component.ConfigTriggerProgram, true)
-	component.cancelProgram = engine.NewProgram[bool](component.parseOutputValue, component.ConfigCancelProgram, false)
+	component.initProgram = engine.NewProgram[bool](runtime, component.parseOutputValue, component.ConfigInitProgram, false)
+	component.triggerProgram = engine.NewProgram[bool](runtime, component.parseOutputValue, component.ConfigTriggerProgram, true)
+	component.cancelProgram = engine.NewProgram[bool](runtime, component.parseOutputValue, component.ConfigCancelProgram, false)
 
-	component.onProgressChan = make(chan *engine.ProgressArg)
-	component.onRunningChan = make(chan bool)
-	component.onOutputChan = make(chan *engine.OutputArg[bool])
-
-	component.pumpExited = make(chan struct{})
-	go component.pump()
-
-	component.triggerProgram.OnProgress().Subscribe(component.onProgressChan)
-	component.triggerProgram.OnRunning().Subscribe(component.onRunningChan, false)
-	component.initProgram.OnOutput().Subscribe(component.onOutputChan)
-	component.triggerProgram.OnOutput().Subscribe(component.onOutputChan)
-	component.cancelProgram.OnOutput().Subscribe(component.onOutputChan)
+	component.triggerProgram.OnProgress(component.onProgress)
+	component.triggerProgram.OnRunning(component.Running.Set)
+	component.initProgram.OnOutput(component.onOutput)
+	component.triggerProgram.OnOutput(component.onOutput)
+	component.cancelProgram.OnOutput(component.onOutput)
 
 	component.TotalTime.Set(component.triggerProgram.TotalTime().Seconds())
 
-	component.initProgram.Run(context.Background())
+	component.initProgram.Start()
 
 	return nil
 }
 
 func (component *SmartTimerBinary) Terminate() {
-	component.runMux.Lock()
 	component.clear()
-	component.runMux.Unlock()
-
-	component.triggerProgram.OnProgress().Unsubscribe(component.onProgressChan)
-	component.triggerProgram.OnRunning().Unsubscribe(component.onRunningChan)
-	component.initProgram.OnOutput().Unsubscribe(component.onOutputChan)
-	component.triggerProgram.OnOutput().Unsubscribe(component.onOutputChan)
-	component.cancelProgram.OnOutput().Unsubscribe(component.onOutputChan)
-
-	close(component.onProgressChan)
-	close(component.onRunningChan)
-	close(component.onOutputChan)
-
-	<-component.pumpExited
 }
 
-func (component *SmartTimerBinary) pump() {
-	defer close(component.pumpExited)
-
-	onProgressChan := component.onProgressChan
-	onRunningChan := component.onRunningChan
-	onOutputChan := component.onOutputChan
-
-	for onProgressChan != nil || onRunningChan != nil || onOutputChan != nil {
-		select {
-		case progress, ok := <-onProgressChan:
-			if !ok {
-				onProgressChan = nil
-				continue
-			}
-
-			component.Progress.Set(int64(math.Round(progress.Percent())))
-			component.ProgressTime.Set(progress.ProgressTime().Seconds())
-
-		case running, ok := <-onRunningChan:
-			if !ok {
-				onRunningChan = nil
-				continue
-			}
-
-			component.Running.Set(running)
-
-		case output, ok := <-onOutputChan:
-			if !ok {
-				onOutputChan = nil
-				continue
-			}
+func (component *SmartTimerBinary) onProgress(progress *engine.ProgressArg) {
+	component.Progress.Set(int64(math.Round(progress.Percent())))
+	component.ProgressTime.Set(progress.ProgressTime().Seconds())
+}
 
-			component.outputs[output.Index()].Set(output.Value())
-		}
-	}
+func (component *SmartTimerBinary) onOutput(output *engine.OutputArg[bool]) {
+	component.outputs[output.Index()].Set(output.Value())
 }
 
 // @Action
@@ -179,11 +123,8 @@ func (component *SmartTimerBinary) Trigger(arg bool) {
 		return
 	}
 
-	component.runMux.Lock()
-	defer component.runMux.Unlock()
-
 	component.clear()
-	component.start()
+	component.triggerProgram.Start()
 }
 
 // @Action
@@ -192,9 +133,6 @@ func (component *SmartTimerBinary) Cancel(arg bool) {
 		return
 	}
 
-	component.runMux.Lock()
-	defer component.runMux.Unlock()
-
 	component.clear()
 }
 
@@ -204,62 +142,18 @@ func (component *SmartTimerBinary) Toggle(arg bool) {
 		return
 	}
 
-	component.runMux.Lock()
-	defer component.runMux.Unlock()
-
-	if component.run != nil {
+	if component.triggerProgram.Running() {
 		component.clear()
 	} else {
-		component.start()
+		component.triggerProgram.Start()
 	}
 }
 
-// Call inside the lock
-func (component *SmartTimerBinary) start() {
-	ctx, cancel := context.WithCancel(context.Background())
-	exit := make(chan struct{})
-
-	go component.entry(ctx, exit)
-
-	component.run = &runData{
-		cancel: cancel,
-		exit:   exit,
-	}
-}
-
-// Call inside the lock
+// Interrupt the trigger program if it is running, then run the cancel program
 func (component *SmartTimerBinary) clear() {
-	run := component.run
-	if run == nil {
-		return
+	if component.triggerProgram.Stop() {
+		component.cancelProgram.Start()
 	}
-
-	run.cancel()
-	<-run.exit
-
-	// Each lock wants to clear run if started
-	// This means we can set to nil only if no lock is currently taken
-	// Which means nobody is trying to clear us (in which case it will
-	// set itself component.run to nil)
-	if component.runMux.TryLock() {
-		component.run = nil
-		component.runMux.Unlock()
-	}
-}
-
-func (component *SmartTimerBinary) entry(ctx context.Context, exit chan<- struct{}) {
-	defer close(exit)
-
-	ret := component.triggerProgram.Run(ctx)
-
-	// interrupted
-	if !ret {
-		component.cancelProgram.Run(context.Background())
-	}
-
-	// set run as nil
-	// FIXME: lock !?
-	component.run = nil
 }
 
 func (component *SmartTimerBinary) parseOutputValue(arg string) (bool, error) {
@@ -1,13 +1,11 @@
 package plugin
 
 import (
-	"context"
 	"fmt"
 	"math"
 	"mylife-home-core-library/definitions"
 	"mylife-home-core-plugins-logic-timers/engine"
 	"strconv"
-	"sync"
 )
 
 // @Plugin(usage="logic")
@@ -68,14 +66,9 @@ type SmartTimerPercent struct {
 	triggerProgram *engine.Program[int64]
 	cancelProgram  *engine.Program[int64]
 	outputs        []definitions.State[int64] // easily address output
-	onProgressChan chan *engine.ProgressArg
-	onRunningChan  chan bool
-	onOutputChan   chan *engine.OutputArg[int64]
-	pumpExited     chan struct{}
-	runMux         sync.Mutex
-	run            *runData
 }
 
+// Note: programs run on the runtime timers, serialized with the actions: no need to lock
 func (component *SmartTimerPercent) Init(runtime definitions.Runtime) error {
 
 	component.TotalTime.Set(0)
@@ -95,83 +88,34 @@ func (component *SmartTimerPercent) Init(runtime definitions.Runtime) error {
 		component.Output9,
 	}
 
-	component.initProgram = engine.NewProgram[int64](component.parseOutputValue, component.ConfigInitProgram, false)
-	component.triggerProgram = engine.NewProgram[int64](component.parseOutputValue, component.ConfigTriggerProgram, true)
-	component.cancelProgram = engine.NewProgram[int64](component.parseOutputValue, component.ConfigCancelProgram, false)
+	component.initProgram = engine.NewProgram[int64](runtime, component.parseOutputValue, component.ConfigInitProgram, false)
+	component.triggerProgram = engine.NewProgram[int64](runtime, component.parseOutputValue, component.ConfigTriggerProgram, true)
+	component.cancelProgram = engine.NewProgram[int64](runtime, component.parseOutputValue, component.ConfigCancelProgram, false)
 
-	component.onProgressChan = make(chan *engine.ProgressArg)
-	component.onRunningChan = make(chan bool)
-	component.onOutputChan = make(chan *engine.OutputArg[int64])
-
-	component.pumpExited = make(chan struct{})
-	go component.pump()
-
-	component.triggerProgram.OnProgress().Subscribe(component.onProgressChan)
-	component.triggerProgram.OnRunning().Subscribe(component.onRunningChan, false)
-	component.initProgram.OnOutput().Subscribe(component.onOutputChan)
-	component.triggerProgram.OnOutput().Subscribe(component.onOutputChan)
-	component.cancelProgram.OnOutput().Subscribe(component.onOutputChan)
+	component.triggerProgram.OnProgress(component.onProgress)
+	component.triggerProgram.OnRunning(component.Running.Set)
+	component.initProgram.OnOutput(component.onOutput)
+	component.triggerProgram.OnOutput(component.onOutput)
+	component.cancelProgram.OnOutput(component.onOutput)
 
 	component.TotalTime.Set(component.triggerProgram.TotalTime().Seconds())
 
-	component.initProgram.Run(context.Background())
+	component.initProgram.Start()
 
 	return nil
 }
 
 func (component *SmartTimerPercent) Terminate() {
-	component.runMux.Lock()
 	component.clear()
-	component.runMux.Unlock()
-
-	component.triggerProgram.OnProgress().Unsubscribe(component.onProgressChan)
-	component.triggerProgram.OnRunning().Unsubscribe(component.onRunningChan)
-	component.initProgram.OnOutput().Unsubscribe(component.onOutputChan)
-	component.triggerProgram.OnOutput().Unsubscribe(component.onOutputChan)
-	component.cancelProgram.OnOutput().Unsubscribe(component.onOutputChan)
-
-	close(component.onProgressChan)
-	close(component.onRunningChan)
-	close(component.onOutputChan)
-
-	<-component.pumpExited
 }
 
-func (component *SmartTimerPercent) pump() {
-	defer close(component.pumpExited)
-
-	onProgressChan := component.onProgressChan
-	onRunningChan := component.onRunningChan
-	onOutputChan := component.onOutputChan
-
-	for onProgressChan != nil || onRunningChan != nil || onOutputChan != nil {
-		select {
-		case progress, ok := <-onProgressChan:
-			if !ok {
-				onProgressChan = nil
-				continue
-			}
-
-			component.Progress.Set(int64(math.Round(progress.Percent())))
-			component.ProgressTime.Set(progress.ProgressTime().Seconds())
-
-		case running, ok := <-onRunningChan:
-			if !ok {
-				onRunningChan = nil
-				continue
-			}
-
-			component.Running.Set(running)
-
-		case output, ok := <-onOutputChan:
-			if !ok {
-				onOutputChan = nil
-				continue
-			}
+func (component *SmartTimerPercent) onProgress(progress *engine.ProgressArg) {
+	component.Progress.Set(int64(math.Round(progress.Percent())))
+	component.ProgressTime.Set(progress.ProgressTime().Seconds())
+}
 
-			component.outputs[output.Index()].Set(output.Value())
-		}
-	}
+func (component *SmartTimerPercent) onOutput(output *engine.OutputArg[int64]) {
+	component.outputs[output.Index()].Set(output.Value())
 }
 
 // @Action
@@ -180,11 +124,8 @@ func (component *SmartTimerPercent) Trigger(arg bool) {
 		return
 	}
 
-	component.runMux.Lock()
-	defer component.runMux.Unlock()
-
 	component.clear()
-	component.start()
+	component.triggerProgram.Start()
 }
 
 // @Action
@@ -193,9 +134,6 @@ func (component *SmartTimerPercent) Cancel(arg bool) {
 		return
 	}
 
-	component.runMux.Lock()
-	defer component.runMux.Unlock()
-
 	component.clear()
 }
 
@@ -205,62 +143,18 @@ func (component *SmartTimerPercent) Toggle(arg bool) {
 		return
 	}
 
-	component.runMux.Lock()
-	defer component.runMux.Unlock()
-
-	if component.run != nil {
+	if component.triggerProgram.Running() {
 		component.clear()
 	} else {
-		component.start()
+		component.triggerProgram.Start()
 	}
 }
 
-// Call inside the lock
-func (component *SmartTimerPercent) start() {
-	ctx, cancel := context.WithCancel(context.Background())
-	exit := make(chan struct{})
-
-	go component.entry(ctx, exit)
-
-	component.run = &runData{
-		cancel: cancel,
-		exit:   exit,
-	}
-}
-
-// Call inside the lock
+// Interrupt the trigger program if it is running, then run the cancel program
 func (component *SmartTimerPercent) clear() {
-	run := component.run
-	if run == nil {
-		return
+	if component.triggerProgram.Stop() {
+		component.cancelProgram.Start()
 	}
-
-	run.cancel()
-	<-run.exit
-
-	// Each lock wants to clear run if started
-	// This means we can set to nil only if no lock is currently taken
-	// Which means nobody is trying to clear us (in which case it will
-	// set itself component.run to nil)
-	if component.runMux.TryLock() {
-		component.run = nil
-		component.runMux.Unlock()
-	}
-}
-
-func (component *SmartTimerPercent) entry(ctx context.Context, exit chan<- struct{}) {
-	defer close(exit)
-
-	ret := component.triggerProgram.Run(ctx)
-
-	// interrupted
-	if !ret {
-		component.cancelProgram.Run(context.Background())
-	}
-
-	// set run as nil
-	// FIXME: lock !?
-	component.run = nil
 }
 
 func (component *SmartTimerPercent) parseOutputValue(arg string) (int64, error) {
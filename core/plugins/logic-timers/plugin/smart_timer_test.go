@@ -0,0 +1,105 @@
+package plugin
+
+import (
+	"mylife-home-core-library/plugintest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSmartTimerBinary(t *testing.T) {
+	h := plugintest.New(t, plugintest.FindOrSkip(t, "logic-timers.smart-timer-binary"), map[string]any{
+		"initProgram":    "o*-off",
+		"triggerProgram": "o0-on w-1s o0-off o1-on w-2s o1-off",
+		"cancelProgram":  "o*-off",
+	})
+
+	assert.Equal(t, 3.0, h.State("totalTime"))
+	assert.Equal(t, false, h.State("output0"))
+	h.ClearChanges()
+
+	h.Action("trigger", true)
+	assert.Equal(t, true, h.State("running"))
+	assert.Equal(t, true, h.State("output0"))
+
+	h.Advance(time.Second)
+	assert.Equal(t, false, h.State("output0"))
+	assert.Equal(t, true, h.State("output1"))
+	assert.Equal(t, int64(33), h.State("progress"))
+	assert.Equal(t, 1.0, h.State("progressTime"))
+
+	h.Advance(2 * time.Second)
+	assert.Equal(t, false, h.State("running"))
+	assert.Equal(t, int64(0), h.State("progress"))
+	assert.Equal(t, []any{true, false}, h.Values("output0"))
+	assert.Equal(t, []any{true, false}, h.Values("output1"))
+	assert.Equal(t, []any{true, false}, h.Values("running"))
+}
+
+func TestSmartTimerBinaryInterrupted(t *testing.T) {
+	h := plugintest.New(t, plugintest.FindOrSkip(t, "logic-timers.smart-timer-binary"), map[string]any{
+		"initProgram":    "",
+		"triggerProgram": "o0-on w-1s o1-on w-1s o*-off",
+		"cancelProgram":  "o2-on",
+	})
+
+	// Toggle starts, then cancels
+	h.Action("toggle", true)
+	h.Advance(500 * time.Millisecond)
+	h.Action("toggle", true)
+	assert.Equal(t, false, h.State("running"))
+	assert.Equal(t, true, h.State("output2"))
+
+	h.Advance(time.Minute)
+	assert.Equal(t, []any{true}, h.Values("output0"))
+	assert.Empty(t, h.Values("output1"))
+
+	// Trigger restarts the program
+	h.ClearChanges()
+	h.Action("trigger", true)
+	h.Advance(1500 * time.Millisecond)
+	h.Action("trigger", true)
+	assert.Equal(t, []any{true, false, true}, h.Values("running"))
+	assert.Equal(t, []any{true}, h.Values("output1"))
+	assert.Empty(t, h.Values("output2")) // already on
+
+	h.Action("cancel", true)
+	h.Advance(time.Minute)
+	assert.Equal(t, []any{true, false, true, false}, h.Values("running"))
+	assert.Len(t, h.Values("output1"), 1)
+}
+
+func TestSmartTimerPercent(t *testing.T) {
+	h := plugintest.New(t, plugintest.FindOrSkip(t, "logic-timers.smart-timer-percent"), map[string]any{
+		"initProgram":    "o*-0",
+		"triggerProgram": "o0-50 w-1s o0-100 w-1s o0-0",
+		"cancelProgram":  "o*-0",
+	})
+
+	h.ClearChanges()
+
+	h.Action("trigger", true)
+	h.Advance(2 * time.Second)
+	assert.Equal(t, []any{int64(50), int64(100), int64(0)}, h.Values("output0"))
+	assert.Equal(t, []any{int64(50), int64(100), int64(0)}, h.Values("progress"))
+}
+
+func TestScheduler(t *testing.T) {
+	h := plugintest.New(t, plugintest.FindOrSkip(t, "logic-timers.scheduler"), map[string]any{
+		"cron": "0 * * * *",
+	})
+
+	hour := plugintest.Epoch.Add(time.Hour)
+	assert.Equal(t, float64(hour.UnixMilli()), h.State("nextDate"))
+	assert.Equal(t, "At 0 minutes past the hour", h.State("schedule"))
+
+	h.Advance(time.Hour)
+	assert.Equal(t, []any{true, false}, h.Values("trigger"))
+	assert.Equal(t, float64(hour.Add(time.Hour).UnixMilli()), h.State("nextDate"))
+
+	h.Action("disable", true)
+	h.Advance(time.Hour)
+	assert.Equal(t, []any{true, false}, h.Values("trigger"))
+	assert.Equal(t, float64(hour.Add(2*time.Hour).UnixMilli()), h.State("nextDate"))
+}
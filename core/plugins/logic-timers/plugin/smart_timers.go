@@ -1,6 +0,0 @@
-package plugin
-
-type runData struct {
-	cancel func()
-	exit   chan struct{}
-}
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"fmt"
+	"mylife-home-ctl/pkg/version"
+)
+
+func main() {
+	fmt.Println(version.Value)
+}
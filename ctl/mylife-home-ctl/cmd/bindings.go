@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"mylife-home-common/bus"
+)
+
+// Same as core store.BindingConfig
+type bindingConfig struct {
+	SourceComponent string          `json:"sourceComponent"`
+	SourceState     string          `json:"sourceState"`
+	TargetComponent string          `json:"targetComponent"`
+	TargetAction    string          `json:"targetAction"`
+	Transforms      json.RawMessage `json:"transforms,omitempty"`
+}
+
+var bindingTransforms string
+
+var bindingsCmd = &cobra.Command{
+	Use:   "bindings",
+	Short: "Bindings of a core instance",
+}
+
+var bindingsListCmd = connectedCommand(&cobra.Command{
+	Use:   "list <instance>",
+	Short: "List the bindings",
+	Args:  cobra.ExactArgs(1),
+}, func(args []string) error {
+	list, err := bus.RpcCall[struct{}, []*bindingConfig](transport.Rpc(), args[0], "bindings.list", struct{}{}, timeout)
+	if err != nil {
+		return err
+	}
+
+	return printJson(list)
+})
+
+var bindingsStatusCmd = connectedCommand(&cobra.Command{
+	Use:   "status <instance>",
+	Short: "Show the status of the bindings (waiting-for-source, waiting-for-target, type-mismatch, active)",
+	Args:  cobra.ExactArgs(1),
+}, func(args []string) error {
+	list, err := bus.RpcCall[struct{}, []json.RawMessage](transport.Rpc(), args[0], "bindings.status", struct{}{}, timeout)
+	if err != nil {
+		return err
+	}
+
+	return printJson(list)
+})
+
+var bindingsAddCmd = connectedCommand(&cobra.Command{
+	Use:   "add <instance> <source-component>.<source-state> <target-component>.<target-action>",
+	Short: "Add a binding",
+	Args:  cobra.ExactArgs(3),
+}, func(args []string) error {
+	input, err := parseBinding(args[1], args[2])
+	if err != nil {
+		return err
+	}
+
+	if bindingTransforms != "" {
+		if !json.Valid([]byte(bindingTransforms)) {
+			return fmt.Errorf("invalid transforms JSON '%s'", bindingTransforms)
+		}
+
+		input.Transforms = json.RawMessage(bindingTransforms)
+	}
+
+	_, err = bus.RpcCall[*bindingConfig, struct{}](transport.Rpc(), args[0], "bindings.add", input, timeout)
+	return err
+})
+
+var bindingsRemoveCmd = connectedCommand(&cobra.Command{
+	Use:   "remove <instance> <source-component>.<source-state> <target-component>.<target-action>",
+	Short: "Remove a binding",
+	Args:  cobra.ExactArgs(3),
+}, func(args []string) error {
+	input, err := parseBinding(args[1], args[2])
+	if err != nil {
+		return err
+	}
+
+	_, err = bus.RpcCall[*bindingConfig, struct{}](transport.Rpc(), args[0], "bindings.remove", input, timeout)
+	return err
+})
+
+func parseBinding(source string, target string) (*bindingConfig, error) {
+	sourceComponent, sourceState, err := parseMember(source)
+	if err != nil {
+		return nil, err
+	}
+
+	targetComponent, targetAction, err := parseMember(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bindingConfig{
+		SourceComponent: sourceComponent,
+		SourceState:     sourceState,
+		TargetComponent: targetComponent,
+		TargetAction:    targetAction,
+	}, nil
+}
+
+// Note: component ids may contain dots, members cannot
+func parseMember(value string) (componentId string, member string, err error) {
+	index := strings.LastIndex(value, ".")
+	if index <= 0 || index == len(value)-1 {
+		return "", "", fmt.Errorf("invalid member '%s', expected <component>.<member>", value)
+	}
+
+	return value[:index], value[index+1:], nil
+}
+
+func init() {
+	bindingsAddCmd.Flags().StringVar(&bindingTransforms, "transforms", "", `transforms JSON array, eg: '[{"type":"scale","to":[0,100]}]'`)
+
+	bindingsCmd.AddCommand(bindingsListCmd)
+	bindingsCmd.AddCommand(bindingsStatusCmd)
+	bindingsCmd.AddCommand(bindingsAddCmd)
+	bindingsCmd.AddCommand(bindingsRemoveCmd)
+	rootCmd.AddCommand(bindingsCmd)
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"mylife-home-common/bus"
+)
+
+// Same as core store.ComponentConfig
+type componentConfig struct {
+	Id             string                     `json:"id"`
+	Plugin         string                     `json:"plugin"`
+	Config         map[string]json.RawMessage `json:"config"`
+	Fault          string                     `json:"fault,omitempty"`          // only in list, reason if the component is faulted
+	Health         *componentHealth           `json:"health,omitempty"`         // only in list
+	DroppedActions map[string]int64           `json:"droppedActions,omitempty"` // only in list, count of invalid values by action
+}
+
+// Same as common components.Health
+type componentHealth struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+type componentIdInput struct {
+	Id string `json:"id"`
+}
+
+var componentConfigItems []string
+var componentConfigFile string
+
+var componentsCmd = &cobra.Command{
+	Use:   "components",
+	Short: "Components of a core instance",
+}
+
+var componentsListCmd = connectedCommand(&cobra.Command{
+	Use:   "list <instance>",
+	Short: "List the components",
+	Args:  cobra.ExactArgs(1),
+}, func(args []string) error {
+	list, err := bus.RpcCall[struct{}, []*componentConfig](transport.Rpc(), args[0], "components.list", struct{}{}, timeout)
+	if err != nil {
+		return err
+	}
+
+	return printJson(list)
+})
+
+var componentsAddCmd = connectedCommand(&cobra.Command{
+	Use:   "add <instance> <id> <plugin>",
+	Short: "Add a component",
+	Long: `Add a component.
+
+Configuration is given with --set key=value (repeatable), and/or --config-file with a JSON object.
+Values are JSON, values that are not valid JSON are sent as strings.`,
+	Args: cobra.ExactArgs(3),
+}, func(args []string) error {
+	config, err := parseComponentConfig(nil)
+	if err != nil {
+		return err
+	}
+
+	input := &componentConfig{
+		Id:     args[1],
+		Plugin: args[2],
+		Config: config,
+	}
+
+	_, err = bus.RpcCall[*componentConfig, struct{}](transport.Rpc(), args[0], "components.add", input, timeout)
+	return err
+})
+
+var componentsUpdateCmd = connectedCommand(&cobra.Command{
+	Use:   "update <instance> <id>",
+	Short: "Update the configuration of a component, keeping its bindings",
+	Long: `Update the configuration of a component, keeping its bindings.
+
+Configuration is the current one of the component, with items given with --set key=value (repeatable), and/or --config-file with a JSON object.`,
+	Args: cobra.ExactArgs(2),
+}, func(args []string) error {
+	list, err := bus.RpcCall[struct{}, []*componentConfig](transport.Rpc(), args[0], "components.list", struct{}{}, timeout)
+	if err != nil {
+		return err
+	}
+
+	var current *componentConfig
+	for _, item := range list {
+		if item.Id == args[1] {
+			current = item
+		}
+	}
+
+	if current == nil {
+		return fmt.Errorf("component '%s' does not exist on instance '%s'", args[1], args[0])
+	}
+
+	config, err := parseComponentConfig(current.Config)
+	if err != nil {
+		return err
+	}
+
+	input := &componentConfig{
+		Id:     current.Id,
+		Plugin: current.Plugin,
+		Config: config,
+	}
+
+	_, err = bus.RpcCall[*componentConfig, struct{}](transport.Rpc(), args[0], "components.update", input, timeout)
+	return err
+})
+
+var componentsRemoveCmd = connectedCommand(&cobra.Command{
+	Use:   "remove <instance> <id>",
+	Short: "Remove a component",
+	Args:  cobra.ExactArgs(2),
+}, func(args []string) error {
+	input := componentIdInput{Id: args[1]}
+
+	_, err := bus.RpcCall[componentIdInput, struct{}](transport.Rpc(), args[0], "components.remove", input, timeout)
+	return err
+})
+
+var componentsRetryCmd = connectedCommand(&cobra.Command{
+	Use:   "retry <instance> <id>",
+	Short: "Create again a faulted component, with its stored configuration",
+	Args:  cobra.ExactArgs(2),
+}, func(args []string) error {
+	input := componentIdInput{Id: args[1]}
+
+	_, err := bus.RpcCall[componentIdInput, struct{}](transport.Rpc(), args[0], "components.retry", input, timeout)
+	return err
+})
+
+// Items override the ones of base (may be nil)
+func parseComponentConfig(base map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	config := make(map[string]json.RawMessage)
+	for key, value := range base {
+		config[key] = value
+	}
+
+	if componentConfigFile != "" {
+		data, err := os.ReadFile(componentConfigFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("invalid config file '%s': %w", componentConfigFile, err)
+		}
+	}
+
+	for _, item := range componentConfigItems {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid config item '%s', expected key=value", item)
+		}
+
+		if json.Valid([]byte(value)) {
+			config[key] = json.RawMessage(value)
+		} else {
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+
+			config[key] = raw
+		}
+	}
+
+	return config, nil
+}
+
+func init() {
+	componentsAddCmd.Flags().StringArrayVar(&componentConfigItems, "set", nil, "Configuration item, as key=value")
+	componentsAddCmd.Flags().StringVar(&componentConfigFile, "config-file", "", "JSON file with the configuration object")
+	componentsUpdateCmd.Flags().StringArrayVar(&componentConfigItems, "set", nil, "Configuration item, as key=value")
+	componentsUpdateCmd.Flags().StringVar(&componentConfigFile, "config-file", "", "JSON file with the configuration object")
+
+	componentsCmd.AddCommand(componentsListCmd)
+	componentsCmd.AddCommand(componentsAddCmd)
+	componentsCmd.AddCommand(componentsUpdateCmd)
+	componentsCmd.AddCommand(componentsRemoveCmd)
+	componentsCmd.AddCommand(componentsRetryCmd)
+	rootCmd.AddCommand(componentsCmd)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Presence messages are retained, and there is no way to know when all of them have been received:
+// the list is taken after this delay
+var instancesSettle time.Duration
+
+var instancesCmd = &cobra.Command{
+	Use:   "instances",
+	Short: "Instances on the bus",
+}
+
+var instancesListCmd = connectedCommand(&cobra.Command{
+	Use:   "list",
+	Short: "List online instances",
+	Args:  cobra.NoArgs,
+}, func(args []string) error {
+	time.Sleep(instancesSettle)
+
+	onlines := transport.Presence().GetOnlines()
+	slices.Sort(onlines)
+
+	for _, instanceName := range onlines {
+		fmt.Println(instanceName)
+	}
+
+	return nil
+})
+
+var instancesInfoCmd = connectedCommand(&cobra.Command{
+	Use:   "info <instance>",
+	Short: "Dump the instance-info metadata of an instance",
+	Args:  cobra.ExactArgs(1),
+}, func(args []string) error {
+	instanceName := args[0]
+
+	view, err := transport.Metadata().CreateView(instanceName)
+	if err != nil {
+		return err
+	}
+
+	defer transport.Metadata().CloseView(view)
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if info, exists := view.Values()["instance-info"]; exists {
+			return printJson(info)
+		}
+
+		time.Sleep(time.Millisecond * 50)
+	}
+
+	return fmt.Errorf("no instance-info found for instance '%s'", instanceName)
+})
+
+func init() {
+	instancesListCmd.Flags().DurationVar(&instancesSettle, "settle", time.Second, "Time to wait for the presence of the instances before listing them")
+
+	instancesCmd.AddCommand(instancesListCmd)
+	instancesCmd.AddCommand(instancesInfoCmd)
+	rootCmd.AddCommand(instancesCmd)
+}
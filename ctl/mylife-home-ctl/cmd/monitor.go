@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"mylife-home-ctl/pkg/recording"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+
+	"mylife-home-common/bus"
+	"mylife-home-common/components"
+	"mylife-home-common/defines"
+)
+
+var recordFile string
+
+var monitorCmd = connectedCommand(&cobra.Command{
+	Use:   "monitor [filter...]",
+	Short: "Print the messages on the bus, and optionally record them",
+	Long: `Print the messages on the bus (all topics, or the given filters), until interrupted.
+
+Component states and actions are decoded using the plugins metadata published by the instances.
+With --record, messages are also recorded to a file that can be replayed.`,
+}, func(args []string) error {
+	filters := args
+	if len(filters) == 0 {
+		filters = []string{"#"}
+	}
+
+	registry := components.NewRegistry()
+	listener := components.ListenBus(transport, registry)
+	defer listener.Terminate()
+
+	mon := &monitor{
+		decoder: &payloadDecoder{registry: registry},
+	}
+
+	if recordFile != "" {
+		file, err := os.Create(recordFile)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+		mon.writer = recording.NewWriter(file)
+	}
+
+	for _, filter := range filters {
+		if err := transport.Monitor().Subscribe(filter, mon.onMessage); err != nil {
+			return err
+		}
+	}
+
+	defer transport.Monitor().Unsubscribe(filters...)
+
+	channel := make(chan os.Signal, 1)
+	signal.Notify(channel, syscall.SIGINT, syscall.SIGTERM)
+	<-channel
+
+	return nil
+})
+
+type monitor struct {
+	decoder *payloadDecoder
+	writer  *recording.Writer
+	mux     sync.Mutex
+}
+
+// Note: called from one goroutine per filter
+func (mon *monitor) onMessage(m bus.Message) {
+	// Do not monitor ourself
+	if m.InstanceName() == defines.InstanceName() {
+		return
+	}
+
+	record := &recording.Record{
+		Time:     time.Now(),
+		Topic:    m.Topic(),
+		Payload:  m.Payload(),
+		Retained: m.Retained(),
+		Decoded:  mon.decoder.decode(m),
+	}
+
+	mon.mux.Lock()
+	defer mon.mux.Unlock()
+
+	printRecord(record)
+
+	if mon.writer != nil {
+		if err := mon.writer.Write(record); err != nil {
+			logger.WithError(err).Error("Could not write record")
+		}
+	}
+}
+
+func printRecord(record *recording.Record) {
+	flag := ""
+	if record.Retained {
+		flag = " (retained)"
+	}
+
+	fmt.Printf("%s %s%s = %s\n", record.Time.Format("15:04:05.000"), record.Topic, flag, record.Decoded)
+}
+
+type payloadDecoder struct {
+	registry components.Registry
+}
+
+func (decoder *payloadDecoder) decode(m bus.Message) (text string) {
+	payload := m.Payload()
+
+	if len(payload) == 0 {
+		return "<cleared>"
+	}
+
+	defer func() {
+		if err := recover(); err != nil {
+			text = fmt.Sprintf("<invalid payload: %x>", payload)
+		}
+	}()
+
+	switch m.Domain() {
+	case "components":
+		if value, ok := decoder.decodeMember(m); ok {
+			return formatValue(value)
+		}
+
+		return fmt.Sprintf("<unknown member: %x>", payload)
+
+	case "online":
+		return strconv.FormatBool(bus.Encoding.ReadBool(payload))
+	}
+
+	// metadata, rpc, logger, ... are JSON
+	if json.Valid(payload) {
+		return string(payload)
+	}
+
+	if utf8.Valid(payload) {
+		return strconv.Quote(string(payload))
+	}
+
+	return fmt.Sprintf("%x", payload)
+}
+
+// path is '<component>/<member>'
+func (decoder *payloadDecoder) decodeMember(m bus.Message) (any, bool) {
+	componentId, memberName, ok := strings.Cut(m.Path(), "/")
+	if !ok {
+		return nil, false
+	}
+
+	data := decoder.registry.GetComponentData(componentId)
+	if data == nil || data.InstanceName() != m.InstanceName() {
+		return nil, false
+	}
+
+	member := data.Component().Plugin().Member(memberName)
+	if member == nil {
+		return nil, false
+	}
+
+	return bus.Encoding.ReadValue(member.ValueType(), m.Payload()), true
+}
+
+func formatValue(value any) string {
+	switch value := value.(type) {
+	case string:
+		return strconv.Quote(value)
+	case bool, int64, float64:
+		return fmt.Sprintf("%v", value)
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprintf("%v", value)
+		}
+
+		return string(data)
+	}
+}
+
+func init() {
+	monitorCmd.Flags().StringVar(&recordFile, "record", "", "Record the messages to this file")
+	rootCmd.AddCommand(monitorCmd)
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"mylife-home-common/bus"
+)
+
+// Same as core projectApplyInput
+type projectApplyInput struct {
+	Components json.RawMessage `json:"components"`
+	Bindings   json.RawMessage `json:"bindings"`
+	Save       bool            `json:"save,omitempty"`
+	DryRun     bool            `json:"dryRun,omitempty"`
+}
+
+var projectSave bool
+var projectDryRun bool
+
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Project of a core instance",
+}
+
+var projectApplyCmd = connectedCommand(&cobra.Command{
+	Use:   "apply <instance> <project-file>",
+	Short: "Apply a project",
+	Long: `Make the components and bindings of the instance match the project, then print the changes.
+
+The project file is a JSON object: { "components": [...], "bindings": [...] }, with the same items as 'components list' and 'bindings list'.
+Either all changes are applied, or none.`,
+	Args: cobra.ExactArgs(2),
+}, func(args []string) error {
+	data, err := os.ReadFile(args[1])
+	if err != nil {
+		return err
+	}
+
+	input := &projectApplyInput{}
+	if err := json.Unmarshal(data, input); err != nil {
+		return fmt.Errorf("invalid project file '%s': %w", args[1], err)
+	}
+
+	input.Save = projectSave
+	input.DryRun = projectDryRun
+
+	diff, err := bus.RpcCall[*projectApplyInput, json.RawMessage](transport.Rpc(), args[0], "project.apply", input, timeout)
+	if err != nil {
+		return err
+	}
+
+	return printJson(diff)
+})
+
+func init() {
+	projectApplyCmd.Flags().BoolVar(&projectSave, "save", false, "Save the store once applied")
+	projectApplyCmd.Flags().BoolVar(&projectDryRun, "dry-run", false, "Only print the changes")
+
+	projectCmd.AddCommand(projectApplyCmd)
+	rootCmd.AddCommand(projectCmd)
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"mylife-home-ctl/pkg/recording"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"mylife-home-common/defines"
+)
+
+var replaySpeed float64
+var replayKeepRetained bool
+
+var replayCmd = connectedCommand(&cobra.Command{
+	Use:   "replay <file>",
+	Short: "Replay a recording on the bus",
+	Long: `Replay a recording made with 'monitor --record', with the original timing.
+
+Use --speed to accelerate (eg: 10 is 10 times faster, 0 is as fast as possible).
+Retained topics published during the replay are cleared at the end, unless --keep-retained is set.
+Point the config to a test broker: messages are published as if they came from the recorded instances.`,
+	Args: cobra.ExactArgs(1),
+}, func(args []string) error {
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	records, err := recording.Read(file)
+	if err != nil {
+		return err
+	}
+
+	retainedTopics := make(map[string]struct{})
+
+	if !replayKeepRetained {
+		defer clearRetained(retainedTopics)
+	}
+
+	var origin time.Time
+	start := time.Now()
+
+	for index, record := range records {
+		if index == 0 {
+			origin = record.Time
+		}
+
+		if replaySpeed > 0 {
+			offset := time.Duration(float64(record.Time.Sub(origin)) / replaySpeed)
+			time.Sleep(time.Until(start.Add(offset)))
+		}
+
+		// Do not conflict with our own instance
+		if instanceName, _, _ := strings.Cut(record.Topic, "/"); instanceName == defines.InstanceName() {
+			continue
+		}
+
+		if err := transport.Monitor().Publish(record.Topic, record.Payload, record.Retained); err != nil {
+			return err
+		}
+
+		if record.Retained {
+			retainedTopics[record.Topic] = struct{}{}
+		}
+
+		printRecord(&recording.Record{
+			Time:     time.Now(),
+			Topic:    record.Topic,
+			Payload:  record.Payload,
+			Retained: record.Retained,
+			Decoded:  record.Decoded,
+		})
+	}
+
+	return nil
+})
+
+func clearRetained(topics map[string]struct{}) {
+	for topic := range topics {
+		if err := transport.Monitor().Publish(topic, []byte{}, true); err != nil {
+			logger.WithError(err).Errorf("Could not clear retained topic '%s'", topic)
+		}
+	}
+}
+
+func init() {
+	replayCmd.Flags().Float64Var(&replaySpeed, "speed", 1, "Replay speed factor, 0 for as fast as possible")
+	replayCmd.Flags().BoolVar(&replayKeepRetained, "keep-retained", false, "Do not clear the retained topics at the end")
+	rootCmd.AddCommand(replayCmd)
+}
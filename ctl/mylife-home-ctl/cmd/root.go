@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"mylife-home-ctl/pkg/version"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"mylife-home-common/bus"
+	"mylife-home-common/config"
+	"mylife-home-common/defines"
+	"mylife-home-common/instance_info"
+	"mylife-home-common/log"
+)
+
+var logger = log.CreateLogger("mylife:home:ctl:main")
+
+var configFile string
+var logConsole bool
+var timeout time.Duration
+
+var transport *bus.Transport
+
+var rootCmd = &cobra.Command{
+	Use:          "mhctl",
+	Short:        "mhctl - Mylife Home command line client",
+	SilenceUsage: true,
+}
+
+// Commands that need the bus connection
+func connectedCommand(command *cobra.Command, run func(args []string) error) *cobra.Command {
+	command.RunE = func(_ *cobra.Command, args []string) error {
+		if err := connect(); err != nil {
+			return err
+		}
+
+		defer transport.Terminate()
+
+		return run(args)
+	}
+
+	return command
+}
+
+func connect() error {
+	log.Init(logConsole)
+	config.Init(configFile)
+	defines.Init("ctl", version.Value)
+	defines.MakeInstanceNameUnique()
+	instance_info.Init()
+
+	var err error
+	transport, err = bus.NewTransport()
+	if err != nil {
+		return err
+	}
+
+	if !waitOnline(timeout) {
+		transport.Terminate()
+		return fmt.Errorf("could not connect to the bus in %s", timeout)
+	}
+
+	return nil
+}
+
+func waitOnline(timeout time.Duration) bool {
+	onlineChan := make(chan bool, 10)
+	transport.Online().Subscribe(onlineChan, true)
+	defer transport.Online().Unsubscribe(onlineChan)
+
+	deadline := time.After(timeout)
+
+	for {
+		select {
+		case online := <-onlineChan:
+			if online {
+				return true
+			}
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+func printJson(value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(data))
+	return nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "config.yaml", "config file (default is $(PWD)/config.yaml)")
+	rootCmd.PersistentFlags().BoolVar(&logConsole, "log-console", false, "Log to console")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", time.Second*5, "Timeout for bus connection and RPC calls")
+}
+
+func Execute() {
+	err := rootCmd.Execute()
+	if err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/cobra"
+
+	"mylife-home-common/bus"
+)
+
+// Same as core storeHistoryDiff input
+type storeHistoryDiffInput struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Same as core storeHistoryRestore input
+type storeHistoryRestoreInput struct {
+	Id     string `json:"id"`
+	Save   bool   `json:"save,omitempty"`
+	DryRun bool   `json:"dryRun,omitempty"`
+}
+
+var storeRestoreSave bool
+var storeRestoreDryRun bool
+
+var storeCmd = &cobra.Command{
+	Use:   "store",
+	Short: "Store of a core instance",
+}
+
+var storeSaveCmd = connectedCommand(&cobra.Command{
+	Use:   "save <instance>",
+	Short: "Save the store",
+	Args:  cobra.ExactArgs(1),
+}, func(args []string) error {
+	_, err := bus.RpcCall[struct{}, struct{}](transport.Rpc(), args[0], "store.save", struct{}{}, timeout)
+	return err
+})
+
+var storeHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Snapshots of the store, taken on each save",
+}
+
+var storeHistoryListCmd = connectedCommand(&cobra.Command{
+	Use:   "list <instance>",
+	Short: "List snapshots, oldest first, with the changes from the previous one",
+	Args:  cobra.ExactArgs(1),
+}, func(args []string) error {
+	list, err := bus.RpcCall[struct{}, json.RawMessage](transport.Rpc(), args[0], "store.history.list", struct{}{}, timeout)
+	if err != nil {
+		return err
+	}
+
+	return printJson(list)
+})
+
+var storeHistoryDiffCmd = connectedCommand(&cobra.Command{
+	Use:   "diff <instance> <from-snapshot> [to-snapshot]",
+	Short: "Print the changes between two snapshots",
+	Long:  `Print the changes between two snapshots. Without 'to-snapshot', or with an empty id, the current content of the store is used.`,
+	Args:  cobra.RangeArgs(2, 3),
+}, func(args []string) error {
+	input := &storeHistoryDiffInput{From: args[1]}
+	if len(args) > 2 {
+		input.To = args[2]
+	}
+
+	diff, err := bus.RpcCall[*storeHistoryDiffInput, json.RawMessage](transport.Rpc(), args[0], "store.history.diff", input, timeout)
+	if err != nil {
+		return err
+	}
+
+	return printJson(diff)
+})
+
+var storeHistoryRestoreCmd = connectedCommand(&cobra.Command{
+	Use:   "restore <instance> <snapshot>",
+	Short: "Restore the components and bindings of a snapshot",
+	Long: `Make the components and bindings of the instance match the snapshot, then print the changes.
+Either all changes are applied, or none.`,
+	Args: cobra.ExactArgs(2),
+}, func(args []string) error {
+	input := &storeHistoryRestoreInput{
+		Id:     args[1],
+		Save:   storeRestoreSave,
+		DryRun: storeRestoreDryRun,
+	}
+
+	diff, err := bus.RpcCall[*storeHistoryRestoreInput, json.RawMessage](transport.Rpc(), args[0], "store.history.restore", input, timeout)
+	if err != nil {
+		return err
+	}
+
+	return printJson(diff)
+})
+
+func init() {
+	storeHistoryRestoreCmd.Flags().BoolVar(&storeRestoreSave, "save", false, "Save the store once restored")
+	storeHistoryRestoreCmd.Flags().BoolVar(&storeRestoreDryRun, "dry-run", false, "Only print the changes")
+
+	storeHistoryCmd.AddCommand(storeHistoryListCmd)
+	storeHistoryCmd.AddCommand(storeHistoryDiffCmd)
+	storeHistoryCmd.AddCommand(storeHistoryRestoreCmd)
+
+	storeCmd.AddCommand(storeSaveCmd)
+	storeCmd.AddCommand(storeHistoryCmd)
+	rootCmd.AddCommand(storeCmd)
+}
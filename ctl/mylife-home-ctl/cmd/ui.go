@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"mylife-home-common/bus"
+)
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "UI instance",
+}
+
+var uiSetDefinitionCmd = connectedCommand(&cobra.Command{
+	Use:   "set-definition <instance> <file>",
+	Short: "Upload the UI definition from a JSON file",
+	Args:  cobra.ExactArgs(2),
+}, func(args []string) error {
+	data, err := os.ReadFile(args[1])
+	if err != nil {
+		return err
+	}
+
+	if !json.Valid(data) {
+		return fmt.Errorf("invalid JSON in definition file '%s'", args[1])
+	}
+
+	_, err = bus.RpcCall[json.RawMessage, struct{}](transport.Rpc(), args[0], "definition.set", json.RawMessage(data), timeout)
+	return err
+})
+
+func init() {
+	uiCmd.AddCommand(uiSetDefinitionCmd)
+	rootCmd.AddCommand(uiCmd)
+}
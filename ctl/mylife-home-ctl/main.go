@@ -0,0 +1,9 @@
+package main
+
+import (
+	"mylife-home-ctl/cmd"
+)
+
+func main() {
+	cmd.Execute()
+}
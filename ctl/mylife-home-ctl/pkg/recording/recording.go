@@ -0,0 +1,74 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// One message recorded on the bus, stored as a JSON line
+type Record struct {
+	Time     time.Time `json:"time"`
+	Topic    string    `json:"topic"`
+	Payload  []byte    `json:"payload"`
+	Retained bool      `json:"retained,omitempty"`
+	Decoded  string    `json:"decoded,omitempty"` // human readable payload, informative only
+}
+
+type Writer struct {
+	writer *bufio.Writer
+}
+
+func NewWriter(writer io.Writer) *Writer {
+	return &Writer{
+		writer: bufio.NewWriter(writer),
+	}
+}
+
+func (writer *Writer) Write(record *Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if _, err := writer.writer.Write(data); err != nil {
+		return err
+	}
+
+	if err := writer.writer.WriteByte('\n'); err != nil {
+		return err
+	}
+
+	// Keep the file usable if the process is killed
+	return writer.writer.Flush()
+}
+
+func Read(reader io.Reader) ([]*Record, error) {
+	records := make([]*Record, 0)
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, 16*1024*1024)
+	line := 0
+
+	for scanner.Scan() {
+		line += 1
+
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		record := &Record{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			return nil, fmt.Errorf("invalid record at line %d: %w", line, err)
+		}
+
+		records = append(records, record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
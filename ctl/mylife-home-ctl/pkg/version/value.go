@@ -0,0 +1,3 @@
+package version
+
+var Value = "1.0.0"
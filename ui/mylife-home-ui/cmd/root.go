@@ -21,24 +21,29 @@ var configFile string
 var logConsole bool
 
 var rootCmd = &cobra.Command{
-	Use:   "mylife-home-ui",
-	Short: "mylife-home-ui - Mylife Home UI",
-	Run:   run,
+	Use:          "mylife-home-ui",
+	Short:        "mylife-home-ui - Mylife Home UI",
+	SilenceUsage: true,
+	RunE:         run,
 }
 
-func run(_ *cobra.Command, _ []string) {
+func run(_ *cobra.Command, _ []string) error {
 	log.Init(logConsole)
 	config.Init(configFile)
 	defines.Init("ui", version.Value)
 	instance_info.Init()
 
-	m := manager.MakeManager()
+	m, err := manager.MakeManager()
+	if err != nil {
+		return err
+	}
 
 	channel := make(chan os.Signal, 1)
 	signal.Notify(channel, syscall.SIGINT, syscall.SIGTERM)
 	<-channel
 
 	m.Terminate()
+	return nil
 }
 
 func init() {
@@ -20,10 +20,15 @@ type Manager struct {
 	webServer *web.WebServer
 }
 
-func MakeManager() *Manager {
+func MakeManager() (*Manager, error) {
 	manager := &Manager{}
 
-	manager.transport = bus.NewTransport()
+	transport, err := bus.NewTransport()
+	if err != nil {
+		return nil, err
+	}
+
+	manager.transport = transport
 	manager.registry = components.NewRegistry()
 	manager.model = model.NewModelManager()
 	manager.listener = components.ListenBus(manager.transport, manager.registry)
@@ -32,7 +37,7 @@ func MakeManager() *Manager {
 
 	instance_info.AddCapability("ui-manager")
 
-	return manager
+	return manager, nil
 }
 
 func (manager *Manager) Terminate() {
@@ -9,7 +9,6 @@ import (
 	"net/http"
 	"slices"
 	"sync"
-	"time"
 
 	"mylife-home-common/components"
 	"mylife-home-common/components/metadata"
@@ -297,9 +296,7 @@ func (m *Manager) executeAction(componentId string, actionName string) {
 
 	action := comp.Action(actionName)
 
+	// Note: the bus keeps the order of emitted actions
 	action <- true
-	// FIXME: Give some time for the action to be processed
-	// For now actions are emitted async, which may break the order
-	time.Sleep(100 * time.Millisecond)
 	action <- false
 }